@@ -0,0 +1,84 @@
+package adversary
+
+import (
+	"sync"
+	"time"
+)
+
+//Behaviour is what a node does in a scenario
+type Behaviour int
+
+const (
+	//Honest nodes follow the protocol
+	Honest Behaviour = iota
+	//Crashed nodes never answer
+	Crashed
+	//Invalid nodes deal shares that don't match their commitments
+	Invalid
+	//Delayed nodes hold each of their phases for the delay of the scenario, before they send its messages
+	Delayed
+)
+
+func (b Behaviour) String() string {
+	switch b {
+	case Crashed:
+		return "crashed"
+	case Invalid:
+		return "invalid"
+	case Delayed:
+		return "delayed"
+	default:
+		return "honest"
+	}
+}
+
+//Scenario gives the number of nodes of each behaviour. The last Crashed nodes of the roster crash, the Invalid
+//ones before them deal invalid shares and the Delayed ones before those are delayed. The root is always honest.
+type Scenario struct {
+	Crashed int
+	Invalid int
+	Delayed int
+	Delay   time.Duration
+}
+
+var scenario struct {
+	sync.RWMutex
+	*Scenario
+}
+
+//Set makes the nodes of every protocol run of the process behave as s says, nil makes them honest (the default)
+func Set(s *Scenario) {
+	scenario.Lock()
+	defer scenario.Unlock()
+	scenario.Scenario = s
+}
+
+//Of returns the behaviour of the node at index in a roster of nodes
+func Of(index int, nodes int) Behaviour {
+	scenario.RLock()
+	defer scenario.RUnlock()
+	s := scenario.Scenario
+	if s == nil || index <= 0 || index >= nodes {
+		return Honest
+	}
+	switch from := nodes - index; {
+	case from <= s.Crashed:
+		return Crashed
+	case from <= s.Crashed+s.Invalid:
+		return Invalid
+	case from <= s.Crashed+s.Invalid+s.Delayed:
+		return Delayed
+	}
+	return Honest
+}
+
+//Hold waits for the delay of the scenario if the node at index is delayed
+func Hold(index int, nodes int) {
+	if Of(index, nodes) != Delayed {
+		return
+	}
+	scenario.RLock()
+	delay := scenario.Delay
+	scenario.RUnlock()
+	time.Sleep(delay)
+}
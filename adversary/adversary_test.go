@@ -0,0 +1,37 @@
+package adversary
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOf(t *testing.T) {
+	defer Set(nil)
+	if Of(3, 10) != Honest {
+		t.Fatal("Nodes should be honest without a scenario")
+	}
+	Set(&Scenario{Crashed: 2, Invalid: 1, Delayed: 2, Delay: 10 * time.Millisecond})
+	expected := []Behaviour{Honest, Honest, Honest, Honest, Honest, Delayed, Delayed, Invalid, Crashed, Crashed}
+	for i, b := range expected {
+		if Of(i, len(expected)) != b {
+			t.Fatalf("Node %d is %s instead of %s", i, Of(i, len(expected)), b)
+		}
+	}
+
+	//the root stays honest whatever the scenario
+	Set(&Scenario{Crashed: 4})
+	if Of(0, 4) != Honest || Of(1, 4) != Crashed {
+		t.Fatal("Wrong behaviours", Of(0, 4), Of(1, 4))
+	}
+
+	Set(&Scenario{Delayed: 1, Delay: 20 * time.Millisecond})
+	start := time.Now()
+	Hold(2, 4)
+	if time.Since(start) > 10*time.Millisecond {
+		t.Fatal("An honest node shouldn't wait")
+	}
+	Hold(3, 4)
+	if time.Since(start) < 20*time.Millisecond {
+		t.Fatal("A delayed node should wait")
+	}
+}
@@ -0,0 +1,14 @@
+/*Package adversary makes nodes of the RandShare protocols misbehave, so that the simulations can measure what
+faults cost and when they stop a run.
+
+A Scenario given to Set applies to every protocol run of the process, the same in every process of a simulation
+since the nodes are picked by their index in the roster: the last ones crash, the ones before deal invalid
+shares and the ones before are delayed. The root, which starts the run, is always honest.
+
+The protocols ask Of for the behaviour of their node:
+	- a crashed node drops every message, as if it had stopped before the run
+	- an invalid dealer sends shares that don't match its commitments, so the honest nodes have to exclude it
+	- a delayed node calls Hold before it sends the messages of each phase
+Without a scenario every node is honest and Of costs a read lock.
+*/
+package adversary
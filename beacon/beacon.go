@@ -0,0 +1,656 @@
+package beacon
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dedis/student_17_randomness/randshare_with_pvss"
+	"github.com/dedis/student_17_randomness/sampling"
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/random"
+	"gopkg.in/dedis/crypto.v0/share"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+	"gopkg.in/dedis/onet.v1"
+	"gopkg.in/dedis/onet.v1/log"
+)
+
+func init() {
+	onet.GlobalProtocolRegister(Name, NewBeacon)
+}
+
+//NewBeacon initialises the tree and network
+func NewBeacon(n *onet.TreeNodeInstance) (onet.ProtocolInstance, error) {
+	t := &Beacon{
+		TreeNodeInstance: n,
+	}
+	err := t.RegisterHandlers(t.HandleD1, t.HandleR1, t.HandleC1)
+	return t, err
+}
+
+//Setup initializes the Beacon struct. suite is the name of the suite (see randsharepvss.SuiteByName), rounds the
+//number of outputs to produce and timeout the time given to each leader to reveal its secret. nodes must be the
+//size of the roster and less than a third of them may be faulty.
+func (b *Beacon) Setup(nodes int, faulty int, purpose string, time int64, suite string, rounds int, timeout time.Duration) error {
+	if nodes != len(b.Roster().List) {
+		return fmt.Errorf("%d nodes for a roster of %d", nodes, len(b.Roster().List))
+	}
+	if faulty < 0 || 3*faulty >= nodes {
+		return errors.New("Wrong number of faulty nodes")
+	}
+	if timeout <= 0 {
+		return errors.New("The timeout must be positive")
+	}
+	s, err := randsharepvss.SuiteByName(suite)
+	if err != nil {
+		return err
+	}
+	if b.Suite().String() != s.String() {
+		return fmt.Errorf("Roster keys are %s points, not %s", b.Suite().String(), s.String())
+	}
+	if rounds < 1 {
+		return errors.New("The beacon needs at least one round")
+	}
+	b.suite = s
+	b.nodes = nodes
+	b.faulty = faulty
+	b.threshold = faulty + 1
+	b.purpose = purpose
+	b.startingTime = time
+	b.rounds = rounds
+	b.timeout = timeout
+	b.X = b.Roster().Publics()
+	b.sessionID = randsharepvss.SessionID(b.suite, randsharepvss.Feldman, b.nodes, b.faulty, b.X, b.purpose, time)
+	b.H, _ = b.suite.Point().Pick(nil, b.suite.Cipher(b.sessionID))
+
+	b.dealt = make(map[int]bool)
+	b.first = make(map[int]Dealing)
+	b.dealings = make(map[int]*dealing)
+	b.round = 0
+	b.leaders = make(map[int]*dealing)
+	b.leader = make(map[int]int)
+	b.reveals = make(map[int]map[int]*R1)
+	b.recovers = make(map[int]map[int]*pvss.PubVerShare)
+	b.recovering = make(map[int]bool)
+	b.adopted = make(map[int]bool)
+	b.history = nil
+	b.Done = make(chan bool, 1)
+	return nil
+}
+
+//Start initiates the beacon from node 0
+func (b *Beacon) Start() error {
+	b.mutex.Lock()
+	d1, err := b.firstDeal()
+	b.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	return b.Broadcast(d1)
+}
+
+//deal picks a new secret and deals it to every node
+func (b *Beacon) deal() (Dealing, *dealing, error) {
+	b.secret = b.suite.Scalar().Pick(random.Stream)
+	encShares, pubPoly, err := pvss.EncShares(b.suite, b.H, b.X, b.secret, b.threshold)
+	if err != nil {
+		return Dealing{}, nil, err
+	}
+	B, commits := pubPoly.Info()
+	return Dealing{B: B, Commits: commits, Shares: encShares}, &dealing{pubPoly: pubPoly, encShares: encShares}, nil
+}
+
+//firstDeal stores our first dealing and returns the message announcing it
+func (b *Beacon) firstDeal() (*D1, error) {
+	msg, d, err := b.deal()
+	if err != nil {
+		return nil, err
+	}
+	b.dealt[b.Index()] = true
+	b.first[b.Index()] = msg
+	b.dealings[b.Index()] = d
+	return &D1{
+		SessionID: b.sessionID,
+		Purpose:   b.purpose,
+		Time:      b.startingTime,
+		Faulty:    b.faulty,
+		Suite:     b.suite.String(),
+		Rounds:    b.rounds,
+		Timeout:   int64(b.timeout / time.Millisecond),
+		Src:       b.Index(),
+		Dealing:   msg,
+	}, nil
+}
+
+//verify returns the dealing if it is well formed (see validDealing) and every encrypted share of msg is valid
+func (b *Beacon) verify(msg Dealing) (*dealing, bool) {
+	if err := validDealing(b.suite, msg, b.nodes, b.threshold); err != nil {
+		log.Lvlf2("Node %d: malformed dealing: %s", b.Index(), err)
+		return nil, false
+	}
+	pubPoly := share.NewPubPoly(b.suite, msg.B, msg.Commits)
+	if bad := randsharepvss.VerifyEncSharesParallel(b.suite, b.H, b.X, pubPoly, msg.Shares); bad != nil {
+		return nil, false
+	}
+	return &dealing{pubPoly: pubPoly, encShares: msg.Shares}, true
+}
+
+//HandleD1 stores the first dealings and starts the first round once every node dealt
+func (b *Beacon) HandleD1(deal StructD1) error {
+	msg := &deal.D1
+	var out []interface{}
+
+	b.mutex.Lock()
+	if b.nodes == 0 { //we need to setup the beacon and deal our first secret
+		if err := b.Setup(len(b.Roster().List), msg.Faulty, msg.Purpose, msg.Time, msg.Suite, msg.Rounds, time.Duration(msg.Timeout)*time.Millisecond); err != nil {
+			b.mutex.Unlock()
+			return err
+		}
+		d1, err := b.firstDeal()
+		if err != nil {
+			b.mutex.Unlock()
+			return err
+		}
+		out = append(out, d1)
+	}
+
+	if !b.validIndex(msg.Src) || !validSender(msg.Src, deal.TreeNode) {
+		b.mutex.Unlock()
+		if err := b.broadcast(out); err != nil {
+			return err
+		}
+		return fmt.Errorf("Wrong dealing sender %d", msg.Src)
+	}
+	if !bytes.Equal(msg.SessionID, b.sessionID) || b.dealt[msg.Src] {
+		b.mutex.Unlock()
+		return b.broadcast(out) //If the sessionID is not correct or we already got the dealing of that sender we don't deal with it
+	}
+	b.dealt[msg.Src] = true
+	if d, ok := b.verify(msg.Dealing); ok {
+		b.first[msg.Src] = msg.Dealing
+		b.dealings[msg.Src] = d
+	} else {
+		log.Lvlf2("Node %d: invalid dealing from %d", b.Index(), msg.Src)
+	}
+	var err error
+	if len(b.dealt) == b.nodes {
+		out, err = b.startRound(1, out)
+	}
+	b.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	return b.broadcast(out)
+}
+
+//broadcast sends the messages in order
+func (b *Beacon) broadcast(out []interface{}) error {
+	for _, msg := range out {
+		if err := b.Broadcast(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//startRound opens round r: it picks the leader and, if we are the leader, reveals our secret. It appends the
+//messages to send to out. The mutex must be held.
+func (b *Beacon) startRound(r int, out []interface{}) ([]interface{}, error) {
+	previous := b.sessionID
+	if r > 1 {
+		previous = b.history[r-2].Output
+	}
+	leader, err := b.pickLeader(previous)
+	if err != nil {
+		return out, err
+	}
+	b.round = r
+	b.leader[r] = leader
+	b.leaders[r] = b.dealings[leader]
+	delete(b.dealings, leader) //each secret is opened once
+
+	if leader == b.Index() {
+		if b.silent == nil || !b.silent(r) {
+			secret := b.secret //deal replaces it with the next one
+			next, _, err := b.deal()
+			if err != nil {
+				return out, err
+			}
+			reveal := &R1{SessionID: b.sessionID, Round: r, Src: b.Index(), Secret: secret, Next: next}
+			b.reveals[r] = map[int]*R1{b.Index(): reveal} //the next dealing is adopted with the reveal, as the others do
+			out = append(out, reveal)
+		}
+	}
+	if b.gaveUp(r) { //enough nodes already gave up on the leader
+		if out, err = b.recover(r, out); err != nil {
+			return out, err
+		}
+	}
+	b.timer = time.AfterFunc(b.timeout, func() { b.late(r) })
+	return b.progress(out)
+}
+
+//pickLeader draws the leader of the round following the one with output previous
+func (b *Beacon) pickLeader(previous []byte) (int, error) {
+	var holders []int
+	for i := range b.dealings {
+		holders = append(holders, i)
+	}
+	return drawLeader(previous, holders, b.history, b.faulty)
+}
+
+//drawLeader draws the leader of the round following history, whose last output is previous, among the holders of
+//a dealing. The leaders of the last faulty rounds are left out, unless no one else holds a dealing.
+func drawLeader(previous []byte, holders []int, history []*Round, faulty int) (int, error) {
+	recent := make(map[int]bool)
+	for k := len(history) - 1; k >= 0 && k >= len(history)-faulty; k-- {
+		recent[history[k].Leader] = true
+	}
+	var eligible, all []int
+	for _, i := range holders {
+		all = append(all, i)
+		if !recent[i] {
+			eligible = append(eligible, i)
+		}
+	}
+	if len(eligible) == 0 {
+		eligible = all
+	}
+	if len(eligible) == 0 {
+		return 0, errors.New("No node has a secret left to reveal")
+	}
+	sort.Ints(eligible)
+	i, err := sampling.NewSource(previous, "leader").Intn(len(eligible))
+	if err != nil {
+		return 0, err
+	}
+	return eligible[i], nil
+}
+
+//HandleR1 stores the reveal of a leader
+func (b *Beacon) HandleR1(reveal StructR1) error {
+	msg := &reveal.R1
+
+	b.mutex.Lock()
+	if !bytes.Equal(msg.SessionID, b.sessionID) || msg.Round < 1 || msg.Round+b.faulty < b.round || msg.Round > b.rounds {
+		b.mutex.Unlock()
+		return nil //If the sessionID is not correct or the leader may lead again we don't deal with the reveal
+	}
+	if err := b.validR1(msg, reveal.TreeNode); err != nil {
+		b.mutex.Unlock()
+		return err
+	}
+	if _, ok := b.reveals[msg.Round]; !ok {
+		b.reveals[msg.Round] = make(map[int]*R1)
+	}
+	if _, ok := b.reveals[msg.Round][msg.Src]; !ok {
+		b.reveals[msg.Round][msg.Src] = msg
+	}
+	b.adopt(msg.Round) //the round may have been recovered before the reveal arrived
+	out, err := b.progress(nil)
+	b.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	return b.broadcast(out)
+}
+
+//HandleC1 stores the decrypted shares of a leader's secret, and sends ours too so that the secret can be recovered
+func (b *Beacon) HandleC1(recover StructC1) error {
+	msg := &recover.C1
+
+	b.mutex.Lock()
+	if !bytes.Equal(msg.SessionID, b.sessionID) || msg.Round < 1 || msg.Round > b.rounds {
+		b.mutex.Unlock()
+		return nil //If the sessionID is not correct we don't deal with the message
+	}
+	if err := b.validC1(msg, recover.TreeNode); err != nil {
+		b.mutex.Unlock()
+		return err
+	}
+	if _, ok := b.recovers[msg.Round]; !ok {
+		b.recovers[msg.Round] = make(map[int]*pvss.PubVerShare)
+	}
+	if _, ok := b.recovers[msg.Round][msg.Src]; !ok {
+		b.recovers[msg.Round][msg.Src] = msg.Share
+	}
+	var out []interface{}
+	var err error
+	if msg.Round <= b.round && b.gaveUp(msg.Round) { //the round of a later message will be joined when it starts
+		out, err = b.recover(msg.Round, out)
+	}
+	if err == nil {
+		out, err = b.progress(out)
+	}
+	b.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	return b.broadcast(out)
+}
+
+//late is called when the timeout of round r expires and asks the others to recover the secret of the leader
+func (b *Beacon) late(r int) {
+	b.mutex.Lock()
+	if b.round != r || len(b.history) >= r {
+		b.mutex.Unlock()
+		return
+	}
+	log.Lvlf2("Node %d: leader %d of round %d is late", b.Index(), b.leader[r], r)
+	out, err := b.recover(r, nil)
+	if err == nil {
+		out, err = b.progress(out)
+	}
+	b.mutex.Unlock()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	if err := b.broadcast(out); err != nil {
+		log.Error(err)
+	}
+}
+
+//gaveUp tells if more than faulty other nodes sent their decrypted share of the secret of round r: one of them
+//at least is honest and its timeout expired, so we join the recovery. Fewer are ignored until our own timeout,
+//otherwise a single node could open the secret of every leader before it reveals it. The mutex must be held.
+func (b *Beacon) gaveUp(r int) bool {
+	others := len(b.recovers[r])
+	if _, ok := b.recovers[r][b.Index()]; ok {
+		others--
+	}
+	return others > b.faulty
+}
+
+//recover decrypts our share of the secret of the leader of round r and appends it to out, once per round. The mutex must be held.
+func (b *Beacon) recover(r int, out []interface{}) ([]interface{}, error) {
+	if b.recovering[r] {
+		return out, nil
+	}
+	b.recovering[r] = true
+	d := b.leaders[r]
+	me := b.Index()
+	decShare, err := pvss.DecShare(b.suite, b.H, b.X[me], d.pubPoly.Eval(me).V, b.Private(), d.encShares[me])
+	if err != nil {
+		return out, err
+	}
+	if _, ok := b.recovers[r]; !ok {
+		b.recovers[r] = make(map[int]*pvss.PubVerShare)
+	}
+	b.recovers[r][me] = decShare
+	return append(out, &C1{SessionID: b.sessionID, Round: r, Src: me, Share: decShare}), nil
+}
+
+//progress finishes the current round, and the following ones, as long as we have a valid reveal or enough
+//valid decrypted shares for them. The mutex must be held.
+func (b *Beacon) progress(out []interface{}) ([]interface{}, error) {
+	for b.round > len(b.history) {
+		r := b.round
+		leader := b.leader[r]
+		d := b.leaders[r]
+		B, commits := d.pubPoly.Info()
+		round := &Round{Index: r, Leader: leader, Dealing: Dealing{B: B, Commits: commits, Shares: d.encShares}}
+		if reveal, ok := b.reveals[r][leader]; ok && b.opens(r, reveal.Secret) {
+			round.Reveal = reveal.Secret
+			round.Secret = b.suite.Point().Mul(nil, reveal.Secret)
+		} else {
+			round.Secret, round.Decrypted = b.recoverSecret(r)
+			round.Recovered = true
+		}
+		if round.Secret == nil {
+			return out, nil //we wait for more messages
+		}
+
+		var err error
+		if out, err = b.finish(round, out); err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+//opens tells if secret is the one dealt by the leader of round r
+func (b *Beacon) opens(r int, secret abstract.Scalar) bool {
+	return b.suite.Point().Mul(b.H, secret).Equal(b.leaders[r].pubPoly.Commit())
+}
+
+//adopt makes the next dealing of the reveal of round r, once the round is over, the dealing of its leader,
+//whether its secret was revealed or recovered, and records the reveal and that dealing in the round. As the
+//leader of round r can't lead one of the following faulty rounds (see pickLeader), the nodes that get the reveal
+//before round r+faulty+1 starts end up with the same dealings. The mutex must be held.
+func (b *Beacon) adopt(r int) {
+	if b.adopted[r] || len(b.history) < r {
+		return
+	}
+	leader := b.leader[r]
+	reveal, ok := b.reveals[r][leader]
+	if !ok || !b.opens(r, reveal.Secret) {
+		return
+	}
+	b.adopted[r] = true
+	if next, ok := b.verify(reveal.Next); ok {
+		b.dealings[leader] = next
+		round := *b.history[r-1] //the rounds already returned by Rounds don't change
+		if round.Reveal == nil {
+			round.Reveal = reveal.Secret
+		}
+		round.Next = &reveal.Next
+		b.history[r-1] = &round
+	}
+}
+
+//recoverSecret returns G*s from the valid decrypted shares of round r and those shares, nil if there aren't
+//enough of them
+func (b *Beacon) recoverSecret(r int) (abstract.Point, []*pvss.PubVerShare) {
+	if len(b.recovers[r]) < b.threshold {
+		return nil, nil
+	}
+	d := b.leaders[r]
+	var shares []*share.PubShare
+	var decrypted []*pvss.PubVerShare
+	for src, decShare := range b.recovers[r] {
+		if decShare.S.I != src || pvss.VerifyDecShare(b.suite, nil, b.X[src], d.encShares[src], decShare) != nil {
+			delete(b.recovers[r], src) //the sender lied, its share is dropped
+			continue
+		}
+		shares = append(shares, &decShare.S)
+		decrypted = append(decrypted, decShare)
+	}
+	if len(shares) < b.threshold {
+		return nil, nil
+	}
+	secret, err := share.RecoverCommit(b.suite, shares, b.threshold, b.nodes)
+	if err != nil {
+		return nil, nil
+	}
+	return secret, decrypted
+}
+
+//finish records the output of round and starts the next one. The mutex must be held.
+func (b *Beacon) finish(round *Round, out []interface{}) ([]interface{}, error) {
+	b.timer.Stop()
+	r := round.Index
+	previous := b.sessionID
+	if r > 1 {
+		previous = b.history[r-2].Output
+	}
+	output, err := Output(previous, r, round.Secret)
+	if err != nil {
+		return out, err
+	}
+	round.Output = output
+	b.history = append(b.history, round)
+	b.adopt(r)
+	if b.finished != nil {
+		b.finished(round)
+	}
+	if r == b.rounds {
+		select {
+		case b.Done <- true:
+		default:
+		}
+		return out, nil
+	}
+	return b.startRound(r+1, out)
+}
+
+//Rounds returns the rounds done so far
+func (b *Beacon) Rounds() []*Round {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]*Round{}, b.history...)
+}
+
+//Output returns SHA256(previous || r || secret), the output of round r
+func Output(previous []byte, r int, secret abstract.Point) ([]byte, error) {
+	secretB, err := secret.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write(previous)
+	binary.Write(h, binary.LittleEndian, uint32(r))
+	h.Write(secretB)
+	return h.Sum(nil), nil
+}
+
+//VerifyChain checks that every round follows the previous one, the first one following sessionID, and that the
+//secret of every round is the one of its dealing: the revealed scalar s has to match the commitment H*s, or the
+//decrypted shares have to be those of the encrypted shares to the keys X.
+//The dealings are bound to the session: first holds the first dealing of every node (see FirstDealings), every
+//dealing has faulty+1 commitments, the leader of every round is drawn as the nodes do and the dealing it opens is
+//its first one or the Next of the last round it led.
+func VerifyChain(suite abstract.Suite, X []abstract.Point, sessionID []byte, faulty int, first map[int]Dealing, rounds []*Round) error {
+	if faulty < 0 || 3*faulty >= len(X) {
+		return errors.New("Wrong number of faulty nodes")
+	}
+	H, _ := suite.Point().Pick(nil, suite.Cipher(sessionID))
+	dealings := make(map[int]Dealing)
+	for i, d := range first {
+		if i < 0 || i >= len(X) {
+			return fmt.Errorf("First dealing of unknown node %d", i)
+		}
+		if err := verifyDealing(suite, H, X, faulty+1, d); err != nil {
+			return fmt.Errorf("First dealing of %d: %s", i, err)
+		}
+		dealings[i] = d
+	}
+	previous := sessionID
+	for k, round := range rounds {
+		if round.Index != k+1 {
+			return fmt.Errorf("Round %d is missing", k+1)
+		}
+		var holders []int
+		for i := range dealings {
+			holders = append(holders, i)
+		}
+		leader, err := drawLeader(previous, holders, rounds[:k], faulty)
+		if err != nil {
+			return fmt.Errorf("Round %d: %s", round.Index, err)
+		}
+		if round.Leader != leader {
+			return fmt.Errorf("Round %d is led by %d instead of %d", round.Index, round.Leader, leader)
+		}
+		if !sameDealing(round.Dealing, dealings[leader]) {
+			return fmt.Errorf("Round %d doesn't open the dealing of its leader", round.Index)
+		}
+		delete(dealings, leader)
+		if err := verifySecret(suite, H, X, faulty+1, round); err != nil {
+			return fmt.Errorf("Round %d: %s", round.Index, err)
+		}
+		output, err := Output(previous, round.Index, round.Secret)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(output, round.Output) {
+			return fmt.Errorf("Wrong output for round %d", round.Index)
+		}
+		if round.Next != nil {
+			if round.Reveal == nil {
+				return fmt.Errorf("Round %d adopts a next dealing without a reveal", round.Index)
+			}
+			if err := verifyDealing(suite, H, X, faulty+1, *round.Next); err != nil {
+				return fmt.Errorf("Next dealing of round %d: %s", round.Index, err)
+			}
+			dealings[leader] = *round.Next
+		}
+		previous = output
+	}
+	return nil
+}
+
+//verifyDealing checks that d is well formed, with threshold commitments, and that its encrypted shares are valid
+func verifyDealing(suite abstract.Suite, H abstract.Point, X []abstract.Point, threshold int, d Dealing) error {
+	if err := validDealing(suite, d, len(X), threshold); err != nil {
+		return err
+	}
+	pubPoly := share.NewPubPoly(suite, d.B, d.Commits)
+	if bad := randsharepvss.VerifyEncSharesParallel(suite, H, X, pubPoly, d.Shares); bad != nil {
+		return errors.New("Invalid encrypted shares")
+	}
+	return nil
+}
+
+//verifySecret checks that the secret of round is the one of its dealing, which has been verified (see
+//verifyDealing)
+func verifySecret(suite abstract.Suite, H abstract.Point, X []abstract.Point, threshold int, round *Round) error {
+	d := round.Dealing
+	if round.Secret == nil {
+		return errors.New("Incomplete round")
+	}
+	pubPoly := share.NewPubPoly(suite, d.B, d.Commits)
+	if round.Reveal != nil {
+		if !suite.Point().Mul(H, round.Reveal).Equal(pubPoly.Commit()) {
+			return errors.New("The revealed secret isn't the one committed to")
+		}
+		if !suite.Point().Mul(nil, round.Reveal).Equal(round.Secret) {
+			return errors.New("The secret isn't the revealed one")
+		}
+		return nil
+	}
+	seen := make(map[int]bool)
+	var shares []*share.PubShare
+	for _, decShare := range round.Decrypted {
+		i := decShare.S.I
+		if i < 0 || i >= len(X) || seen[i] || d.Shares[i].S.I != i {
+			return errors.New("Invalid decrypted share")
+		}
+		if pvss.VerifyDecShare(suite, nil, X[i], d.Shares[i], decShare) != nil {
+			return errors.New("Invalid decrypted share")
+		}
+		seen[i] = true
+		shares = append(shares, &decShare.S)
+	}
+	if len(shares) < threshold {
+		return errors.New("Not enough decrypted shares")
+	}
+	secret, err := share.RecoverCommit(suite, shares, threshold, len(X))
+	if err != nil {
+		return err
+	}
+	if !secret.Equal(round.Secret) {
+		return errors.New("The secret isn't the recovered one")
+	}
+	return nil
+}
+
+//FirstDealings returns the valid first dealings, ours included, by dealer: the chain of the rounds starts from
+//them (see VerifyChain)
+func (b *Beacon) FirstDealings() map[int]Dealing {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	first := make(map[int]Dealing)
+	for i, d := range b.first {
+		first[i] = d
+	}
+	return first
+}
+
+//SessionID returns the identifier of the beacon, which is the output of round 0
+func (b *Beacon) SessionID() []byte {
+	return b.sessionID
+}
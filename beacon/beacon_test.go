@@ -0,0 +1,365 @@
+package beacon
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dedis/student_17_randomness/randshare_with_pvss"
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/share"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+	"gopkg.in/dedis/onet.v1"
+	"gopkg.in/dedis/onet.v1/log"
+)
+
+//testName is the beacon whose nodes follow testHooks
+const testName = "BeaconTest"
+
+//testHooks lets the tests make nodes fail and watch every node, the nodes take them when they are created
+var testHooks struct {
+	silent   func(node int, round int) bool //the node doesn't reveal in that round
+	early    func(node int) bool            //the node sends its decrypted share as soon as a round starts
+	finished func(node int, r *Round)       //called when a node is done with a round
+}
+
+func init() {
+	onet.GlobalProtocolRegister(testName, func(n *onet.TreeNodeInstance) (onet.ProtocolInstance, error) {
+		p, err := NewBeacon(n)
+		if err != nil {
+			return nil, err
+		}
+		b := p.(*Beacon)
+		silent, early, finished := testHooks.silent, testHooks.early, testHooks.finished
+		if silent != nil {
+			b.silent = func(round int) bool { return silent(b.Index(), round) }
+		}
+		b.finished = func(r *Round) {
+			if finished != nil {
+				finished(b.Index(), r)
+			}
+			if early != nil && early(b.Index()) && r.Index < b.rounds {
+				go recoverEarly(b, r.Index+1)
+			}
+		}
+		return b, nil
+	})
+}
+
+//recoverEarly makes b send its decrypted share of the secret of round r without waiting for the leader
+func recoverEarly(b *Beacon, r int) {
+	b.mutex.Lock()
+	out, err := b.recover(r, nil)
+	b.mutex.Unlock()
+	if err == nil {
+		err = b.broadcast(out)
+	}
+	if err != nil {
+		log.Lvl2(err)
+	}
+}
+
+//runBeacon runs rounds rounds of the beacon on nodes local nodes, faulty of them being possibly faulty, and
+//returns the rounds seen by every node
+func runBeacon(t *testing.T, nodes int, faulty int, rounds int) (*Beacon, map[int][]*Round) {
+	var mutex sync.Mutex
+	seen := make(map[int][]*Round)
+	testHooks.finished = func(node int, r *Round) {
+		mutex.Lock()
+		seen[node] = append(seen[node], r)
+		mutex.Unlock()
+	}
+	defer func() { testHooks.finished = nil }()
+
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol(testName, tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	b := protocol.(*Beacon)
+	if err = b.Setup(nodes, faulty, "Beacon test run", time.Now().Unix(), randsharepvss.Ed25519, rounds, 500*time.Millisecond); err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	if err = b.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-b.Done:
+	case <-time.After(time.Second * time.Duration(rounds) * 2):
+		t.Fatal("Beacon timeout")
+	}
+	//the other nodes may finish a bit later
+	for k := 0; k < 100; k++ {
+		mutex.Lock()
+		done := 0
+		for _, rs := range seen {
+			if len(rs) == rounds {
+				done++
+			}
+		}
+		mutex.Unlock()
+		if done == nodes {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	return b, seen
+}
+
+//checkAgreement checks that every node saw the same valid chain of outputs
+func checkAgreement(t *testing.T, b *Beacon, seen map[int][]*Round, nodes int, rounds int) {
+	if len(seen) != nodes {
+		t.Fatal("Some nodes didn't finish any round", len(seen))
+	}
+	reference := b.Rounds()
+	if err := VerifyChain(b.suite, b.X, b.SessionID(), b.faulty, b.FirstDealings(), reference); err != nil {
+		t.Fatal(err)
+	}
+	for node, rs := range seen {
+		if len(rs) != rounds {
+			t.Fatalf("Node %d did %d rounds out of %d", node, len(rs), rounds)
+		}
+		for k, r := range rs {
+			if !bytes.Equal(r.Output, reference[k].Output) || r.Leader != reference[k].Leader {
+				t.Fatalf("Node %d disagrees on round %d", node, k+1)
+			}
+		}
+	}
+}
+
+func TestBeacon(t *testing.T) {
+	nodes, rounds := 7, 10
+	b, seen := runBeacon(t, nodes, nodes/3, rounds)
+	checkAgreement(t, b, seen, nodes, rounds)
+	for _, r := range b.Rounds() {
+		if r.Recovered {
+			t.Fatal("An honest leader had its secret recovered in round", r.Index)
+		}
+		log.Lvlf2("Round %d led by %d: %x", r.Index, r.Leader, r.Output)
+	}
+}
+
+func TestBeaconSilentLeader(t *testing.T) {
+	testHooks.silent = func(node int, round int) bool { return round == 2 }
+	defer func() { testHooks.silent = nil }()
+
+	nodes, rounds := 7, 8
+	b, seen := runBeacon(t, nodes, nodes/3, rounds)
+	checkAgreement(t, b, seen, nodes, rounds)
+	history := b.Rounds()
+	if !history[1].Recovered {
+		t.Fatal("The secret of the silent leader wasn't recovered")
+	}
+	for _, r := range history[2:] {
+		if r.Leader == history[1].Leader {
+			t.Fatal("The silent leader led again in round", r.Index)
+		}
+	}
+}
+
+func TestBeaconEarlyRecover(t *testing.T) {
+	//as many nodes as may be faulty ask to recover every leader's secret as soon as its round starts
+	testHooks.early = func(node int) bool { return node == 1 || node == 2 }
+	defer func() { testHooks.early = nil }()
+
+	nodes, rounds := 7, 6
+	b, seen := runBeacon(t, nodes, nodes/3, rounds)
+	checkAgreement(t, b, seen, nodes, rounds)
+	for node, rs := range seen {
+		for _, r := range rs {
+			if r.Recovered {
+				t.Fatalf("Node %d recovered the secret of the honest leader of round %d", node, r.Index)
+			}
+		}
+	}
+}
+
+//TestBeaconFaulty runs the beacon with fewer faulty nodes than nodes/3, the other nodes must take the number of
+//the root from its dealing
+func TestBeaconFaulty(t *testing.T) {
+	nodes, faulty, rounds := 7, 1, 6
+	b, seen := runBeacon(t, nodes, faulty, rounds)
+	checkAgreement(t, b, seen, nodes, rounds)
+	for _, r := range b.Rounds() {
+		if len(r.Dealing.Commits) != faulty+1 {
+			t.Fatal("Wrong threshold in round", r.Index, len(r.Dealing.Commits))
+		}
+	}
+}
+
+//TestSetup checks that Setup refuses the parameters a D1 could set wrongly
+func TestSetup(t *testing.T) {
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(4, true)
+	defer local.CloseAll()
+	protocol, err := local.CreateProtocol(Name, tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	b := protocol.(*Beacon)
+	defer b.TreeNodeInstance.Done()
+	for _, c := range []struct {
+		nodes, faulty int
+		timeout       time.Duration
+	}{{5, 1, time.Second}, {3, 0, time.Second}, {4, 2, time.Second}, {4, -1, time.Second}, {4, 1, 0}, {4, 1, -time.Second}} {
+		if err := b.Setup(c.nodes, c.faulty, "setup", 1, randsharepvss.Ed25519, 1, c.timeout); err == nil {
+			t.Fatal("Setup accepted", c.nodes, "nodes,", c.faulty, "faulty and a timeout of", c.timeout)
+		}
+	}
+	if err := b.Setup(4, 1, "setup", 1, randsharepvss.Ed25519, 1, time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyChain(t *testing.T) {
+	suite, _ := randsharepvss.SuiteByName(randsharepvss.Ed25519)
+	nodes, faulty := 4, 1
+	x := make([]abstract.Scalar, nodes)
+	X := make([]abstract.Point, nodes)
+	for i := range x {
+		x[i] = suite.NewKey(nil)
+		X[i] = suite.Point().Mul(nil, x[i])
+	}
+	sessionID := []byte("session")
+	H, _ := suite.Point().Pick(nil, suite.Cipher(sessionID))
+
+	//deal deals secret to X, it returns the dealing and its public polynomial
+	deal := func(secret abstract.Scalar, threshold int) (Dealing, *share.PubPoly) {
+		encShares, pubPoly, err := pvss.EncShares(suite, H, X, secret, threshold)
+		if err != nil {
+			t.Fatal(err)
+		}
+		B, commits := pubPoly.Info()
+		return Dealing{B: B, Commits: commits, Shares: encShares}, pubPoly
+	}
+	first := make(map[int]Dealing)
+	firstSecrets := make(map[int]abstract.Scalar)
+	firstPolys := make(map[int]*share.PubPoly)
+	for i := 0; i < nodes; i++ {
+		firstSecrets[i] = suite.NewKey(nil)
+		first[i], firstPolys[i] = deal(firstSecrets[i], faulty+1)
+	}
+	//rehash sets the outputs of rounds again, from the first one
+	rehash := func(rounds []*Round) {
+		previous := sessionID
+		for _, round := range rounds {
+			round.Output, _ = Output(previous, round.Index, round.Secret)
+			previous = round.Output
+		}
+	}
+
+	//chain runs the beacon from the first dealings as the nodes do, the secret of the second round is recovered
+	//from its decrypted shares and the other leaders reveal theirs with their next dealing
+	chain := func(rounds int) []*Round {
+		dealings, secrets, polys := make(map[int]Dealing), make(map[int]abstract.Scalar), make(map[int]*share.PubPoly)
+		for i := range first {
+			dealings[i], secrets[i], polys[i] = first[i], firstSecrets[i], firstPolys[i]
+		}
+		var history []*Round
+		previous := sessionID
+		for k := 0; k < rounds; k++ {
+			var holders []int
+			for i := range dealings {
+				holders = append(holders, i)
+			}
+			leader, err := drawLeader(previous, holders, history, faulty)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := dealings[leader]
+			delete(dealings, leader)
+			round := &Round{Index: k + 1, Leader: leader, Dealing: d, Secret: suite.Point().Mul(nil, secrets[leader])}
+			if k == 1 {
+				round.Recovered = true
+				for i := 0; i < faulty+1; i++ {
+					decShare, err := pvss.DecShare(suite, H, X[i], polys[leader].Eval(i).V, x[i], d.Shares[i])
+					if err != nil {
+						t.Fatal(err)
+					}
+					round.Decrypted = append(round.Decrypted, decShare)
+				}
+			} else {
+				round.Reveal = secrets[leader]
+				secrets[leader] = suite.NewKey(nil)
+				next, poly := deal(secrets[leader], faulty+1)
+				round.Next = &next
+				dealings[leader], polys[leader] = next, poly
+			}
+			if round.Output, err = Output(previous, round.Index, round.Secret); err != nil {
+				t.Fatal(err)
+			}
+			history = append(history, round)
+			previous = round.Output
+		}
+		return history
+	}
+	rounds := chain(6)
+	if err := VerifyChain(suite, X, sessionID, faulty, first, rounds); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyChain(suite, X, sessionID, faulty, first, rounds[1:]); err == nil {
+		t.Fatal("VerifyChain accepted a chain without its first round")
+	}
+	if err := VerifyChain(suite, X, sessionID, faulty-1, first, rounds); err == nil {
+		t.Fatal("VerifyChain accepted dealings with another threshold")
+	}
+
+	//outputs that hash consistently but secrets that aren't the dealt ones
+	for _, k := range []int{0, 1} {
+		forged := chain(6)
+		forged[k].Reveal = suite.NewKey(nil)
+		forged[k].Secret = suite.Point().Mul(nil, forged[k].Reveal)
+		rehash(forged)
+		if err := VerifyChain(suite, X, sessionID, faulty, first, forged); err == nil {
+			t.Fatal("VerifyChain accepted a secret that wasn't dealt in round", k+1)
+		}
+	}
+
+	//a valid dealing and its secret, but not the one the leader committed to
+	forged := chain(6)
+	secret := suite.NewKey(nil)
+	forged[0].Dealing, _ = deal(secret, faulty+1)
+	forged[0].Reveal = secret
+	forged[0].Secret = suite.Point().Mul(nil, secret)
+	rehash(forged)
+	if err := VerifyChain(suite, X, sessionID, faulty, first, forged); err == nil {
+		t.Fatal("VerifyChain accepted a dealing that wasn't adopted")
+	}
+
+	//a single node opening dealings of its own with a threshold of 1, each one committed in the previous round
+	forged = nil
+	secret = suite.NewKey(nil)
+	d, _ := deal(secret, 1)
+	for k := 0; k < 3; k++ {
+		next := suite.NewKey(nil)
+		nextDealing, _ := deal(next, 1)
+		forged = append(forged, &Round{Index: k + 1, Dealing: d, Reveal: secret, Secret: suite.Point().Mul(nil, secret), Next: &nextDealing})
+		secret, d = next, nextDealing
+	}
+	rehash(forged)
+	if err := VerifyChain(suite, X, sessionID, faulty, map[int]Dealing{0: forged[0].Dealing}, forged); err == nil {
+		t.Fatal("VerifyChain accepted the dealings of a single node with a threshold of 1")
+	}
+
+	forged = chain(6)
+	forged[0].Leader = (forged[0].Leader + 1) % nodes
+	if err := VerifyChain(suite, X, sessionID, faulty, first, forged); err == nil {
+		t.Fatal("VerifyChain accepted a leader that wasn't drawn")
+	}
+	forged = chain(6)
+	forged[1].Next = forged[0].Next
+	if err := VerifyChain(suite, X, sessionID, faulty, first, forged); err == nil {
+		t.Fatal("VerifyChain accepted a next dealing without a reveal")
+	}
+
+	rounds[1].Decrypted = rounds[1].Decrypted[:faulty]
+	if err := VerifyChain(suite, X, sessionID, faulty, first, rounds); err == nil {
+		t.Fatal("VerifyChain accepted a secret recovered from too few shares")
+	}
+}
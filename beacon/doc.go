@@ -0,0 +1,41 @@
+/*Package beacon is a continuous randomness beacon in the style of HydRand, on top of the PVSS of randsharepvss.
+The protocol has three messages:
+	- the deal D1 which is used once by every node to commit to its first secret with PVSS
+	- the reveal R1 which is used by the leader of a round to open its secret and deal the next one
+	- the recover C1 which is used to broadcast decrypted shares when the leader didn't reveal in time
+
+In round r the leader is drawn (see sampling) from the output of round r-1 among the nodes that have a
+committed secret and didn't lead one of the last faulty rounds. It reveals the scalar s it dealt, which is
+checked against the commitment H*s of its dealing, and the output is SHA256(output(r-1) || r || G*s). If no
+reveal arrives before the timeout, the nodes decrypt their share of the leader's dealing and recover G*s
+from faulty+1 of them: the output is the same, and the leader, who didn't deal a new secret, never leads again.
+A node sends its decrypted share once its own timeout expired or once more than faulty others sent theirs, so
+that the faulty nodes alone can't open a secret before its leader reveals it. Whether the secret was revealed
+or recovered, the next dealing of a leader is the one of its reveal, which is taken until its leader may lead
+again, faulty rounds later.
+A round costs one reveal carrying n encrypted shares, or n broadcasts of one decrypted share, i.e. O(n^2),
+instead of the n dealings of randsharepvss.
+
+Unlike HydRand there is no confirmation phase: nodes assume that a reveal received by one honest node
+reaches all of them within faulty rounds, otherwise they may disagree on whether the leader keeps a secret.
+
+Every message is checked by validate.go before it is used: the sender must be a node of the beacon and the one
+the message claims, the points of a dealing must be set and decode in the suite, with threshold commitments and
+one encrypted share per node, and a decrypted share must be the sender's. fuzz_test.go holds FuzzRounds, which
+plays every node but the root round after round: the leaders reveal, stay silent, reveal late or badly, and the
+nodes send messages in the name of others. The handlers must not panic, the outputs must not change and the
+rounds done must pass VerifyChain.
+
+VerifyChain checks a list of rounds: the outputs, and the secret of every round against the dealing opened in
+it. It starts from the first dealings (see FirstDealings) and binds every round to them: the dealings have
+faulty+1 commitments, the leader is drawn as the nodes draw it, and the dealing it opens is its first one or the
+one it adopted with its last reveal (Round.Next).
+
+The package uses these files:
+- struct.go defines the messages, the rounds and the state of a node
+- beacon.go defines the actions for each message, the leader draw and VerifyChain
+- validate.go checks the incoming messages and compares the dealings
+- beacon_test.go runs the beacon in a local test, with silent leaders and early recoveries, and checks VerifyChain
+- fuzz_test.go holds FuzzRounds
+*/
+package beacon
@@ -0,0 +1,214 @@
+package beacon
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/dedis/student_17_randomness/randshare_with_pvss"
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+	"gopkg.in/dedis/onet.v1"
+)
+
+//fuzzName is the beacon of FuzzRounds: the root is a real node, the others are played by the test and their
+//instances drop what the root sends them
+const fuzzName = "BeaconFuzz"
+
+//fuzzPeer is the instance of a node played by the test, it drops what the root sends
+type fuzzPeer struct {
+	*onet.TreeNodeInstance
+}
+
+//Start does nothing, the test sends the messages of the peer
+func (p *fuzzPeer) Start() error {
+	return nil
+}
+
+func init() {
+	onet.GlobalProtocolRegister(fuzzName, func(n *onet.TreeNodeInstance) (onet.ProtocolInstance, error) {
+		if n.IsRoot() {
+			return NewBeacon(n)
+		}
+		p := &fuzzPeer{TreeNodeInstance: n}
+		return p, p.RegisterHandlers(func(StructD1) error { return nil }, func(StructR1) error { return nil }, func(StructC1) error { return nil })
+	})
+}
+
+//fuzzDealing is a dealing of a peer with the secret it opens and the decrypted share of every node
+type fuzzDealing struct {
+	secret    abstract.Scalar
+	dealing   Dealing
+	decShares []*pvss.PubVerShare
+}
+
+//fuzzBeacon is a beacon whose peers, every node but the root, are played by the test: each peer has its first
+//dealing and the next ones it reveals in the rounds it leads, made once for every run
+type fuzzBeacon struct {
+	nodes, faulty, rounds int
+	tree                  *onet.Tree
+	sessionID             []byte
+	dealings              map[int][]*fuzzDealing
+}
+
+func newFuzzBeacon(t testing.TB, local *onet.LocalTest, servers []*onet.Server, tree *onet.Tree, rounds int) *fuzzBeacon {
+	suite, _ := randsharepvss.SuiteByName(randsharepvss.Ed25519)
+	nodes := len(servers)
+	fb := &fuzzBeacon{nodes: nodes, faulty: (nodes - 1) / 3, rounds: rounds, tree: tree, dealings: make(map[int][]*fuzzDealing)}
+	X := tree.Roster.Publics()
+	fb.sessionID = randsharepvss.SessionID(suite, randsharepvss.Feldman, nodes, fb.faulty, X, "fuzz", 1)
+	H, _ := suite.Point().Pick(nil, suite.Cipher(fb.sessionID))
+	for p := 1; p < nodes; p++ {
+		for k := 0; k <= rounds; k++ {
+			d := &fuzzDealing{secret: suite.NewKey(nil)}
+			encShares, pubPoly, err := pvss.EncShares(suite, H, X, d.secret, fb.faulty+1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for j := 0; j < nodes; j++ {
+				decShare, err := pvss.DecShare(suite, H, X[j], pubPoly.Eval(j).V, local.GetPrivate(servers[j]), encShares[j])
+				if err != nil {
+					t.Fatal(err)
+				}
+				d.decShares = append(d.decShares, decShare)
+			}
+			B, commits := pubPoly.Info()
+			d.dealing = Dealing{B: B, Commits: commits, Shares: encShares}
+			fb.dealings[p] = append(fb.dealings[p], d)
+		}
+	}
+	return fb
+}
+
+//from returns the tree node of peer p, the one the messages of p come from
+func (fb *fuzzBeacon) from(p int) *onet.TreeNode {
+	for _, node := range fb.tree.List() {
+		if node.RosterIndex == p {
+			return node
+		}
+	}
+	return nil
+}
+
+//run sets b up, gives it the first dealing of every peer and then plays the round led by a peer for every byte
+//of ops, as long as the beacon has rounds left. used[p] is the dealing of peer p the beacon holds.
+func (fb *fuzzBeacon) run(t *testing.T, b *Beacon, ops []byte) {
+	if err := b.Setup(fb.nodes, fb.faulty, "fuzz", 1, randsharepvss.Ed25519, fb.rounds, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for p := 1; p < fb.nodes; p++ {
+		msg := D1{SessionID: fb.sessionID, Faulty: fb.faulty, Src: p, Dealing: fb.dealings[p][0].dealing}
+		b.HandleD1(StructD1{TreeNode: fb.from(p), D1: msg})
+	}
+
+	used := make(map[int]int)
+	applied := make(map[int]bool) //the rounds whose next dealing is counted in used
+	var outputs [][]byte
+	for _, v := range ops {
+		b.mutex.Lock()
+		r, leader := b.round, b.leader[b.round]
+		done := len(b.history) == b.rounds
+		b.mutex.Unlock()
+		if done || leader == 0 { //our node reveals by itself, the round is over once Start or a handler returns
+			break
+		}
+		fb.play(b, r, leader, used[leader], v)
+
+		rounds := b.Rounds()
+		for i, output := range outputs {
+			if !bytes.Equal(output, rounds[i].Output) {
+				t.Fatal("The output of round", i+1, "changed")
+			}
+		}
+		for _, round := range rounds[len(outputs):] {
+			outputs = append(outputs, round.Output)
+		}
+		for _, round := range rounds {
+			if round.Next != nil && !applied[round.Index] {
+				applied[round.Index] = true
+				used[round.Leader]++
+			}
+		}
+	}
+
+	if err := VerifyChain(b.suite, b.X, b.SessionID(), b.faulty, b.FirstDealings(), b.Rounds()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//play gives b the messages of round r, led by peer leader whose current dealing is its used-th one. v picks them:
+//  - bit 0: the leader reveals, otherwise (v>>3)&3 of the other peers send their decrypted share of its secret
+//  - bit 1: the next dealing of the reveal misses a commitment, it isn't adopted
+//  - bit 2: the leader first sends its reveal under an index out of the roster
+//  - bit 5: the peers send the decrypted share of the next peer instead of their own
+//  - bit 6: the leader reveals late, after the decrypted shares
+//  - bit 7: another peer first sends a reveal and a decrypted share in the name of the leader
+func (fb *fuzzBeacon) play(b *Beacon, r int, leader int, used int, v byte) {
+	current, next := fb.dealings[leader][used], fb.dealings[leader][used+1]
+	reveal := R1{SessionID: fb.sessionID, Round: r, Src: leader, Secret: current.secret, Next: next.dealing}
+	if v&2 != 0 {
+		reveal.Next.Commits = append([]abstract.Point{nil}, reveal.Next.Commits[1:]...)
+	}
+	other := leader%(fb.nodes-1) + 1
+	if v&128 != 0 {
+		forged := reveal
+		forged.Secret = fb.dealings[other][0].secret
+		b.HandleR1(StructR1{TreeNode: fb.from(other), R1: forged})
+		share := C1{SessionID: fb.sessionID, Round: r, Src: leader, Share: current.decShares[leader]}
+		b.HandleC1(StructC1{TreeNode: fb.from(other), C1: share})
+	}
+	if v&4 != 0 {
+		wrong := reveal
+		wrong.Src = fb.nodes
+		b.HandleR1(StructR1{TreeNode: fb.from(leader), R1: wrong})
+	}
+	if v&1 != 0 {
+		b.HandleR1(StructR1{TreeNode: fb.from(leader), R1: reveal})
+		return
+	}
+	recovering := int(v>>3) & 3
+	for p := 1; p < fb.nodes && recovering > 0; p++ {
+		if p == leader {
+			continue
+		}
+		share := C1{SessionID: fb.sessionID, Round: r, Src: p, Share: current.decShares[p]}
+		if v&32 != 0 {
+			share.Share = current.decShares[p%(fb.nodes-1)+1]
+		}
+		b.HandleC1(StructC1{TreeNode: fb.from(p), C1: share})
+		recovering--
+	}
+	if v&64 != 0 {
+		b.HandleR1(StructR1{TreeNode: fb.from(leader), R1: reveal})
+	}
+}
+
+//FuzzRounds runs the rounds of a beacon whose peers reveal, stay silent and get recovered, reveal late or send
+//messages in the name of others, as the fuzz input says, one byte per round led by a peer. The handlers must not
+//panic, the outputs must not change and the rounds done must make a chain that VerifyChain accepts.
+func FuzzRounds(f *testing.F) {
+	local := onet.NewLocalTest()
+	servers, _, tree := local.GenTree(4, true)
+	defer local.CloseAll()
+	fb := newFuzzBeacon(f, local, servers, tree, 4)
+
+	f.Add([]byte{1, 1, 1, 1})
+	f.Add([]byte{16, 1, 1, 1})
+	f.Add([]byte{8, 8, 64, 1, 1})
+	f.Add([]byte{3, 16, 1, 1})
+	f.Add([]byte{129, 128 | 16, 64 | 16, 1})
+	f.Add([]byte{5, 32 | 16, 16, 1})
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		protocol, err := local.CreateProtocol(fuzzName, tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := protocol.(*Beacon)
+		defer b.TreeNodeInstance.Done()
+		fb.run(t, b, ops)
+	})
+}
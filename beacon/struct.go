@@ -0,0 +1,133 @@
+package beacon
+
+import (
+	"sync"
+	"time"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/share"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+	"gopkg.in/dedis/onet.v1"
+	"gopkg.in/dedis/onet.v1/network"
+)
+
+//Name can be used from other packages to refer to this protocol.
+const Name = "Beacon"
+
+//init registers the messages
+func init() {
+	for _, p := range []interface{}{D1{}, R1{}, C1{}, StructD1{}, StructR1{}, StructC1{}} {
+		network.RegisterMessage(p)
+	}
+}
+
+//Dealing is a PVSS of one secret to every node
+type Dealing struct {
+	B       abstract.Point      //Info about the pubPoly of the dealer
+	Commits []abstract.Point    //Commits used with B to reconstruct the pubPoly
+	Shares  []*pvss.PubVerShare //The encrypted shares, one per node
+}
+
+//D1 is the first dealing of a node
+type D1 struct {
+	SessionID []byte  //SessionID to verify the validity of the message
+	Purpose   string  //the purpose of the beacon
+	Time      int64   //time given by initializer to compute sessionID
+	Faulty    int     //the number of faulty nodes given to the root (see Setup)
+	Suite     string  //the name of the suite used for the PVSS
+	Rounds    int     //the number of rounds to run
+	Timeout   int64   //the time, in milliseconds, given to a leader to reveal
+	Src       int     //The sender
+	Dealing   Dealing //Its first secret
+}
+
+//StructD1 just contains D1 and the data necessary to identify and
+// process the message in the sda framework.
+type StructD1 struct {
+	*onet.TreeNode //The tree
+	D1             //The deal
+}
+
+//R1 is the reveal of the leader
+type R1 struct {
+	SessionID []byte          //SessionID to verify the validity of the message
+	Round     int             //The round led by Src
+	Src       int             //The sender
+	Secret    abstract.Scalar //The secret Src dealt before
+	Next      Dealing         //The secret Src commits to for its next round
+}
+
+//StructR1 just contains R1 and the data necessary to identify and
+// process the message in the sda framework.
+type StructR1 struct {
+	*onet.TreeNode //The tree
+	R1             //The reveal
+}
+
+//C1 carries the share of the sender of the leader's secret, when the leader failed
+type C1 struct {
+	SessionID []byte            //SessionID to verify the validity of the message
+	Round     int               //The round to recover
+	Src       int               //The sender
+	Share     *pvss.PubVerShare //The decrypted share of Src of the dealing of the leader
+}
+
+//StructC1 just contains C1 and the data necessary to identify and
+// process the message in the sda framework.
+type StructC1 struct {
+	*onet.TreeNode //The tree
+	C1             //The recover
+}
+
+//Round is the outcome of one round of the beacon
+type Round struct {
+	Index     int                 //The round number, starting at 1
+	Leader    int                 //The leader of the round
+	Dealing   Dealing             //The dealing of the leader opened in the round
+	Recovered bool                //Was the secret recovered from the shares instead of revealed ?
+	Reveal    abstract.Scalar     //s, when the leader revealed it
+	Decrypted []*pvss.PubVerShare //The decrypted shares G*s_i the secret was recovered from otherwise
+	Next      *Dealing            //The next dealing of the leader, once adopted from its reveal
+	Secret    abstract.Point      //G*s, s being the secret of the leader
+	Output    []byte              //SHA256(output of the previous round || Index || Secret)
+}
+
+//dealing is a verified Dealing
+type dealing struct {
+	pubPoly   *share.PubPoly
+	encShares []*pvss.PubVerShare
+}
+
+//Beacon is our protocol struct
+type Beacon struct {
+	*onet.TreeNodeInstance                                   //The tree of nodes
+	mutex                  sync.Mutex                        //Mutex to avoid concurrency
+	suite                  abstract.Suite                    //The suite used for the PVSS
+	nodes                  int                               //Number of nodes
+	faulty                 int                               //Number of faulty nodes
+	threshold              int                               //The threshold to recover secrets
+	purpose                string                            //The purpose of the beacon
+	startingTime           int64                             //starting time of the beacon
+	rounds                 int                               //Number of rounds to run
+	timeout                time.Duration                     //Time given to a leader to reveal
+	sessionID              []byte                            //The SessionID number
+	H                      abstract.Point                    //Our second base point created with SessionID
+	X                      []abstract.Point                  //The public keys
+	secret                 abstract.Scalar                   //The secret of our current dealing
+	dealt                  map[int]bool                      //The nodes whose first dealing we received
+	first                  map[int]Dealing                   //The valid first dealings, by dealer
+	dealings               map[int]*dealing                  //The current dealing of every node that can lead
+	round                  int                               //The current round, 0 while dealing
+	leaders                map[int]*dealing                  //The dealing opened in each round, by round
+	leader                 map[int]int                       //The leader of each round
+	reveals                map[int]map[int]*R1               //The reveals received, by round and sender
+	recovers               map[int]map[int]*pvss.PubVerShare //The decrypted shares received, by round and sender
+	recovering             map[int]bool                      //The rounds for which we sent our decrypted share
+	adopted                map[int]bool                      //The rounds whose leader's next dealing we adopted
+	timer                  *time.Timer                       //Fires when the leader of the current round is late
+	history                []*Round                          //The rounds done
+	Done                   chan bool                         //Is the beacon done ?
+
+	silent   func(round int) bool //Do we keep our secret in that round ? Only set by the tests
+	finished func(r *Round)       //Called with the mutex held when a round is done, only set by the tests
+}
@@ -0,0 +1,116 @@
+package beacon
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+	"gopkg.in/dedis/onet.v1"
+)
+
+//validPoint checks that p is a point of suite, i.e. that it is set and decodes back to itself (on the curve)
+func validPoint(suite abstract.Suite, p abstract.Point) bool {
+	if p == nil {
+		return false
+	}
+	b, err := p.MarshalBinary()
+	if err != nil {
+		return false
+	}
+	q := suite.Point()
+	return q.UnmarshalBinary(b) == nil && q.Equal(p)
+}
+
+//wellFormed checks that the values of an encrypted or decrypted share are set
+func wellFormed(s *pvss.PubVerShare) bool {
+	return s != nil && s.S.V != nil && s.P.C != nil && s.P.R != nil && s.P.VG != nil && s.P.VH != nil
+}
+
+//validShare checks that the values of an encrypted or decrypted share are set and that its points are valid
+func validShare(suite abstract.Suite, s *pvss.PubVerShare) bool {
+	return wellFormed(s) && validPoint(suite, s.S.V) && validPoint(suite, s.P.VG) && validPoint(suite, s.P.VH)
+}
+
+//validIndex checks that i is the index of a node of the beacon
+func (b *Beacon) validIndex(i int) bool {
+	return i >= 0 && i < b.nodes && i < len(b.X)
+}
+
+//validSender checks that the index a message claims is the one of the node that sent it
+func validSender(src int, from *onet.TreeNode) bool {
+	return from != nil && from.RosterIndex == src
+}
+
+//validDealing checks a dealing before its shares are verified: threshold valid commitments and one valid
+//encrypted share per node, in order
+func validDealing(suite abstract.Suite, d Dealing, nodes int, threshold int) error {
+	if len(d.Commits) != threshold {
+		return fmt.Errorf("%d commitments for a threshold of %d", len(d.Commits), threshold)
+	}
+	if len(d.Shares) != nodes {
+		return fmt.Errorf("%d shares for %d nodes", len(d.Shares), nodes)
+	}
+	if !validPoint(suite, d.B) {
+		return errors.New("Malformed base point")
+	}
+	for _, c := range d.Commits {
+		if !validPoint(suite, c) {
+			return errors.New("Malformed commitment")
+		}
+	}
+	for i, s := range d.Shares {
+		if !validShare(suite, s) || s.S.I != i {
+			return fmt.Errorf("Malformed share %d", i)
+		}
+	}
+	return nil
+}
+
+//validR1 checks that a reveal comes from its sender and opens a secret, its next dealing is checked when it is
+//adopted (see adopt)
+func (b *Beacon) validR1(msg *R1, from *onet.TreeNode) error {
+	if !b.validIndex(msg.Src) || !validSender(msg.Src, from) {
+		return fmt.Errorf("Wrong reveal sender %d", msg.Src)
+	}
+	if msg.Secret == nil {
+		return fmt.Errorf("No secret in the reveal of %d", msg.Src)
+	}
+	return nil
+}
+
+//validC1 checks that a recover comes from its sender and carries a valid decrypted share of it
+func (b *Beacon) validC1(msg *C1, from *onet.TreeNode) error {
+	if !b.validIndex(msg.Src) || !validSender(msg.Src, from) {
+		return fmt.Errorf("Wrong recover sender %d", msg.Src)
+	}
+	if !validShare(b.suite, msg.Share) || msg.Share.S.I != msg.Src {
+		return fmt.Errorf("Malformed share in the recover of %d", msg.Src)
+	}
+	return nil
+}
+
+//sameDealing tells if a and b are the same dealing
+func sameDealing(a, b Dealing) bool {
+	if len(a.Commits) != len(b.Commits) || len(a.Shares) != len(b.Shares) || !samePoint(a.B, b.B) {
+		return false
+	}
+	for i := range a.Commits {
+		if !samePoint(a.Commits[i], b.Commits[i]) {
+			return false
+		}
+	}
+	for i := range a.Shares {
+		s, t := a.Shares[i], b.Shares[i]
+		if !wellFormed(s) || !wellFormed(t) || s.S.I != t.S.I || !samePoint(s.S.V, t.S.V) ||
+			!samePoint(s.P.VG, t.P.VG) || !samePoint(s.P.VH, t.P.VH) || !s.P.C.Equal(t.P.C) || !s.P.R.Equal(t.P.R) {
+			return false
+		}
+	}
+	return true
+}
+
+//samePoint tells if p and q are set and equal
+func samePoint(p, q abstract.Point) bool {
+	return p != nil && q != nil && p.Equal(q)
+}
@@ -0,0 +1,12 @@
+/*Package bn256 provides an abstract.Suite over the G1 group of the 256-bit Barreto-Naehrig
+curve, so that the randshare protocols can run over a pairing-friendly curve.
+
+The group arithmetic is done by golang.org/x/crypto/bn256, the files are:
+- point.go defines the G1 points
+- suite.go defines the group and the ciphersuite
+- suite_test.go tests the suite against the generic crypto tests
+
+G1 returns the underlying bn256 element of a point so that a collective output can later be
+checked with bn256.Pair.
+*/
+package bn256
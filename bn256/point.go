@@ -0,0 +1,178 @@
+package bn256
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"errors"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/bn256"
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/group"
+	"gopkg.in/dedis/crypto.v0/nist"
+	"gopkg.in/dedis/crypto.v0/random"
+)
+
+//coordLen is the number of bytes of one coordinate of a G1 point
+const coordLen = 32
+
+//fieldP is the prime of the base field, the curve is y^2 = x^3 + 3 over it
+var fieldP, _ = new(big.Int).SetString("65000549695646603732796438742359905742825358107623003571877145026864184071783", 10)
+
+//curveB is the constant of the curve equation
+var curveB = big.NewInt(3)
+
+//point is a G1 point. The underlying bn256.G1 is never modified once it is set,
+//which makes Set and Clone cheap and the points safe to share between goroutines.
+type point struct {
+	g *bn256.G1
+}
+
+//affine normalizes g to affine coordinates so that later reads don't write
+func affine(g *bn256.G1) *bn256.G1 {
+	g.Marshal()
+	return g
+}
+
+//G1 returns the bn256 element of p, it is used to check outputs with bn256.Pair
+func G1(p abstract.Point) *bn256.G1 {
+	return p.(*point).g
+}
+
+func (p *point) String() string {
+	return p.g.String()
+}
+
+func (p *point) Equal(p2 abstract.Point) bool {
+	return bytes.Equal(p.g.Marshal(), p2.(*point).g.Marshal())
+}
+
+func (p *point) Null() abstract.Point {
+	p.g = affine(new(bn256.G1).ScalarBaseMult(big.NewInt(0)))
+	return p
+}
+
+func (p *point) Base() abstract.Point {
+	p.g = affine(new(bn256.G1).ScalarBaseMult(big.NewInt(1)))
+	return p
+}
+
+func (p *point) PickLen() int {
+	//Same layout as the NIST curves: 8 most-significant bits for randomness
+	//and the least-significant 8 bits for the embedded data length
+	return (fieldP.BitLen() - 8 - 8) / 8
+}
+
+//Pick a curve point containing a variable amount of embedded data.
+//Remaining bits comprising the point are chosen randomly.
+//G1 has cofactor 1 so every point on the curve is a valid group element.
+func (p *point) Pick(data []byte, rand cipher.Stream) (abstract.Point, []byte) {
+	dl := p.PickLen()
+	if dl > len(data) {
+		dl = len(data)
+	}
+
+	for {
+		b := random.Bits(uint(fieldP.BitLen()), false, rand)
+		if data != nil {
+			b[coordLen-1] = byte(dl)
+			copy(b[coordLen-dl-1:coordLen-1], data)
+		}
+		x := new(big.Int).SetBytes(b)
+		if x.Cmp(fieldP) >= 0 {
+			continue
+		}
+		y2 := new(big.Int).Mul(x, x)
+		y2.Mul(y2, x)
+		y2.Add(y2, curveB)
+		y2.Mod(y2, fieldP)
+		y := new(big.Int).ModSqrt(y2, fieldP)
+		if y == nil {
+			continue
+		}
+		if random.Bool(rand) {
+			y.Sub(fieldP, y)
+		}
+
+		buf := make([]byte, 2*coordLen)
+		x.FillBytes(buf[:coordLen])
+		y.FillBytes(buf[coordLen:])
+		g, ok := new(bn256.G1).Unmarshal(buf)
+		if !ok {
+			continue
+		}
+		p.g = affine(g)
+		return p, data[dl:]
+	}
+}
+
+//Data extracts the data embedded with Pick
+func (p *point) Data() ([]byte, error) {
+	b := p.g.Marshal()[:coordLen]
+	dl := int(b[coordLen-1])
+	if dl > p.PickLen() {
+		return nil, errors.New("invalid embedded data length")
+	}
+	return b[coordLen-dl-1 : coordLen-1], nil
+}
+
+func (p *point) Set(p2 abstract.Point) abstract.Point {
+	p.g = p2.(*point).g
+	return p
+}
+
+func (p *point) Clone() abstract.Point {
+	return &point{g: p.g}
+}
+
+func (p *point) Add(a, b abstract.Point) abstract.Point {
+	p.g = affine(new(bn256.G1).Add(a.(*point).g, b.(*point).g))
+	return p
+}
+
+func (p *point) Sub(a, b abstract.Point) abstract.Point {
+	neg := new(bn256.G1).Neg(b.(*point).g)
+	p.g = affine(new(bn256.G1).Add(a.(*point).g, neg))
+	return p
+}
+
+func (p *point) Neg(a abstract.Point) abstract.Point {
+	p.g = affine(new(bn256.G1).Neg(a.(*point).g))
+	return p
+}
+
+func (p *point) Mul(b abstract.Point, s abstract.Scalar) abstract.Point {
+	k := &s.(*nist.Int).V
+	if b == nil {
+		p.g = affine(new(bn256.G1).ScalarBaseMult(k))
+	} else {
+		p.g = affine(new(bn256.G1).ScalarMult(b.(*point).g, k))
+	}
+	return p
+}
+
+func (p *point) MarshalSize() int {
+	return 2 * coordLen
+}
+
+func (p *point) MarshalBinary() ([]byte, error) {
+	return p.g.Marshal(), nil
+}
+
+func (p *point) UnmarshalBinary(buf []byte) error {
+	g, ok := new(bn256.G1).Unmarshal(buf)
+	if !ok {
+		return errors.New("invalid bn256 G1 point")
+	}
+	p.g = affine(g)
+	return nil
+}
+
+func (p *point) MarshalTo(w io.Writer) (int, error) {
+	return group.PointMarshalTo(p, w)
+}
+
+func (p *point) UnmarshalFrom(r io.Reader) (int, error) {
+	return group.PointUnmarshalFrom(p, r)
+}
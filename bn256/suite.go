@@ -0,0 +1,89 @@
+package bn256
+
+import (
+	"crypto/cipher"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"reflect"
+
+	"golang.org/x/crypto/bn256"
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/cipher/sha3"
+	"gopkg.in/dedis/crypto.v0/nist"
+	"gopkg.in/dedis/crypto.v0/random"
+)
+
+//curve is the abstract.Group of the G1 points
+type curve struct{}
+
+func (c *curve) String() string {
+	return "BN256"
+}
+
+//ScalarLen is the number of bytes in the encoding of a scalar
+func (c *curve) ScalarLen() int {
+	return (bn256.Order.BitLen() + 7) / 8
+}
+
+//Scalar creates a scalar modulo the order of G1
+func (c *curve) Scalar() abstract.Scalar {
+	return nist.NewInt64(0, bn256.Order)
+}
+
+//PointLen is the number of bytes in the encoding of a point (both coordinates)
+func (c *curve) PointLen() int {
+	return 2 * coordLen
+}
+
+//Point creates a point initialized to the neutral element
+func (c *curve) Point() abstract.Point {
+	p := new(point)
+	p.Null()
+	return p
+}
+
+//PrimeOrder is true as G1 has cofactor 1
+func (c *curve) PrimeOrder() bool {
+	return true
+}
+
+type suite128 struct {
+	curve
+}
+
+//Hash is SHA256
+func (s *suite128) Hash() hash.Hash {
+	return sha256.New()
+}
+
+//Cipher is the SHA3/SHAKE128 sponge cipher
+func (s *suite128) Cipher(key []byte, options ...interface{}) abstract.Cipher {
+	return sha3.NewShakeCipher128(key, options...)
+}
+
+func (s *suite128) Read(r io.Reader, objs ...interface{}) error {
+	return abstract.SuiteRead(s, r, objs)
+}
+
+func (s *suite128) Write(w io.Writer, objs ...interface{}) error {
+	return abstract.SuiteWrite(s, w, objs)
+}
+
+func (s *suite128) New(t reflect.Type) interface{} {
+	return abstract.SuiteNew(s, t)
+}
+
+//NewKey picks a private key from rand, or from random.Stream if rand is nil
+func (s *suite128) NewKey(rand cipher.Stream) abstract.Scalar {
+	if rand == nil {
+		rand = random.Stream
+	}
+	return s.Scalar().Pick(rand)
+}
+
+//NewAES128SHA256BN256 returns the ciphersuite based on SHA-256 and the G1 group of BN256,
+//named like the NIST suites it sits next to
+func NewAES128SHA256BN256() abstract.Suite {
+	return new(suite128)
+}
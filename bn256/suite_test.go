@@ -0,0 +1,33 @@
+package bn256
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"golang.org/x/crypto/bn256"
+	"gopkg.in/dedis/crypto.v0/nist"
+	"gopkg.in/dedis/crypto.v0/random"
+	"gopkg.in/dedis/crypto.v0/test"
+)
+
+func TestSuite(t *testing.T) {
+	test.TestSuite(NewAES128SHA256BN256())
+}
+
+func TestPairing(t *testing.T) {
+	suite := NewAES128SHA256BN256()
+	a := suite.Scalar().Pick(random.Stream)
+	b := suite.Scalar().Pick(random.Stream)
+	aG := suite.Point().Mul(nil, a)
+	abG := suite.Point().Mul(aG, b)
+
+	//e(abG, g2) == e(aG, b*g2)
+	g2 := new(bn256.G2).ScalarBaseMult(big.NewInt(1))
+	bG2 := new(bn256.G2).ScalarBaseMult(&b.(*nist.Int).V)
+	left := bn256.Pair(G1(abG), g2).Marshal()
+	right := bn256.Pair(G1(aG), bG2).Marshal()
+	if !bytes.Equal(left, right) {
+		t.Fatal("pairing check failed")
+	}
+}
@@ -0,0 +1,231 @@
+package commitreveal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/random"
+	"gopkg.in/dedis/onet.v1"
+)
+
+//ValueLen is the length in bytes of the values r_i and of the collective string
+const ValueLen = 32
+
+func init() {
+	onet.GlobalProtocolRegister(Name, NewCommitReveal)
+}
+
+//NewCommitReveal initialises the tree and network
+func NewCommitReveal(n *onet.TreeNodeInstance) (onet.ProtocolInstance, error) {
+	t := &CommitReveal{
+		TreeNodeInstance: n,
+	}
+	err := t.RegisterHandlers(t.HandleC1, t.HandleR1)
+	return t, err
+}
+
+//Setup initializes the CommitReveal struct and picks our value r_i
+func (cr *CommitReveal) Setup(nodes int, purpose string, time int64) error {
+	cr.nodes = nodes
+	cr.purpose = purpose
+	cr.startingTime = time
+	cr.X = cr.Roster().Publics()
+	cr.sessionID = SessionID(cr.nodes, cr.X, cr.purpose, time)
+
+	cr.value = random.Bytes(ValueLen, random.Stream)
+	cr.revealed = false
+	cr.commits = make(map[int][]byte)
+	cr.reveals = make(map[int][]byte)
+	cr.coStringReady = false
+	cr.Done = make(chan bool, 1)
+	return nil
+}
+
+//Start initiates the protocol from node 0
+func (cr *CommitReveal) Start() error {
+	cr.mutex.Lock()
+	commit := cr.commit()
+	cr.mutex.Unlock()
+	return cr.Broadcast(commit)
+}
+
+//commit stores our own commit and returns the message announcing it
+func (cr *CommitReveal) commit() *C1 {
+	c := Commit(cr.sessionID, cr.Index(), cr.value)
+	cr.commits[cr.Index()] = c
+	return &C1{SessionID: cr.sessionID, Purpose: cr.purpose, Time: cr.startingTime, Src: cr.Index(), Commit: c}
+}
+
+//HandleC1 stores the commits and reveals our value once everyone committed
+func (cr *CommitReveal) HandleC1(commit StructC1) error {
+	msg := &commit.C1
+
+	cr.mutex.Lock()
+	var announce *C1
+	if cr.nodes == 0 { //first message, we setup cr and commit to our value too
+		nodes := len(cr.List())
+		if err := cr.Setup(nodes, msg.Purpose, msg.Time); err != nil {
+			cr.mutex.Unlock()
+			return err
+		}
+		announce = cr.commit()
+	}
+
+	if _, ok := cr.commits[msg.Src]; ok || msg.Src < 0 || msg.Src >= cr.nodes || !bytes.Equal(msg.SessionID, cr.sessionID) {
+		cr.mutex.Unlock()
+		if announce != nil {
+			return cr.Broadcast(announce)
+		}
+		return nil //If the sessionID is not correct or we already got a commit from that sender we don't deal with it
+	}
+	cr.commits[msg.Src] = msg.Commit
+
+	var reveal *R1
+	if len(cr.commits) == cr.nodes && !cr.revealed { //everyone is bound to its value, we can reveal ours
+		cr.revealed = true
+		cr.reveals[cr.Index()] = cr.value
+		reveal = &R1{SessionID: cr.sessionID, Src: cr.Index(), Value: cr.value}
+	}
+	cr.mutex.Unlock()
+
+	if announce != nil {
+		if err := cr.Broadcast(announce); err != nil {
+			return err
+		}
+	}
+	if reveal != nil {
+		if err := cr.Broadcast(reveal); err != nil {
+			return err
+		}
+		return cr.finish()
+	}
+	return nil
+}
+
+//HandleR1 checks the revealed values against their commits and computes the collective string once all arrived
+func (cr *CommitReveal) HandleR1(reveal StructR1) error {
+	msg := &reveal.R1
+
+	cr.mutex.Lock()
+	if _, ok := cr.reveals[msg.Src]; ok || !bytes.Equal(msg.SessionID, cr.sessionID) {
+		cr.mutex.Unlock()
+		return nil //If the sessionID is not correct or we already got the value of that sender we don't deal with it
+	}
+	c, ok := cr.commits[msg.Src]
+	if !ok || !bytes.Equal(c, Commit(cr.sessionID, msg.Src, msg.Value)) {
+		cr.mutex.Unlock()
+		return fmt.Errorf("Value of node %d doesn't match its commit", msg.Src)
+	}
+	cr.reveals[msg.Src] = msg.Value
+	cr.mutex.Unlock()
+	return cr.finish()
+}
+
+//finish computes the collective string if every value was revealed
+func (cr *CommitReveal) finish() error {
+	cr.mutex.Lock()
+	if len(cr.reveals) != cr.nodes || cr.coStringReady {
+		cr.mutex.Unlock()
+		return nil
+	}
+	coString, err := xor(cr.reveals, cr.nodes)
+	if err != nil {
+		cr.mutex.Unlock()
+		return err
+	}
+	cr.coString = coString
+	cr.coStringReady = true
+	cr.mutex.Unlock()
+	cr.Done <- true
+	return nil
+}
+
+//Random returns the collective string created by our protocol and the
+//associated transcript so that it can be verified by a third party
+func (cr *CommitReveal) Random() ([]byte, *Transcript, error) {
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+
+	if !cr.coStringReady {
+		return nil, nil, errors.New("Not ready")
+	}
+	transcript := &Transcript{
+		SessionID: cr.sessionID,
+		Nodes:     cr.nodes,
+		Purpose:   cr.purpose,
+		Time:      cr.startingTime,
+		X:         cr.X,
+		Commits:   cr.commits,
+		Reveals:   cr.reveals,
+	}
+	return cr.coString, transcript, nil
+}
+
+//Verify checks that every revealed value matches its commit and that their XOR is random
+func Verify(random []byte, transcript *Transcript) error {
+	if !bytes.Equal(transcript.SessionID, SessionID(transcript.Nodes, transcript.X, transcript.Purpose, transcript.Time)) {
+		return errors.New("Wrong session identifier")
+	}
+	for i := 0; i < transcript.Nodes; i++ {
+		c, ok := transcript.Commits[i]
+		if !ok || !bytes.Equal(c, Commit(transcript.SessionID, i, transcript.Reveals[i])) {
+			return fmt.Errorf("Value of node %d doesn't match its commit", i)
+		}
+	}
+	coString, err := xor(transcript.Reveals, transcript.Nodes)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(coString, random) {
+		return errors.New("CoString isn't correct")
+	}
+	return nil
+}
+
+//xor returns the XOR of the values of the nodes
+func xor(values map[int][]byte, nodes int) ([]byte, error) {
+	result := make([]byte, ValueLen)
+	for i := 0; i < nodes; i++ {
+		if len(values[i]) != ValueLen {
+			return nil, fmt.Errorf("Value of node %d has a wrong length", i)
+		}
+		for k := range result {
+			result[k] ^= values[i][k]
+		}
+	}
+	return result, nil
+}
+
+//Commit returns H(sessionID || src || value), the commit of node src to value
+func Commit(sessionID []byte, src int, value []byte) []byte {
+	h := sha256.New()
+	h.Write(sessionID)
+	binary.Write(h, binary.LittleEndian, uint32(src))
+	h.Write(value)
+	return h.Sum(nil)
+}
+
+//SessionID hashes the data (nodes, public keys, purpose, starting time) that caracterizes a particular protocol run into a session identifier
+func SessionID(nodes int, X []abstract.Point, purpose string, time int64) []byte {
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, uint32(nodes)); err != nil {
+		return nil
+	}
+	for _, key := range X {
+		keyB, err := key.MarshalBinary()
+		if err != nil {
+			return nil
+		}
+		buf.Write(keyB)
+	}
+	buf.WriteString(purpose)
+	if err := binary.Write(buf, binary.LittleEndian, time); err != nil {
+		return nil
+	}
+	h := sha256.Sum256(buf.Bytes())
+	return h[:]
+}
@@ -0,0 +1,54 @@
+package commitreveal
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/dedis/onet.v1"
+	"gopkg.in/dedis/onet.v1/log"
+)
+
+func TestCommitReveal(t *testing.T) {
+	var nodes = 13
+
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol(Name, tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	cr := protocol.(*CommitReveal)
+	if err = cr.Setup(nodes, "CommitReveal test run", time.Now().Unix()); err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	if err = cr.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-cr.Done:
+		random, transcript, err := cr.Random()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = Verify(random, transcript); err != nil {
+			t.Fatal(err)
+		}
+		log.Lvlf1("CommitReveal verified")
+
+		//a value that doesn't match its commit is rejected
+		value := append([]byte{}, transcript.Reveals[3]...)
+		transcript.Reveals[3][0] ^= 1
+		if err = Verify(random, transcript); err == nil {
+			t.Fatal("Verify accepted a value that doesn't match its commit")
+		}
+		transcript.Reveals[3] = value
+		random[0] ^= 1
+		if err = Verify(random, transcript); err == nil {
+			t.Fatal("Verify accepted a wrong collective string")
+		}
+	case <-time.After(time.Second * time.Duration(nodes)):
+		t.Fatal("CommitReveal timeout")
+	}
+}
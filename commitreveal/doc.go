@@ -0,0 +1,17 @@
+/*Package commitreveal is a simple commit-reveal randomness protocol, used as a baseline to measure the cost of
+the bias resistance of randshare and randsharepvss.
+The protocol has two messages:
+	- the commit C1 which is used to broadcast H(SessionID || i || r_i)
+	- the reveal R1 which is used to broadcast r_i once every commit arrived
+
+The collective string is the XOR of the r_i. It is NOT bias-resistant: the last node to reveal knows the
+result first and can withhold its r_i to choose between two outcomes, and a single silent node stops the
+protocol. It has the same Random/Transcript/Verify shape as randsharepvss so both can be plotted side by side:
+simulation/commitreveal.toml runs it as the commitreveal variant of the simulation.
+
+A simple protocol uses three files:
+- struct.go defines the messages sent around
+- commitreveal.go defines the actions for each message
+- commitreveal_test.go tests the protocol in a local test
+*/
+package commitreveal
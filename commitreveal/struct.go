@@ -0,0 +1,78 @@
+package commitreveal
+
+import (
+	"sync"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/onet.v1"
+	"gopkg.in/dedis/onet.v1/network"
+)
+
+//Name can be used from other packages to refer to this protocol.
+const Name = "CommitReveal"
+
+//init registers the messages
+func init() {
+	for _, p := range []interface{}{C1{}, R1{}, StructC1{}, StructR1{}} {
+		network.RegisterMessage(p)
+	}
+}
+
+//C1 is the commit
+type C1 struct {
+	SessionID []byte //SessionID to verify the validity of the message
+	Purpose   string //the purpose of the current ProtocolInstance
+	Time      int64  //time given by initializer to compute sessionID
+	Src       int    //The sender
+	Commit    []byte //H(SessionID || Src || r_Src)
+}
+
+//StructC1 just contains C1 and the data necessary to identify and
+// process the message in the sda framework.
+type StructC1 struct {
+	*onet.TreeNode //The tree
+	C1             //The commit
+}
+
+//R1 is the reveal
+type R1 struct {
+	SessionID []byte //SessionID to verify the validity of the message
+	Src       int    //The sender
+	Value     []byte //r_Src
+}
+
+//StructR1 just contains R1 and the data necessary to identify and
+// process the message in the sda framework.
+type StructR1 struct {
+	*onet.TreeNode //The tree
+	R1             //The reveal
+}
+
+//Transcript is given to a third party so that it can verify the collective string
+type Transcript struct {
+	SessionID []byte           //The sessionID
+	Nodes     int              //Number of nodes
+	Purpose   string           //The purpose
+	Time      int64            //the starting time
+	X         []abstract.Point //The public keys
+	Commits   map[int][]byte   //The commits
+	Reveals   map[int][]byte   //The revealed values
+}
+
+//CommitReveal is our protocol struct
+type CommitReveal struct {
+	*onet.TreeNodeInstance                  //The tree of nodes
+	mutex                  sync.Mutex       //Mutex to avoid concurrency
+	nodes                  int              //Number of nodes
+	purpose                string           //The purpose of the protocol
+	startingTime           int64            //starting time of the protocol run
+	sessionID              []byte           //The SessionID number (see method SessionID)
+	X                      []abstract.Point //The public keys of the nodes
+	value                  []byte           //Our r_i
+	revealed               bool             //did we send our reveal ?
+	commits                map[int][]byte   //The commits received
+	reveals                map[int][]byte   //The reveals received and checked against their commit
+	coString               []byte           //The collective string
+	coStringReady          bool             //is the collective string computed yet ?
+	Done                   chan bool        //are we done ?
+}
@@ -15,7 +15,7 @@ import (
 )
 
 func init() {
-	onet.GlobalProtocolRegister("RandShare", NewRandShare)
+	onet.GlobalProtocolRegister(Name, NewRandShare)
 }
 
 //NewRandShare initialises the tree and network
@@ -54,7 +54,7 @@ func (rs *RandShare) Setup(nodes int, faulty int, purpose string, time int64) er
 	}
 	rs.secrets = make(map[int]abstract.Point)
 	rs.coStringReady = false
-	rs.Done = make(chan bool, 0)
+	rs.Done = make(chan bool, 1) //buffered so that nodes nobody waits for don't block their handlers
 
 	//for demo
 	rs.decshare = false
@@ -84,6 +84,7 @@ func (rs *RandShare) Start() error {
 		Commits:   commits,
 		Purpose:   rs.purpose,
 		Time:      rs.startingTime,
+		Faulty:    rs.faulty,
 	}
 
 	for j := 0; j < rs.nodes; j++ {
@@ -101,9 +102,12 @@ func (rs *RandShare) HandleA1(announce StructA1) error {
 	msg := &announce.A1
 
 	if rs.nodes == 0 { //we need to setup rs and brodcast our encrypted shares
-		rs.mutex.Lock()
 		nodes := len(rs.List())
-		if err := rs.Setup(nodes, nodes/3, msg.Purpose, msg.Time); err != nil {
+		if msg.Faulty < 0 || 3*msg.Faulty >= nodes {
+			return errors.New("Wrong number of faulty nodes")
+		}
+		rs.mutex.Lock()
+		if err := rs.Setup(nodes, msg.Faulty, msg.Purpose, msg.Time); err != nil {
 			return err
 		}
 		encShares, pubPoly, err := pvss.EncShares(rs.Suite(), rs.H, rs.X, nil, rs.threshold)
@@ -120,6 +124,7 @@ func (rs *RandShare) HandleA1(announce StructA1) error {
 			Commits:   commits,
 			Purpose:   rs.purpose,
 			Time:      rs.startingTime,
+			Faulty:    rs.faulty,
 		}
 		for j := 0; j < rs.nodes; j++ {
 			//we know they are correct, we can store them
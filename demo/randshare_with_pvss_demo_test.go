@@ -10,7 +10,7 @@ import (
 
 func TestRandShare(t *testing.T) {
 
-	var name = "RandShare"
+	var name = Name
 	var nodes = 13
 	// 2/3 would prevent network splitting attacks
 	var faulty = nodes / 3
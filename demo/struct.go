@@ -12,7 +12,7 @@ import (
 )
 
 //Name can be used from other packages to refer to this protocol.
-const Name = "RandShare"
+const Name = "RandShareDemo"
 
 //init registers the handlers
 func init() {
@@ -39,6 +39,7 @@ type A1 struct {
 	SessionID []byte              //SessionID to verify the validity of the message
 	Purpose   string              //the purpose of the current ProtocolInstance
 	Time      int64               //time given by initializer to compute sessionID
+	Faulty    int                 //the number of faulty nodes given to the root (see Setup)
 	Src       int                 //The sender
 	B         abstract.Point      //Info about pubPoly of Src
 	Commits   []abstract.Point    //Commits used with B to reconstruct pubPoly
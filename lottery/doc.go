@@ -0,0 +1,18 @@
+/*Package lottery elects leaders and lottery winners among weighted participants with the collective string
+of randsharepvss.
+
+Each winner is drawn with a probability proportional to its weight among the participants that haven't won
+yet: a point is drawn uniformly in [0, total weight) with sampling.Source and the winner is the participant
+whose cumulative weight range contains it. The participants are sorted by name and each name may appear once,
+so the winners don't depend on the order the list was given in. The stream of sampling.Source is seeded with
+SHA256 of the collective string, the label and the sorted list, so the same list, collective string and label
+always give the same winners and another list gives other ones.
+
+The list and the label have to be fixed before the Purpose and Time of the session giving the collective string
+are, e.g. by putting their hash in the Purpose: otherwise whoever learns the string first could try other lists
+or labels until one makes them win.
+
+Draw returns a Proof that anyone can check with Proof.Verify: it verifies the transcript with
+randsharepvss.Verify and draws the winners again.
+*/
+package lottery
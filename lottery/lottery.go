@@ -0,0 +1,158 @@
+package lottery
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/dedis/student_17_randomness/randshare_with_pvss"
+	"github.com/dedis/student_17_randomness/sampling"
+)
+
+//Participant is someone taking part in the lottery
+type Participant struct {
+	Name   string //The unique name of the participant, e.g. its public key
+	Weight uint64 //Its stake, a participant with a zero weight never wins
+}
+
+//Proof ties the winners of a lottery to the collective string they were drawn with
+type Proof struct {
+	Random       []byte                    //The collective string
+	Transcript   *randsharepvss.Transcript //The transcript of the collective string
+	Label        string                    //The label of the lottery, see sampling.NewSource
+	Participants []Participant             //The participants, sorted by name
+	Winners      []int                     //The indexes in Participants of the winners, in the order they were drawn
+}
+
+//Draw verifies the transcript and draws winners distinct winners among the participants. The order of the list
+//doesn't matter, but the list and the label have to be fixed before the Purpose and Time of the session giving
+//random are, otherwise one could pick them once random is known.
+func Draw(random []byte, transcript *randsharepvss.Transcript, label string, participants []Participant, winners int) ([]Participant, *Proof, error) {
+	sorted := append([]Participant{}, participants...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	p := &Proof{Random: random, Transcript: transcript, Label: label, Participants: sorted}
+	w, err := p.draw(winners)
+	if err != nil {
+		return nil, nil, err
+	}
+	p.Winners = w
+	result := make([]Participant, len(w))
+	for i, index := range w {
+		result[i] = sorted[index]
+	}
+	return result, p, nil
+}
+
+//Leader verifies the transcript and elects one leader among the participants
+func Leader(random []byte, transcript *randsharepvss.Transcript, label string, participants []Participant) (Participant, *Proof, error) {
+	winners, p, err := Draw(random, transcript, label, participants, 1)
+	if err != nil {
+		return Participant{}, nil, err
+	}
+	return winners[0], p, nil
+}
+
+//Verify checks the transcript of the collective string and that the winners are the ones drawn from it
+func (p *Proof) Verify() error {
+	winners, err := p.draw(len(p.Winners))
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(winners, p.Winners) {
+		return errors.New("The winners don't match the collective string")
+	}
+	return nil
+}
+
+//draw verifies the transcript and returns the indexes of the winners
+func (p *Proof) draw(winners int) ([]int, error) {
+	if p.Transcript == nil {
+		return nil, errors.New("No transcript")
+	}
+	if err := randsharepvss.Verify(p.Random, p.Transcript); err != nil {
+		return nil, err
+	}
+	weights, err := checkParticipants(p.Participants, winners)
+	if err != nil {
+		return nil, err
+	}
+	return pick(sampling.NewSource(seed(p.Random, p.Label, p.Participants), p.Label), weights, winners)
+}
+
+//seed returns SHA256(random || len(label) || label || participants), every participant being written as
+//len(name) || name || weight: the draw depends on the whole list and not only on the weights in order
+func seed(random []byte, label string, participants []Participant) []byte {
+	h := sha256.New()
+	h.Write(random)
+	binary.Write(h, binary.BigEndian, uint64(len(label)))
+	h.Write([]byte(label))
+	for _, participant := range participants {
+		binary.Write(h, binary.BigEndian, uint64(len(participant.Name)))
+		h.Write([]byte(participant.Name))
+		binary.Write(h, binary.BigEndian, participant.Weight)
+	}
+	return h.Sum(nil)
+}
+
+//checkParticipants returns the weights of the participants after checking that they are sorted by name, once
+//each, and that there are enough of them to draw winners
+func checkParticipants(participants []Participant, winners int) ([]uint64, error) {
+	if winners < 1 {
+		return nil, errors.New("At least one winner must be drawn")
+	}
+	names := make(map[string]bool)
+	weights := make([]uint64, len(participants))
+	var total uint64
+	candidates := 0
+	for i, participant := range participants {
+		if names[participant.Name] {
+			return nil, fmt.Errorf("Participant %s is twice in the list", participant.Name)
+		}
+		names[participant.Name] = true
+		if i > 0 && participants[i-1].Name > participant.Name {
+			return nil, errors.New("The participants aren't sorted by name")
+		}
+		if participant.Weight > math.MaxUint64-total {
+			return nil, errors.New("The total weight overflows")
+		}
+		total += participant.Weight
+		weights[i] = participant.Weight
+		if participant.Weight > 0 {
+			candidates++
+		}
+	}
+	if winners > candidates {
+		return nil, fmt.Errorf("Can't draw %d winners among %d participants with a weight", winners, candidates)
+	}
+	return weights, nil
+}
+
+//pick draws the winners one after the other, each one with a probability proportional to its weight among the
+//participants left. The weights of the winners are set to zero.
+func pick(source *sampling.Source, weights []uint64, winners int) ([]int, error) {
+	var total uint64
+	for _, w := range weights {
+		total += w
+	}
+	result := make([]int, 0, winners)
+	for len(result) < winners {
+		point, err := source.Uint64n(total)
+		if err != nil {
+			return nil, err
+		}
+		for i, w := range weights {
+			if point < w {
+				result = append(result, i)
+				total -= w
+				weights[i] = 0
+				break
+			}
+			point -= w
+		}
+	}
+	return result, nil
+}
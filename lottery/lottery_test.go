@@ -0,0 +1,121 @@
+package lottery
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/dedis/student_17_randomness/randshare_with_pvss/pvsstest"
+	"github.com/dedis/student_17_randomness/sampling"
+)
+
+func TestPickWeighted(t *testing.T) {
+	stakes := []uint64{10, 0, 30, 60}
+	wins := make([]int, len(stakes))
+	draws := 20000
+	for i := 0; i < draws; i++ {
+		source := sampling.NewSource([]byte("random"), fmt.Sprint("draw ", i))
+		w, err := pick(source, append([]uint64{}, stakes...), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wins[w[0]]++
+	}
+	if wins[1] != 0 {
+		t.Fatal("A participant without weight won")
+	}
+	for i, stake := range stakes {
+		expected := draws * int(stake) / 100
+		if wins[i] < expected*9/10 || wins[i] > expected*11/10 {
+			t.Fatalf("Participant %d won %d times, expected about %d", i, wins[i], expected)
+		}
+	}
+
+	//every participant with a weight ends up drawn once
+	w, err := pick(sampling.NewSource([]byte("random"), "all"), append([]uint64{}, stakes...), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[int]bool)
+	for _, i := range w {
+		if i == 1 || seen[i] {
+			t.Fatal("Wrong winners", w)
+		}
+		seen[i] = true
+	}
+}
+
+func TestCheckParticipants(t *testing.T) {
+	participants := []Participant{{"a", 1}, {"b", 0}, {"c", 2}}
+	if _, err := checkParticipants(participants, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checkParticipants(participants, 3); err == nil {
+		t.Fatal("More winners than participants with a weight")
+	}
+	if _, err := checkParticipants(append(participants, Participant{"a", 3}), 1); err == nil {
+		t.Fatal("A participant was accepted twice")
+	}
+	if _, err := checkParticipants([]Participant{{"b", 1}, {"a", 1}}, 1); err == nil {
+		t.Fatal("Unsorted participants were accepted")
+	}
+	if _, err := checkParticipants([]Participant{{"a", 1 << 63}, {"b", 1 << 63}}, 1); err == nil {
+		t.Fatal("The total weight overflowed")
+	}
+}
+
+func TestDraw(t *testing.T) {
+	random, transcript := pvsstest.Run(t, 7, "lottery test")
+	participants := []Participant{{"alice", 5}, {"bob", 1}, {"carol", 20}, {"dave", 0}, {"eve", 3}}
+
+	winners, proof, err := Draw(random, transcript, "lottery", participants, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(winners) != 3 {
+		t.Fatal("Wrong number of winners", len(winners))
+	}
+	if err := proof.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	//the order the participants are given in doesn't matter
+	reversed := make([]Participant, len(participants))
+	for i, participant := range participants {
+		reversed[len(participants)-1-i] = participant
+	}
+	again, _, err := Draw(random, transcript, "lottery", reversed, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, winners) {
+		t.Fatal("The order of the participants changed the winners")
+	}
+	leader, leaderProof, err := Leader(random, transcript, "leader", participants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader.Weight == 0 {
+		t.Fatal("A participant without weight was elected")
+	}
+	if err := leaderProof.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	//the proof doesn't hold with other winners or another list of participants
+	proof.Winners[0], proof.Winners[1] = proof.Winners[1], proof.Winners[0]
+	if err := proof.Verify(); err == nil {
+		t.Fatal("Verify accepted other winners")
+	}
+	proof.Winners[0], proof.Winners[1] = proof.Winners[1], proof.Winners[0]
+	proof.Participants = append([]Participant{}, participants...)
+	proof.Participants[3].Weight = 1000
+	if err := proof.Verify(); err == nil {
+		t.Fatal("Verify accepted another list of participants")
+	}
+	proof.Participants = reversed
+	if err := proof.Verify(); err == nil {
+		t.Fatal("Verify accepted unsorted participants")
+	}
+}
+
@@ -0,0 +1,19 @@
+/*Package metrics records what the RandShare protocols do and exposes it in the Prometheus text format.
+
+metrics.go holds a small Registry of counters and histograms, without any dependency. The protocols record into
+the Default registry through a Run (protocol.go), one per protocol instance:
+	- randshare_phase_seconds, the time spent in each phase of a run and in the whole run
+	- randshare_shares_verified_total and randshare_shares_rejected_total, the shares checked, by kind
+	- randshare_votes_received_total, the votes on dealers received, by message
+	- randshare_dealers_excluded_total, the dealers left out of the collective string
+	- randshare_messages_sent_total and randshare_message_bytes_total, by message type. The bytes need a second
+	  encoding of every message, so they are only counted once the metrics are read (MeasureSizes)
+	- randshare_runs_total, the runs that ended with a collective string
+Every series is labelled with the address of the conode (node) and the protocol (randshare or pvss), as a
+simulation runs many conodes in one process.
+
+The Metrics service (service.go) answers GetMetrics with the series of its conode. If METRICS_ADDRESS is set,
+e.g. to localhost:9100, the first conode of the process also serves every series at http://METRICS_ADDRESS/metrics
+for a local Prometheus to scrape, ?node=<address> keeping those of one conode.
+*/
+package metrics
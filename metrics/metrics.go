@@ -0,0 +1,233 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/dedis/onet.v1/log"
+)
+
+//Counter describes a counter, its value for each set of label values only goes up
+type Counter struct {
+	Name   string
+	Help   string
+	Labels []string
+}
+
+//Histogram describes a histogram, its observations for each set of label values are counted in Buckets (the
+//upper bounds, sorted)
+type Histogram struct {
+	Name    string
+	Help    string
+	Labels  []string
+	Buckets []float64
+}
+
+//Add adds v to the counter of values in the Default registry
+func (c *Counter) Add(v float64, values ...string) {
+	Default.Add(c, v, values...)
+}
+
+//Observe adds v to the histogram of values in the Default registry
+func (h *Histogram) Observe(v float64, values ...string) {
+	Default.Observe(h, v, values...)
+}
+
+//family gathers the series of one counter or histogram
+type family struct {
+	name    string
+	help    string
+	kind    string //counter or histogram
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+}
+
+//series is the value of a family for one set of label values
+type series struct {
+	values  []string
+	value   float64  //the value of a counter, the sum of the observations of a histogram
+	count   uint64   //the number of observations of a histogram
+	buckets []uint64 //the number of observations in each bucket of a histogram
+}
+
+//Registry holds the counters and histograms of the protocols and writes them in the Prometheus text format
+type Registry struct {
+	mutex    sync.Mutex
+	families map[string]*family
+}
+
+//Default is the registry of the process, the protocols record their metrics there (see protocol.go)
+var Default = NewRegistry()
+
+//NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+//get returns the series of values in the family name, created on first use. It returns nil if the number of values
+//isn't the number of labels.
+func (r *Registry) get(name string, help string, kind string, labels []string, buckets []float64, values []string) *series {
+	if len(values) != len(labels) {
+		log.Errorf("Metric %s has %d labels, got %d values", name, len(labels), len(values))
+		return nil
+	}
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{name: name, help: help, kind: kind, labels: labels, buckets: buckets, series: make(map[string]*series)}
+		r.families[name] = f
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: append([]string{}, values...), buckets: make([]uint64, len(buckets))}
+		f.series[key] = s
+	}
+	return s
+}
+
+//Add adds v to the counter c of values
+func (r *Registry) Add(c *Counter, v float64, values ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if s := r.get(c.Name, c.Help, "counter", c.Labels, nil, values); s != nil {
+		s.value += v
+	}
+}
+
+//Observe adds an observation v to the histogram h of values
+func (r *Registry) Observe(h *Histogram, v float64, values ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	s := r.get(h.Name, h.Help, "histogram", h.Labels, h.Buckets, values)
+	if s == nil {
+		return
+	}
+	s.value += v
+	s.count++
+	for i, bound := range h.Buckets {
+		if v <= bound {
+			s.buckets[i]++
+		}
+	}
+}
+
+//Get returns the value of the counter name, or the number of observations of the histogram name, for values
+func (r *Registry) Get(name string, values ...string) float64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	f, ok := r.families[name]
+	if !ok {
+		return 0
+	}
+	s, ok := f.series[strings.Join(values, "\xff")]
+	if !ok {
+		return 0
+	}
+	if f.kind == "histogram" {
+		return float64(s.count)
+	}
+	return s.value
+}
+
+//Write writes the metrics in the Prometheus text format, families and series sorted. If node isn't empty, only
+//the series whose label "node" is node are written.
+func (r *Registry) Write(w io.Writer, node string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	b := bufio.NewWriter(w)
+	for _, name := range names {
+		f := r.families[name]
+		nodeLabel := -1
+		for i, l := range f.labels {
+			if l == "node" {
+				nodeLabel = i
+			}
+		}
+		var list []*series
+		for _, s := range f.series {
+			if node == "" || (nodeLabel >= 0 && s.values[nodeLabel] == node) {
+				list = append(list, s)
+			}
+		}
+		if len(list) == 0 {
+			continue
+		}
+		sort.Slice(list, func(i, j int) bool {
+			return strings.Join(list[i].values, "\xff") < strings.Join(list[j].values, "\xff")
+		})
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", f.name, escape(f.help, false), f.name, f.kind)
+		for _, s := range list {
+			if f.kind == "counter" {
+				fmt.Fprintf(b, "%s%s %s\n", f.name, labels(f.labels, s.values, ""), number(s.value))
+				continue
+			}
+			for i, bound := range f.buckets {
+				fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, labels(f.labels, s.values, number(bound)), s.buckets[i])
+			}
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, labels(f.labels, s.values, "+Inf"), s.count)
+			fmt.Fprintf(b, "%s_sum%s %s\n", f.name, labels(f.labels, s.values, ""), number(s.value))
+			fmt.Fprintf(b, "%s_count%s %d\n", f.name, labels(f.labels, s.values, ""), s.count)
+		}
+	}
+	return b.Flush()
+}
+
+//ServeHTTP writes every metric of the registry, so that a Prometheus server can scrape it
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := r.Write(w, req.URL.Query().Get("node")); err != nil {
+		log.Error("Couldn't write the metrics:", err)
+	}
+}
+
+//labels formats the label pairs of a series, with le as last label if it isn't empty (histogram buckets)
+func labels(names []string, values []string, le string) string {
+	var pairs []string
+	for i, name := range names {
+		pairs = append(pairs, name+"=\""+escape(values[i], true)+"\"")
+	}
+	if le != "" {
+		pairs = append(pairs, "le=\""+le+"\"")
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+//escape escapes the backslashes and newlines of a help text, and the double quotes of a label value
+func escape(s string, quotes bool) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, "\n", `\n`, -1)
+	if quotes {
+		s = strings.Replace(s, `"`, `\"`, -1)
+	}
+	return s
+}
+
+//number formats a value as Prometheus expects it
+func number(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"gopkg.in/dedis/onet.v1"
+	"gopkg.in/dedis/onet.v1/network"
+)
+
+var (
+	testCounter   = &Counter{Name: "test_total", Help: "A test counter.", Labels: []string{"node", "kind"}}
+	testHistogram = &Histogram{Name: "test_seconds", Help: "A test histogram.", Labels: []string{"node"}, Buckets: []float64{0.5, 1}}
+)
+
+func TestWrite(t *testing.T) {
+	r := NewRegistry()
+	r.Add(testCounter, 2, "b", "x")
+	r.Add(testCounter, 1, "a", `quote"d`)
+	r.Add(testCounter, 1, "a", `quote"d`)
+	r.Observe(testHistogram, 0.2, "a")
+	r.Observe(testHistogram, 0.7, "a")
+	r.Observe(testHistogram, 3, "a")
+	r.Add(testCounter, 1, "too few values") //dropped
+
+	expected := `# HELP test_seconds A test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{node="a",le="0.5"} 1
+test_seconds_bucket{node="a",le="1"} 2
+test_seconds_bucket{node="a",le="+Inf"} 3
+test_seconds_sum{node="a"} 3.9
+test_seconds_count{node="a"} 3
+# HELP test_total A test counter.
+# TYPE test_total counter
+test_total{node="a",kind="quote\"d"} 2
+test_total{node="b",kind="x"} 2
+`
+	var b bytes.Buffer
+	if err := r.Write(&b, ""); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != expected {
+		t.Fatalf("Wrong metrics:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := r.Write(&b, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "# HELP test_total A test counter.\n# TYPE test_total counter\ntest_total{node=\"b\",kind=\"x\"} 2\n" {
+		t.Fatalf("Only the series of node b should be written:\n%s", b.String())
+	}
+
+	if r.Get("test_total", "a", `quote"d`) != 2 || r.Get("test_seconds", "a") != 3 || r.Get("test_total", "c", "x") != 0 {
+		t.Fatal("Wrong values")
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	r := NewRegistry()
+	r.Add(testCounter, 1, "a", "x")
+	r.Add(testCounter, 1, "b", "x")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics?node=a", nil))
+	body, _ := ioutil.ReadAll(w.Body)
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Fatal("Wrong content type", w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(string(body), `test_total{node="a",kind="x"} 1`) || strings.Contains(string(body), `node="b"`) {
+		t.Fatalf("Wrong metrics:\n%s", body)
+	}
+}
+
+//TestService checks that a conode only returns its own series
+func TestService(t *testing.T) {
+	local := onet.NewTCPTest() //the clients need the websockets of TCP conodes
+	servers, _, _ := local.GenTree(2, true)
+	defer local.CloseAll()
+
+	for _, server := range servers {
+		NewRun(server.ServerIdentity.Address.String(), "test").Done()
+	}
+	client := local.NewClient(ServiceName)
+	reply := &Metrics{}
+	if err := client.SendProtobuf(servers[0].ServerIdentity, &GetMetrics{}, reply); err != nil {
+		t.Fatal(err)
+	}
+	own := `randshare_runs_total{node="` + servers[0].ServerIdentity.Address.String() + `",protocol="test"} 1`
+	if !strings.Contains(reply.Text, own) || strings.Contains(reply.Text, servers[1].ServerIdentity.Address.String()) {
+		t.Fatalf("Wrong metrics:\n%s", reply.Text)
+	}
+}
+
+//TestSent checks that the bytes of the messages are only counted once the sizes are measured
+func TestSent(t *testing.T) {
+	atomic.StoreInt32(&measureSizes, 0) //TestService asked for the metrics
+	msg := &GetMetrics{}
+	run := NewRun("sent", "test")
+	run.Sent(msg, 2)
+	if Default.Get("randshare_messages_sent_total", "sent", "test", "GetMetrics") != 2 ||
+		Default.Get("randshare_message_bytes_total", "sent", "test", "GetMetrics") != 0 {
+		t.Fatal("The bytes were counted before the sizes were measured")
+	}
+	MeasureSizes()
+	run.Sent(msg, 2)
+	b, err := network.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Default.Get("randshare_message_bytes_total", "sent", "test", "GetMetrics") != float64(2*len(b)) {
+		t.Fatal("Wrong number of bytes")
+	}
+}
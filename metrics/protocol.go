@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/dedis/onet.v1/network"
+)
+
+//The metrics of the RandShare protocols. Every series has the labels node, the address of the conode, and
+//protocol, randshare or pvss.
+var (
+	Phase = &Histogram{
+		Name:    "randshare_phase_seconds",
+		Help:    "Time spent in each phase of a run, total is the whole run.",
+		Labels:  []string{"node", "protocol", "phase"},
+		Buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60},
+	}
+	SharesVerified = &Counter{
+		Name:   "randshare_shares_verified_total",
+		Help:   "Shares that passed their check, by kind of share.",
+		Labels: []string{"node", "protocol", "kind"},
+	}
+	SharesRejected = &Counter{
+		Name:   "randshare_shares_rejected_total",
+		Help:   "Shares that failed their check, by kind of share.",
+		Labels: []string{"node", "protocol", "kind"},
+	}
+	VotesReceived = &Counter{
+		Name:   "randshare_votes_received_total",
+		Help:   "Votes on dealers received from the other nodes, by message.",
+		Labels: []string{"node", "protocol", "message"},
+	}
+	DealersExcluded = &Counter{
+		Name:   "randshare_dealers_excluded_total",
+		Help:   "Dealers left out of the collective string.",
+		Labels: []string{"node", "protocol"},
+	}
+	MessagesSent = &Counter{
+		Name:   "randshare_messages_sent_total",
+		Help:   "Messages sent, by message type.",
+		Labels: []string{"node", "protocol", "type"},
+	}
+	BytesSent = &Counter{
+		Name:   "randshare_message_bytes_total",
+		Help:   "Bytes of the messages sent, by message type.",
+		Labels: []string{"node", "protocol", "type"},
+	}
+	Runs = &Counter{
+		Name:   "randshare_runs_total",
+		Help:   "Runs that ended with a collective string.",
+		Labels: []string{"node", "protocol"},
+	}
+)
+
+//Run records the metrics of one protocol run of a node
+type Run struct {
+	node     string
+	protocol string
+	start    time.Time //start of the run
+	phase    time.Time //start of the current phase
+}
+
+//NewRun starts the clock of a run of protocol at node
+func NewRun(node string, protocol string) *Run {
+	now := time.Now()
+	return &Run{node: node, protocol: protocol, start: now, phase: now}
+}
+
+//Phase records the time since the end of the previous phase as the duration of phase
+func (r *Run) Phase(phase string) {
+	now := time.Now()
+	Phase.Observe(now.Sub(r.phase).Seconds(), r.node, r.protocol, phase)
+	r.phase = now
+}
+
+//Done records the duration of the whole run
+func (r *Run) Done() {
+	Phase.Observe(time.Since(r.start).Seconds(), r.node, r.protocol, "total")
+	Runs.Add(1, r.node, r.protocol)
+}
+
+//Shares records the number of shares of kind that passed and failed their check
+func (r *Run) Shares(kind string, verified int, rejected int) {
+	if verified > 0 {
+		SharesVerified.Add(float64(verified), r.node, r.protocol, kind)
+	}
+	if rejected > 0 {
+		SharesRejected.Add(float64(rejected), r.node, r.protocol, kind)
+	}
+}
+
+//Votes records the votes on dealers carried by a message
+func (r *Run) Votes(message string, votes int) {
+	VotesReceived.Add(float64(votes), r.node, r.protocol, message)
+}
+
+//Excluded records the dealers left out of the collective string
+func (r *Run) Excluded(dealers int) {
+	DealersExcluded.Add(float64(dealers), r.node, r.protocol)
+}
+
+//measureSizes is set once someone reads the metrics, see MeasureSizes
+var measureSizes int32
+
+//MeasureSizes makes Sent record the size of the messages in BytesSent. Sent has to encode every message a second
+//time for it, so it is only done once the metrics endpoint is started or a client asked for the metrics.
+func MeasureSizes() {
+	atomic.StoreInt32(&measureSizes, 1)
+}
+
+//Sent records msg sent to copies nodes, its size is the one of its network encoding if the sizes are measured
+func (r *Run) Sent(msg interface{}, copies int) {
+	if copies <= 0 {
+		return
+	}
+	name := reflect.Indirect(reflect.ValueOf(msg)).Type().Name()
+	MessagesSent.Add(float64(copies), r.node, r.protocol, name)
+	if atomic.LoadInt32(&measureSizes) == 0 {
+		return
+	}
+	if b, err := network.Marshal(msg); err == nil {
+		BytesSent.Add(float64(copies*len(b)), r.node, r.protocol, name)
+	}
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"sync"
+
+	"gopkg.in/dedis/onet.v1"
+	"gopkg.in/dedis/onet.v1/log"
+	"gopkg.in/dedis/onet.v1/network"
+)
+
+//ServiceName is the name of the metrics service
+const ServiceName = "Metrics"
+
+//AddressEnv is the environment variable holding the address of the metrics endpoint, e.g. localhost:9100. The
+//endpoint is started by the first conode of the process, and none is started if it isn't set.
+const AddressEnv = "METRICS_ADDRESS"
+
+//GetMetrics asks a conode for its metrics
+type GetMetrics struct{}
+
+//Metrics holds the metrics of a conode in the Prometheus text format
+type Metrics struct {
+	Text string
+}
+
+func init() {
+	network.RegisterMessage(&GetMetrics{})
+	network.RegisterMessage(&Metrics{})
+	if _, err := onet.RegisterNewService(ServiceName, newService); err != nil {
+		log.Fatal(err)
+	}
+}
+
+//Service answers the GetMetrics requests of the clients with the metrics of its conode
+type Service struct {
+	*onet.ServiceProcessor
+}
+
+var listen sync.Once
+
+func newService(c *onet.Context) onet.Service {
+	s := &Service{ServiceProcessor: onet.NewServiceProcessor(c)}
+	if address := os.Getenv(AddressEnv); address != "" {
+		listen.Do(func() {
+			go func() {
+				if err := Listen(address); err != nil {
+					log.Error("Metrics endpoint stopped:", err)
+				}
+			}()
+		})
+	}
+	if err := s.RegisterHandler(s.GetMetrics); err != nil {
+		log.Error("Couldn't register the metrics handler:", err)
+	}
+	return s
+}
+
+//GetMetrics returns the series of the Default registry recorded by our conode
+func (s *Service) GetMetrics(req *GetMetrics) (*Metrics, onet.ClientError) {
+	MeasureSizes()
+	var b bytes.Buffer
+	if err := Default.Write(&b, s.ServerIdentity().Address.String()); err != nil {
+		return nil, onet.NewClientError(err)
+	}
+	return &Metrics{Text: b.String()}, nil
+}
+
+//Listen serves the metrics of the Default registry at address/metrics, those of every conode of the process
+//unless the scraper picks one with ?node=<address>. It only returns on error.
+func Listen(address string) error {
+	MeasureSizes()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Default)
+	return http.ListenAndServe(address, mux)
+}
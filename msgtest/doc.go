@@ -0,0 +1,6 @@
+/*Package msgtest holds the helpers the tests of the protocols share to check their message validation.
+
+Malformed feeds a handler messages broken by mutations, one at a time and then in random combinations, and
+fails the test if one is accepted.
+*/
+package msgtest
@@ -0,0 +1,35 @@
+package msgtest
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+//Malformed checks that valid accepts the messages built by fresh, and feeds them broken by one or more mutations
+//to handle, which must refuse them. The mutations are applied in order, those changing the length of a slice come last.
+func Malformed(t testing.TB, name string, mutations []func(interface{}), fresh func() interface{}, valid func(interface{}) error, handle func(interface{}) error) {
+	if err := valid(fresh()); err != nil {
+		t.Fatalf("Valid %s was refused: %s", name, err)
+	}
+	for i, mutate := range mutations {
+		msg := fresh()
+		mutate(msg)
+		if handle(msg) == nil {
+			t.Fatalf("Malformed %s %d was accepted", name, i)
+		}
+	}
+	//then random combinations of them
+	rnd := rand.New(rand.NewSource(int64(len(name))))
+	for k := 0; k < 200; k++ {
+		msg := fresh()
+		chosen := rnd.Perm(len(mutations))[:1+rnd.Intn(len(mutations))]
+		sort.Ints(chosen)
+		for _, m := range chosen {
+			mutations[m](msg)
+		}
+		if handle(msg) == nil {
+			t.Fatalf("Malformed %s was accepted", name)
+		}
+	}
+}
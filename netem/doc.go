@@ -0,0 +1,15 @@
+/*Package netem emulates a wide area network for the local simulations, where the conodes talk over localhost,
+so that the wall clock times of the RandShare protocols can be compared as if the nodes were spread over the
+world.
+
+A Network given to Set applies to every protocol run of the process. Each Link has a one way latency, a jitter,
+a bandwidth and a packet loss rate; the nodes are spread over regions by their index in the roster, a Local link
+joining two nodes of a region and a Remote link two nodes of different regions.
+
+The protocols hand their messages to Send instead of sending them, which sends each one once it would have
+arrived. As between two conodes, the messages of a link arrive in order and nothing is dropped: onet runs over
+TCP, so a lost packet is sent again after a retransmission timeout, which is what the loss rate costs. The
+emulation only delays the sending side, the bytes counted by the simulation are unchanged.
+Without a network Send sends right away and Enabled lets the protocols keep their usual calls.
+*/
+package netem
@@ -0,0 +1,213 @@
+package netem
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"gopkg.in/dedis/onet.v1"
+	"gopkg.in/dedis/onet.v1/log"
+	"gopkg.in/dedis/onet.v1/network"
+)
+
+//MSS is the payload of a TCP packet, a message of n bytes is lost packet by packet
+const MSS = 1460
+
+//MinRTO is the shortest time TCP waits before it retransmits a lost packet
+const MinRTO = 200 * time.Millisecond
+
+//Link gives the conditions of the link from a node to another
+type Link struct {
+	Latency   time.Duration //one way
+	Jitter    time.Duration //the latency of each message varies uniformly by up to Jitter either way
+	Bandwidth float64       //in bytes per second, 0 leaves the link uncapped
+	Loss      float64       //the probability that a packet is lost, it is then retransmitted after a timeout
+}
+
+//Network gives the links between the nodes. They are spread over Regions by their index in the roster, node i
+//being in region i%Regions: Local links the nodes of a region and Remote those of different regions. With
+//Regions 0 or 1, every link is Remote.
+type Network struct {
+	Local   Link
+	Remote  Link
+	Regions int
+}
+
+//Check refuses the links that can't be emulated
+func (n *Network) Check() error {
+	for _, l := range []Link{n.Local, n.Remote} {
+		if l.Latency < 0 || l.Jitter < 0 || l.Bandwidth < 0 {
+			return errors.New("Negative link condition")
+		}
+		if l.Jitter > l.Latency {
+			return errors.New("The jitter can't be larger than the latency")
+		}
+		if l.Loss < 0 || l.Loss >= 1 {
+			return errors.New("The loss has to be in [0, 1)")
+		}
+	}
+	if n.Regions < 0 {
+		return errors.New("Negative number of regions")
+	}
+	return nil
+}
+
+//Link returns the link from the node at index from to the node at index to
+func (n *Network) Link(from int, to int) Link {
+	if n.Regions > 1 && from%n.Regions == to%n.Regions {
+		return n.Local
+	}
+	return n.Remote
+}
+
+var emulated struct {
+	sync.RWMutex
+	*Network
+	links map[string]*queue
+}
+
+//Set makes the messages of every protocol run of the process cross n, nil sends them directly (the default)
+func Set(n *Network) {
+	emulated.Lock()
+	defer emulated.Unlock()
+	emulated.Network = n
+	emulated.links = make(map[string]*queue)
+}
+
+//Enabled tells if the messages cross an emulated network
+func Enabled() bool {
+	emulated.RLock()
+	defer emulated.RUnlock()
+	return emulated.Network != nil
+}
+
+//Send calls send once msg, sent now by from, would have arrived at to. It doesn't wait: the messages of a link
+//wait in its queue and arrive in the order they were sent, as on the TCP connection between two conodes.
+//Without a network, send is called right away. The errors of send are logged. As msg is sent later, it must not
+//be changed once given.
+func Send(from *onet.TreeNode, to *onet.TreeNode, msg interface{}, send func() error) {
+	emulated.RLock()
+	n := emulated.Network
+	emulated.RUnlock()
+	if n == nil {
+		if err := send(); err != nil {
+			log.Error(err)
+		}
+		return
+	}
+	l := n.Link(from.RosterIndex, to.RosterIndex)
+	size := 0
+	if l.Bandwidth > 0 || l.Loss > 0 {
+		if b, err := network.Marshal(msg); err == nil {
+			size = len(b)
+		}
+	}
+	link(from, to).push(l, size, send)
+}
+
+//link returns the queue of the link from one conode to another
+func link(from *onet.TreeNode, to *onet.TreeNode) *queue {
+	key := from.ServerIdentity.Address.String() + ">" + to.ServerIdentity.Address.String()
+	emulated.Lock()
+	defer emulated.Unlock()
+	q, ok := emulated.links[key]
+	if !ok {
+		q = &queue{}
+		emulated.links[key] = q
+	}
+	return q
+}
+
+//delivery is a message waiting in a queue
+type delivery struct {
+	at   time.Time
+	send func() error
+}
+
+//queue holds the messages crossing a link. Its goroutine runs while there are messages.
+type queue struct {
+	sync.Mutex
+	waiting []*delivery
+	busy    time.Time //the end of the transmission of the last message
+	last    time.Time //the arrival of the last message
+	running bool
+}
+
+//push computes when a message of size bytes arrives and queues it: its transmission starts once the previous
+//one ends, then it takes the latency, the jitter and a timeout per lost packet, and it never overtakes the
+//previous message
+func (q *queue) push(l Link, size int, send func() error) {
+	q.Lock()
+	defer q.Unlock()
+	start := time.Now()
+	if q.busy.After(start) {
+		start = q.busy
+	}
+	q.busy = start.Add(transmission(l, size))
+	at := q.busy.Add(delay(l) + retransmissions(l, size))
+	if at.Before(q.last) {
+		at = q.last
+	}
+	q.last = at
+	q.waiting = append(q.waiting, &delivery{at: at, send: send})
+	if !q.running {
+		q.running = true
+		go q.deliver()
+	}
+}
+
+//deliver sends the messages of the queue when they arrive
+func (q *queue) deliver() {
+	for {
+		q.Lock()
+		if len(q.waiting) == 0 {
+			q.running = false
+			q.Unlock()
+			return
+		}
+		d := q.waiting[0]
+		q.waiting = q.waiting[1:]
+		q.Unlock()
+		time.Sleep(time.Until(d.at))
+		if err := d.send(); err != nil {
+			//the conodes may have stopped while the message was crossing
+			log.Lvl2("Couldn't send over the emulated link:", err)
+		}
+	}
+}
+
+//transmission is the time to put size bytes on the link
+func transmission(l Link, size int) time.Duration {
+	if l.Bandwidth <= 0 {
+		return 0
+	}
+	return time.Duration(float64(size) / l.Bandwidth * float64(time.Second))
+}
+
+//delay is the latency of one message, the jitter drawn uniformly
+func delay(l Link) time.Duration {
+	if l.Jitter <= 0 {
+		return l.Latency
+	}
+	return l.Latency - l.Jitter + time.Duration(rand.Int63n(int64(2*l.Jitter)+1))
+}
+
+//retransmissions is the time lost to the packets of a message of size bytes that have to be sent again, each
+//loss costing a retransmission timeout: twice the worst latency, at least MinRTO
+func retransmissions(l Link, size int) time.Duration {
+	if l.Loss <= 0 {
+		return 0
+	}
+	rto := 2 * (l.Latency + l.Jitter)
+	if rto < MinRTO {
+		rto = MinRTO
+	}
+	var lost time.Duration
+	for p := 0; p <= size/MSS; p++ {
+		for rand.Float64() < l.Loss {
+			lost += rto
+		}
+	}
+	return lost
+}
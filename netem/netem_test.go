@@ -0,0 +1,108 @@
+package netem
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"gopkg.in/dedis/onet.v1"
+	"gopkg.in/dedis/onet.v1/network"
+)
+
+type testMessage struct {
+	Data []byte
+}
+
+func init() {
+	network.RegisterMessage(testMessage{})
+}
+
+func nodes(n int) []*onet.TreeNode {
+	var list []*onet.TreeNode
+	for i := 0; i < n; i++ {
+		si := &network.ServerIdentity{Address: network.NewTCPAddress(fmt.Sprintf("127.0.0.1:%d", 2000+i))}
+		list = append(list, &onet.TreeNode{ServerIdentity: si, RosterIndex: i})
+	}
+	return list
+}
+
+func TestLink(t *testing.T) {
+	local, remote := Link{Latency: time.Millisecond}, Link{Latency: 100 * time.Millisecond}
+	n := &Network{Local: local, Remote: remote, Regions: 3}
+	if n.Link(0, 3) != local || n.Link(1, 2) != remote {
+		t.Fatal("Wrong links")
+	}
+	n.Regions = 1
+	if n.Link(0, 3) != remote {
+		t.Fatal("Every link should be remote with a single region")
+	}
+	if err := n.Check(); err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range []Link{{Latency: -1}, {Latency: 1, Jitter: 2}, {Loss: 1}} {
+		if (&Network{Remote: l}).Check() == nil {
+			t.Fatalf("%+v should be refused", l)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	defer Set(nil)
+	list := nodes(3)
+	sent := false
+	Send(list[0], list[1], &testMessage{}, func() error { sent = true; return nil })
+	if !sent {
+		t.Fatal("Without a network the message should be sent right away")
+	}
+
+	Set(&Network{
+		Local:   Link{Latency: 10 * time.Millisecond, Jitter: 10 * time.Millisecond},
+		Remote:  Link{Latency: 50 * time.Millisecond, Bandwidth: 1e5},
+		Regions: 2,
+	})
+	var mutex sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		i := i
+		wg.Add(1)
+		Send(list[0], list[2], &testMessage{}, func() error {
+			mutex.Lock()
+			order = append(order, i)
+			mutex.Unlock()
+			wg.Done()
+			return nil
+		})
+	}
+	wg.Wait()
+	for i, j := range order {
+		if i != j {
+			t.Fatal("The messages of a link should arrive in order", order)
+		}
+	}
+
+	//10kB at 100kB/s take 100ms on top of the latency
+	var remote time.Duration
+	wg.Add(1)
+	start := time.Now()
+	Send(list[0], list[1], &testMessage{Data: make([]byte, 10000)}, func() error {
+		remote = time.Since(start)
+		wg.Done()
+		return nil
+	})
+	wg.Wait()
+	if remote < 150*time.Millisecond {
+		t.Fatal("The message arrived too early", remote)
+	}
+}
+
+func TestRetransmissions(t *testing.T) {
+	if retransmissions(Link{Latency: time.Second}, 1e6) != 0 {
+		t.Fatal("Nothing should be retransmitted without loss")
+	}
+	lost := retransmissions(Link{Latency: time.Millisecond, Loss: 0.5}, 100*MSS)
+	if lost < 10*MinRTO || lost%MinRTO != 0 {
+		t.Fatal("Wrong retransmission time", lost)
+	}
+}
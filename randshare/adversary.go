@@ -0,0 +1,17 @@
+package randshare
+
+import (
+	"github.com/dedis/student_17_randomness/adversary"
+	"gopkg.in/dedis/crypto.v0/random"
+	"gopkg.in/dedis/crypto.v0/share"
+)
+
+//crashed tells if the scenario of the simulation crashed our node, it then drops every message (see package adversary)
+func (rs *RandShare) crashed() bool {
+	return adversary.Of(rs.Index(), len(rs.List())) == adversary.Crashed
+}
+
+//invalidShare returns a share that doesn't match our commitments, as an invalid dealer sends it
+func (rs *RandShare) invalidShare(s *share.PriShare) *share.PriShare {
+	return &share.PriShare{I: s.I, V: rs.Suite().Scalar().Pick(random.Stream)}
+}
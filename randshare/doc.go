@@ -1,12 +1,59 @@
 /*
-The protocol has two messages:
-	- Announce which is sent from the root down the tree
-	- Reply which is sent back up to the root
+The protocol has five messages:
+	- Announce which is broadcast once by each dealer with the commitments to its polynomial
+	- PrivateShare which carries the share of one node and is only sent to that node
+	- Reply which carries the votes of a node on every dealer
+	- Commitment which carries the decisions of a node on every dealer
+	- Share which reveals the shares of the good dealers
 
+Each dealer used to broadcast n announces repeating its commitments, one with each share; it now broadcasts
+them once and sends each share to its target only. No runs comparing the two are committed yet. Both are run
+from package simulation with simulation.toml, unchanged so that Servers, BF and Hosts are the same, once with
+the randshare package of the commit before the split and once with this one, each time moving
+test_data/simulation.csv to test_data/randshare/announce_before.csv and announce_after.csv:
+	go run . simulation.toml
+	go run ../report before=test_data/randshare/announce_before.csv after=test_data/randshare/announce_after.csv
+bw-randshare gives the saving, which should be modest since the n^2 Reply and Commitment broadcasts dominated the bandwidth; each node
+now sends one Reply and one Commitment holding the votes on every dealer.
 
-A simple protocol uses four files:
+A Reply holds a complaint, the share the node received, for each dealer whose share doesn't match its
+commitments. A complaint is only counted as a negative vote if the share is the one of the accuser and fails
+the check against the PubPoly of the dealer, so a false accusation is ignored. More than faulty complaints
+exclude the dealer.
+
+Every revealed share is checked against the commitments of its dealer before it is used, the secret of a dealer
+is recovered from the first threshold valid shares and the nodes that reveal an invalid one are reported by Liars.
+
+Random returns the collective string with a Transcript holding the commitments of every dealer, the tallies of
+the replies and commitments, the tracker and the revealed shares. Verify recovers the secrets of the good dealers
+from it and checks that they add up to the collective string. Announce and PrivateShare carry the number of faulty
+nodes and the purpose given to the root, so that every node uses the same threshold.
+
+Every message is checked by validate.go before it is used: the indexes must be those of nodes of the
+session, Reply and Commitment must have one entry per node, Announce threshold commitments and the points
+and shares must be set. A malformed message is refused with an error.
+
+adversary.go lets the simulations crash nodes, make dealers send invalid shares and delay nodes, see package
+adversary. The nodes wait for the shares of every dealer, so a crashed one stops the run unless the root sets a
+timeout (timeout.go): once it is over a node votes the dealers it misses something from absent, an absence
+counts as a negative vote, so they end up with 0 in the tracker and out of n'. netem.go sends the
+messages across the emulated network of the simulation if it sets one, see package netem.
+
+fuzz_test.go holds FuzzHandlers, which feeds a node sequences of valid and corrupted messages decoded from the
+fuzz input: the handlers must not panic and the collective string, once there is one, must be the sum of the
+secrets of the good dealers, must never change and must verify. Run it with go test -fuzz=FuzzHandlers.
+
+Each run records its phases (deal, vote, commit, reveal), the shares it checked, the votes it received, the
+dealers it excluded and the messages it sent in the metrics package, see metrics/doc.go.
+Its steps are traced as events stamped with the session, node, phase, peer and outcome, see trace/doc.go.
+
+The protocol uses these files:
 - struct.go defines the messages sent around
 - randshare.go defines the actions for each message
-- randshare_test.go tests the protocol in a local test
+- verify.go verifies a transcript
+- validate.go checks the incoming messages
+- timeout.go ends the deal phase without the missing dealers
+- randshare_test.go and verify_test.go test the protocol in a local test
+- fuzz_test.go fuzzes the handlers
 */
 package randshare
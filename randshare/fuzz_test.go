@@ -0,0 +1,177 @@
+package randshare
+
+import (
+	"testing"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/random"
+	"gopkg.in/dedis/crypto.v0/share"
+	"gopkg.in/dedis/onet.v1"
+	"gopkg.in/dedis/onet.v1/network"
+)
+
+//fuzzDealing is the polynomial of a dealer, its commitments and the shares of every node
+type fuzzDealing struct {
+	secret   abstract.Scalar
+	announce *Announce
+	shares   []*share.PriShare
+}
+
+func newFuzzDealings(nodes int, faulty int) []*fuzzDealing {
+	var dealings []*fuzzDealing
+	for d := 0; d < nodes; d++ {
+		priPoly := share.NewPriPoly(network.Suite, faulty+1, nil, random.Stream)
+		b, commits := priPoly.Commit(nil).Info()
+		dealings = append(dealings, &fuzzDealing{
+			secret:   priPoly.Secret(),
+			announce: &Announce{Faulty: faulty, Src: d, B: b, Commits: commits},
+			shares:   priPoly.Shares(nodes),
+		})
+	}
+	return dealings
+}
+
+//corrupt returns a copy of s whose value doesn't match the commitments anymore
+func corrupt(s *share.PriShare) *share.PriShare {
+	return &share.PriShare{I: s.I, V: network.Suite.Scalar().Add(s.V, network.Suite.Scalar().One())}
+}
+
+//handle gives rs the message described by kind, a and v, built from the dealings. Our node is 0 and deals
+//dealings[0].
+func handle(rs *RandShare, dealings []*fuzzDealing, kind byte, a byte, v byte) {
+	nodes := len(dealings)
+	src := int(a) % (nodes + 1) //nodes is a wrong index
+	dealer := src % nodes
+	from := &onet.TreeNode{RosterIndex: src}
+	switch kind % 5 {
+	case 0:
+		announce := *dealings[dealer].announce
+		announce.Src = src
+		announce.Commits = append([]abstract.Point{}, announce.Commits...)
+		if v&1 != 0 { //the commitments mix two polynomials, no share matches them
+			announce.Commits[0] = dealings[(dealer+1)%nodes].announce.Commits[0]
+		}
+		if v&2 != 0 {
+			announce.Commits = announce.Commits[1:]
+		}
+		rs.HandleAnnounce(StructAnnounce{TreeNode: from, Announce: announce})
+	case 1:
+		tgt := int(v>>2) % (nodes + 1)
+		s := dealings[dealer].shares[tgt%nodes]
+		if v&1 != 0 {
+			s = corrupt(s)
+		}
+		rs.HandlePrivateShare(StructPrivateShare{TreeNode: from, PrivateShare: PrivateShare{Faulty: rs.faulty, Src: src, Tgt: tgt, Share: s}})
+	case 2: //bit j of v : src complains about dealer j, with its true share if bit 4 is set (a false accusation)
+		reply := Reply{Src: src}
+		for j := 0; j < nodes; j++ {
+			vote := &DealerVote{Tgt: j}
+			if v&(1<<uint(j)) != 0 {
+				vote.Complaint = corrupt(dealings[j].shares[dealer])
+				if v&16 != 0 {
+					vote.Complaint = dealings[j].shares[dealer]
+				}
+			}
+			reply.Votes = append(reply.Votes, vote)
+		}
+		if v&32 != 0 {
+			reply.Votes = reply.Votes[1:]
+		}
+		rs.HandleReply(StructReply{TreeNode: from, Reply: reply})
+	case 3: //bit j of v : src decides that dealer j is good
+		commitment := Commitment{Src: src}
+		for j := 0; j < nodes; j++ {
+			commitment.Votes = append(commitment.Votes, v&(1<<uint(j)) != 0)
+		}
+		if v&32 != 0 {
+			commitment.Votes = commitment.Votes[1:]
+		}
+		rs.HandleCommitment(StructCommitment{TreeNode: from, Commitment: commitment})
+	case 4: //src reveals its share of the dealer v
+		revealed := int(v>>2) % nodes
+		s := dealings[revealed].shares[dealer]
+		if v&1 != 0 {
+			s = corrupt(s)
+		}
+		if v&2 != 0 {
+			s = dealings[revealed].shares[(dealer+1)%nodes]
+		}
+		rs.HandleShare(StructShare{TreeNode: from, Share: Share{Src: revealed, Tgt: src, Share: s}})
+	}
+}
+
+//FuzzHandlers drives the handlers of a node with the messages described by the fuzz input, three bytes each.
+//They must not panic, and once there is a collective string it must be the sum of the secrets of the good
+//dealers, must not change and must verify.
+func FuzzHandlers(f *testing.F) {
+	nodes, faulty := 4, 1
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(1, true) //a single node, Broadcast doesn't send anything
+	defer local.CloseAll()
+	dealings := newFuzzDealings(nodes, faulty)
+
+	//an honest run : every announce and private share, then the replies, commitments and shares of the others
+	var honest []byte
+	for a := byte(1); a < 4; a++ {
+		honest = append(honest, 0, a, 0, 1, a, 0)
+	}
+	for a := byte(1); a < 4; a++ {
+		honest = append(honest, 2, a, 0)
+	}
+	for a := byte(1); a < 4; a++ {
+		honest = append(honest, 3, a, 15)
+	}
+	for d := byte(0); d < 4; d++ {
+		honest = append(honest, 4, 1, d<<2, 4, 2, d<<2)
+	}
+	f.Add(honest)
+	f.Add(append([]byte{4, 1, 1, 4, 2, 2, 2, 3, 4, 0, 2, 1}, honest...))
+	f.Add([]byte{0, 4, 3, 1, 4, 5, 2, 4, 63, 3, 4, 63, 4, 4, 3})
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		protocol, err := local.CreateProtocol("RandShare", tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs := protocol.(*RandShare)
+		defer rs.TreeNodeInstance.Done()
+		if err := rs.Setup(nodes, faulty, ""); err != nil {
+			t.Fatal(err)
+		}
+		//we dealt dealings[0], as deal would
+		rs.announces[0] = dealings[0].announce
+		rs.privShares[0] = dealings[0].shares[0]
+		rs.replies[0] = &DealerVote{Tgt: 0}
+
+		var coString abstract.Scalar
+		for k := 0; k+2 < len(ops); k += 3 {
+			handle(rs, dealings, ops[k], ops[k+1], ops[k+2])
+			if !rs.coStringReady {
+				continue
+			}
+			if coString != nil {
+				if !coString.Equal(rs.coString) {
+					t.Fatal("The collective string changed")
+				}
+				continue
+			}
+			coString = rs.coString.Clone()
+			expected := rs.Suite().Scalar().Zero()
+			for j, d := range dealings {
+				if rs.tracker[j] == 1 {
+					expected.Add(expected, d.secret)
+				}
+			}
+			if !expected.Equal(coString) {
+				t.Fatal("The collective string isn't the sum of the secrets of the good dealers")
+			}
+			random, transcript, err := rs.Random()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := Verify(random, transcript); err != nil {
+				t.Fatal("The collective string doesn't verify:", err)
+			}
+		}
+	})
+}
@@ -2,17 +2,27 @@ package randshare
 
 import (
 	"errors"
+	"sort"
 	"time"
 
+	"github.com/dedis/student_17_randomness/adversary"
+	"github.com/dedis/student_17_randomness/metrics"
+	"github.com/dedis/student_17_randomness/trace"
 	"gopkg.in/dedis/crypto.v0/abstract"
 	"gopkg.in/dedis/crypto.v0/random"
 	"gopkg.in/dedis/crypto.v0/share"
 	"gopkg.in/dedis/onet.v1"
-	//"gopkg.in/dedis/onet.v1/log"
+	"gopkg.in/dedis/onet.v1/log"
 )
 
+//hooks lets the tests make dealers misbehave, they are nil otherwise
+var hooks struct {
+	share  func(dealer int, target int, s *share.PriShare) *share.PriShare //the share the dealer sends to target
+	reveal func(node int, dealer int, s *share.PriShare) *share.PriShare   //the share of dealer the node reveals
+}
+
 func init() {
-	onet.GlobalProtocolRegister("RandShare", NewRandShare)
+	onet.GlobalProtocolRegister(Name, NewRandShare)
 }
 
 // NewProtocol initialises the structure for use in one round
@@ -20,7 +30,7 @@ func NewRandShare(n *onet.TreeNodeInstance) (onet.ProtocolInstance, error) {
 	t := &RandShare{
 		TreeNodeInstance: n,
 	}
-	err := t.RegisterHandlers(t.HandleAnnounce, t.HandleReply, t.HandleCommitment, t.HandleShare)
+	err := t.RegisterHandlers(t.HandleAnnounce, t.HandlePrivateShare, t.HandleReply, t.HandleCommitment, t.HandleShare)
 	return t, err
 }
 
@@ -33,22 +43,41 @@ func (rs *RandShare) Setup(nodes int, faulty int, purpose string) error {
 	rs.nPrime = -1
 
 	rs.announces = make(map[int]*Announce)
-	rs.replies = make(map[int]*Reply)
+	rs.privShares = make(map[int]*share.PriShare)
+	rs.replies = make(map[int]*DealerVote)
+	rs.replied = make(map[int]bool)
+	rs.accusations = make(map[int][]*accusation)
+	rs.decided = make(map[int]bool)
+	rs.committed = false
 	rs.votes = make(map[int]*Vote)
 	rs.commits = make(map[int]*Vote)
 	rs.tracker = make(map[int]int)
 	rs.shares = make(map[int]map[int]*share.PriShare)
 	rs.secrets = make(map[int]*abstract.Scalar)
+	rs.pending = make(map[int][]*Share)
+	rs.liars = make(map[int]bool)
 
 	rs.coStringReady = false
-	rs.Done = make(chan bool, 0)
+	rs.Done = make(chan bool, 1) //buffered so that nodes nobody waits for don't block their handlers
+	rs.run = metrics.NewRun(rs.ServerIdentity().Address.String(), "randshare")
+	//plain randshare has no session identifier, the round of the onet instance is the same at every node
+	rs.tracer = trace.New("randshare", rs.Token().RoundID.String(), rs.Index())
+	rs.tracer.Eventf("setup", trace.NoPeer, "started", "%d nodes, %d faulty", nodes, faulty)
 
 	return nil
 }
 
 func (rs *RandShare) Start() error {
+	rs.handling.Lock()
+	defer rs.handling.Unlock()
 	rs.time = time.Now()
+	return rs.deal()
+}
 
+//deal creates our polynomial si(x), broadcasts its commitments once and sends each node its share si(j). The deal
+//phase ends once we checked the share of every dealer or the timeout is over (see closeDeal).
+func (rs *RandShare) deal() error {
+	adversary.Hold(rs.Index(), rs.nodes)
 	//compute priPoly si(x)
 	priPoly := share.NewPriPoly(rs.Suite(), rs.threshold, nil, random.Stream)
 	//compute shares si(x)
@@ -57,166 +86,319 @@ func (rs *RandShare) Start() error {
 	pubPoly := priPoly.Commit(nil)
 	b, commits := pubPoly.Info()
 
-	//send share si(j)
-	for j := 0; j < rs.nodes; j++ {
-
-		announce := &Announce{
-			Src:     rs.Index(),
-			Tgt:     j,
-			Share:   shares[j],
-			B:       b,
-			Commits: commits,
-		}
+	//the commitments are the same for everyone, they are sent once
+	timeout := int64(rs.timeout / time.Millisecond)
+	announce := &Announce{Faulty: rs.faulty, Purpose: rs.purpose, Timeout: timeout, Src: rs.Index(), B: b, Commits: commits}
+	rs.announces[rs.Index()] = announce
+	rs.privShares[rs.Index()] = shares[rs.Index()]
+	rs.replies[rs.Index()] = &DealerVote{Tgt: rs.Index()}
+	if err := rs.broadcast(announce); err != nil {
+		return err
+	}
+	rs.run.Sent(announce, len(rs.List())-1)
+	rs.tracer.Event("deal", trace.NoPeer, "announce sent")
 
-		//.SendTo isn't working, for simulation purpose We replace it with a Brodcast
-		//and nodes will deal with a receive msg iff the msg.Tgt matches their Id
-		/*if j != rs.Index() {
-			if err := rs.SendTo(rs.List()[j], announce); err != nil {
-				return err
-			}
-		} else {
-			rs.announces[j] = announce
-		}*/
-		//============================================
+	//send share si(j) to j only
+	for _, node := range rs.List() {
+		j := node.RosterIndex
 		if j == rs.Index() {
-			rs.announces[j] = announce
-			rs.replies[j] = &Reply{Src: j, Tgt: j}
+			continue
+		}
+		priShare := shares[j]
+		if hooks.share != nil {
+			priShare = hooks.share(rs.Index(), j, priShare)
 		}
-		if err := rs.Broadcast(announce); err != nil {
+		if adversary.Of(rs.Index(), rs.nodes) == adversary.Invalid {
+			priShare = rs.invalidShare(priShare)
+		}
+		privateShare := &PrivateShare{Faulty: rs.faulty, Purpose: rs.purpose, Timeout: timeout, Src: rs.Index(), Tgt: j, Share: priShare}
+		if err := rs.sendTo(node, privateShare); err != nil {
 			return err
 		}
-		//============================================
+		rs.run.Sent(privateShare, 1)
+		rs.tracer.Event("deal", j, "share sent")
 	}
+	rs.deadline(rs.closeDeal)
 	return nil
 }
 
-func (rs *RandShare) HandleAnnounce(announce StructAnnounce) error {
+//setupFromMessage sets rs up with the parameters of the root and deals our shares if the message is the first
+//we receive
+func (rs *RandShare) setupFromMessage(faulty int, purpose string, timeout int64) error {
+	if rs.nodes != 0 {
+		return nil
+	}
+	nodes := len(rs.List())
+	if faulty < 0 || 3*faulty >= nodes {
+		return errors.New("Wrong number of faulty nodes")
+	}
+	if timeout < 0 {
+		return errors.New("Negative timeout")
+	}
+	if err := rs.Setup(nodes, faulty, purpose); err != nil {
+		return err
+	}
+	rs.timeout = time.Duration(timeout) * time.Millisecond
+	return rs.deal()
+}
 
+//HandleAnnounce stores the commitments of a dealer
+func (rs *RandShare) HandleAnnounce(announce StructAnnounce) error {
+	if rs.crashed() {
+		return nil
+	}
+	rs.handling.Lock()
+	defer rs.handling.Unlock()
 	msg := &announce.Announce
-	//added for simulation purpose
-	//============================
-	if (msg.Tgt != rs.Index()) || (rs.Index() == msg.Src) {
+	if err := rs.setupFromMessage(msg.Faulty, msg.Purpose, msg.Timeout); err != nil {
+		return err
+	}
+	if err := rs.validAnnounce(msg, announce.TreeNode); err != nil {
+		rs.tracer.Eventf("deal", msg.Src, "invalid announce", "%v", err)
+		return err
+	}
+	rs.tracer.Event("deal", msg.Src, "announce received")
+	if _, ok := rs.announces[msg.Src]; ok || msg.Src == rs.Index() {
 		return nil
 	}
-	//=============================
-	if rs.nodes == 0 { // if it's our first message, we set up rs and send our shares before anwsering
-		//setup of our node
-		nodes := len(rs.List())
-		if err := rs.Setup(nodes, nodes/3, ""); err != nil {
+	rs.announces[msg.Src] = msg
+	if err := rs.check(msg.Src); err != nil {
+		return err
+	}
+	//the accusations against that dealer can now be checked
+	accusations := rs.accusations[msg.Src]
+	delete(rs.accusations, msg.Src)
+	for _, a := range accusations {
+		rs.accuse(a.src, msg.Src, a.complaint)
+	}
+	if len(accusations) > 0 {
+		if err := rs.commit(); err != nil {
 			return err
 		}
-		//sending our announce
-		priPoly := share.NewPriPoly(rs.Suite(), rs.threshold, nil, random.Stream)
-		shares := priPoly.Shares(rs.nodes)
-		pubPoly := priPoly.Commit(nil)
-		b, commits := pubPoly.Info()
-
-		for j := 0; j < rs.nodes; j++ {
-			announce := &Announce{
-				Src:     rs.Index(),
-				Tgt:     j,
-				Share:   shares[j],
-				B:       b,
-				Commits: commits,
-			}
-			//.SendTo isn't working, for simulation purpose We replace it with a Brodcast
-			//and nodes will deal with a receive msg iff the msg.Tgt matches their Id
-			/*if j != rs.Index() {
-				if err := rs.SendTo(rs.List()[j], announce); err != nil {
-					return err
-				}
-			} else {
-				rs.announces[j] = announce
-			}*/
-			//============================================
-			if j == rs.Index() {
-				rs.announces[j] = announce
-				rs.replies[j] = &Reply{Src: j, Tgt: j}
-			}
-			if err := rs.Broadcast(announce); err != nil {
-				return err
-			}
-			//============================================
+	}
+	//so can the shares of that dealer revealed before
+	pending := rs.pending[msg.Src]
+	delete(rs.pending, msg.Src)
+	for _, revealed := range pending {
+		if err := rs.reveal(revealed); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	//now we can handle the announce
-	rs.announces[msg.Src] = msg
-	reply := &Reply{Src: rs.Index(), Tgt: msg.Src}
-	PubPoly := share.NewPubPoly(rs.Suite(), msg.B, msg.Commits)
-	shareIsCorrect := PubPoly.Check(msg.Share)
+//HandlePrivateShare stores the share a dealer sent us
+func (rs *RandShare) HandlePrivateShare(privateShare StructPrivateShare) error {
+	if rs.crashed() {
+		return nil
+	}
+	rs.handling.Lock()
+	defer rs.handling.Unlock()
+	msg := &privateShare.PrivateShare
+	if err := rs.setupFromMessage(msg.Faulty, msg.Purpose, msg.Timeout); err != nil {
+		return err
+	}
+	if err := rs.validPrivateShare(msg, privateShare.TreeNode); err != nil {
+		rs.tracer.Eventf("deal", msg.Src, "invalid share", "%v", err)
+		return err
+	}
+	rs.tracer.Event("deal", msg.Src, "share received")
+	if _, ok := rs.privShares[msg.Src]; ok || msg.Tgt != rs.Index() || msg.Src == rs.Index() {
+		return nil
+	}
+	rs.privShares[msg.Src] = msg.Share
+	return rs.check(msg.Src)
+}
+
+//check verifies the share of src against its commitments once we have both, and sends our replies when
+//every dealer is checked
+func (rs *RandShare) check(src int) error {
+	announce, ok := rs.announces[src]
+	priShare, ok2 := rs.privShares[src]
+	if _, ok3 := rs.replies[src]; !ok || !ok2 || ok3 { //the timeout may have counted src as absent already
+		return nil
+	}
+	reply := &DealerVote{Tgt: src}
+	PubPoly := share.NewPubPoly(rs.Suite(), announce.B, announce.Commits)
+	shareIsCorrect := PubPoly.Check(priShare)
 	if !shareIsCorrect {
-		reply.Vote = msg.Share
+		reply.Complaint = priShare
+		rs.run.Shares("private", 0, 1)
+		rs.tracer.Event("deal", src, "complaint")
+	} else {
+		rs.run.Shares("private", 1, 0)
+		rs.tracer.Event("deal", src, "share valid")
 	}
-	rs.replies[msg.Src] = reply
-	//log.LLvlf1("id %d is storing for src %d, rep leng %d", rs.Index(), msg.Src, len(rs.replies))
-	if len(rs.replies) == rs.nodes { //if each share arrived (not our own), we send them
-		for j := 0; j < rs.nodes; j++ {
-			//if j != rs.Index() {
-
-			/*if rs.replies[j] == nil {
-				log.LLvlf1("NEINE id %d vs j %d", rs.Index(), j)
-			}*/
-			//log.LLvlf1("id %d is brodcasting %+v", rs.Index(), rs.replies)
-			if err := rs.Broadcast(rs.replies[j]); err != nil {
-				return err
-			}
+	rs.replies[src] = reply
+	return rs.sendReplies()
+}
 
-		}
+//sendReplies sends all our votes at once when we have one on every dealer
+func (rs *RandShare) sendReplies() error {
+	if len(rs.replies) != rs.nodes { //we wait for each share (not our own) or the timeout
+		return nil
 	}
-	return nil
+	bulk := &Reply{Src: rs.Index()}
+	for j := 0; j < rs.nodes; j++ {
+		bulk.Votes = append(bulk.Votes, rs.replies[j])
+	}
+	adversary.Hold(rs.Index(), rs.nodes)
+	if err := rs.broadcast(bulk); err != nil {
+		return err
+	}
+	rs.run.Sent(bulk, len(rs.List())-1)
+	rs.run.Phase("deal")
+	rs.tracer.Event("vote", trace.NoPeer, "reply sent")
+	return rs.countReply(bulk)
 }
 
+//HandleReply counts the votes of a node on every dealer. Once every dealer is decided, we send our decisions.
 func (rs *RandShare) HandleReply(reply StructReply) error {
-
-	msg := &reply.Reply
-
-	if _, ok := rs.votes[msg.Tgt]; !ok {
-		rs.votes[msg.Tgt] = &Vote{PositiveCounter: 0, NegativeCounter: 0}
+	if rs.crashed() {
+		return nil
+	}
+	rs.handling.Lock()
+	defer rs.handling.Unlock()
+	if err := rs.validReply(&reply.Reply, reply.TreeNode); err != nil {
+		rs.tracer.Eventf("vote", reply.Src, "invalid reply", "%v", err)
+		return err
 	}
+	rs.tracer.Event("vote", reply.Src, "reply received")
+	rs.run.Votes("Reply", len(reply.Votes))
+	return rs.countReply(&reply.Reply)
+}
 
-	if &msg.Vote != nil {
-		rs.votes[msg.Tgt].PositiveCounter += 1
-	} else {
-		rs.votes[msg.Tgt].NegativeCounter += 1
+//countReply adds the votes of msg.Src to our counters, a complaint only counts as a negative vote once we checked
+//it against the commitments of the dealer (see accuse). An absence can't be checked, it always counts as a
+//negative vote. A node only votes once, under its own index (see validReply), so the faulty nodes alone can't
+//reach more than faulty negative votes.
+func (rs *RandShare) countReply(msg *Reply) error {
+	if rs.coStringReady {
+		return nil //we are done, the late messages don't change anything
 	}
+	if rs.replied[msg.Src] {
+		return nil //we already counted the votes of that node
+	}
+	rs.replied[msg.Src] = true
 
-	//by default vote is neg
-	commit := &Commitment{Src: rs.Index(), Tgt: msg.Tgt}
-	if rs.votes[msg.Tgt].PositiveCounter > 2*rs.faulty {
-		commit.Vote = 1
-		if err := rs.Broadcast(commit); err != nil {
-			return err
+	for _, vote := range msg.Votes {
+		if vote == nil || vote.Tgt < 0 || vote.Tgt >= rs.nodes {
+			continue
 		}
-	}
-	if rs.votes[msg.Tgt].NegativeCounter > rs.faulty {
-		commit.Vote = 0
-		if err := rs.Broadcast(commit); err != nil {
-			return err
+		if vote.Absent {
+			rs.vote(vote.Tgt).NegativeCounter += 1
+		} else if vote.Complaint == nil {
+			rs.vote(vote.Tgt).PositiveCounter += 1
+		} else if _, ok := rs.announces[vote.Tgt]; ok {
+			rs.accuse(msg.Src, vote.Tgt, vote.Complaint)
+		} else { //we can't check it before the commitments of the dealer arrive (see HandleAnnounce)
+			rs.accusations[vote.Tgt] = append(rs.accusations[vote.Tgt], &accusation{src: msg.Src, complaint: vote.Complaint})
 		}
 	}
+	return rs.commit()
+}
 
-	return nil
+//vote returns the counters of dealer j
+func (rs *RandShare) vote(j int) *Vote {
+	if _, ok := rs.votes[j]; !ok {
+		rs.votes[j] = &Vote{PositiveCounter: 0, NegativeCounter: 0}
+	}
+	return rs.votes[j]
 }
 
-func (rs *RandShare) HandleCommitment(commitment StructCommitment) error {
+//accuse counts the complaint of src against dealer as a negative vote if it holds : the share must be the one
+//of src and must not match the commitments of the dealer. A false accusation is ignored.
+//A faulty node can still make up a share, but only for its own index and once (see countReply).
+func (rs *RandShare) accuse(src int, dealer int, complaint *share.PriShare) {
+	announce := rs.announces[dealer]
+	if complaint.I != src || complaint.V == nil {
+		return
+	}
+	PubPoly := share.NewPubPoly(rs.Suite(), announce.B, announce.Commits)
+	if PubPoly.Check(complaint) {
+		rs.tracer.Eventf("vote", src, "false accusation", "against %d", dealer)
+		return //the share is valid, the dealer is falsely accused
+	}
+	rs.tracer.Eventf("vote", src, "accusation", "against %d", dealer)
+	rs.vote(dealer).NegativeCounter += 1
+}
 
-	msg := &commitment.Commitment
+//commit broadcasts our Commitment once every dealer has more than 2*faulty positive or more than faulty negative
+//votes
+func (rs *RandShare) commit() error {
+	if rs.committed {
+		return nil
+	}
+	decisions := make([]bool, rs.nodes)
+	for j := 0; j < rs.nodes; j++ {
+		vote, ok := rs.votes[j]
+		switch {
+		case ok && vote.PositiveCounter > 2*rs.faulty:
+			decisions[j] = true
+		case ok && vote.NegativeCounter > rs.faulty:
+			decisions[j] = false
+		default:
+			return nil //we wait for more votes on j
+		}
+	}
+	rs.committed = true
+	commit := &Commitment{Src: rs.Index(), Votes: decisions}
+	adversary.Hold(rs.Index(), rs.nodes)
+	if err := rs.broadcast(commit); err != nil {
+		return err
+	}
+	rs.run.Sent(commit, len(rs.List())-1)
+	rs.run.Phase("vote")
+	rs.tracer.Event("commit", trace.NoPeer, "commitment sent")
+	return rs.countCommitment(commit)
+}
 
-	if _, ok := rs.commits[msg.Tgt]; !ok {
-		rs.commits[msg.Tgt] = &Vote{PositiveCounter: 0, NegativeCounter: 0}
+//HandleCommitment counts the decisions of a node on every dealer. Once every dealer is decided by more than
+//2*faulty nodes, we reveal the shares of the good ones.
+func (rs *RandShare) HandleCommitment(commitment StructCommitment) error {
+	if rs.crashed() {
+		return nil
 	}
-	if msg.Vote == 1 {
-		rs.commits[msg.Tgt].PositiveCounter += 1
-	} else {
-		rs.commits[msg.Tgt].NegativeCounter += 1
+	rs.handling.Lock()
+	defer rs.handling.Unlock()
+	if err := rs.validCommitment(&commitment.Commitment, commitment.TreeNode); err != nil {
+		rs.tracer.Eventf("commit", commitment.Src, "invalid commitment", "%v", err)
+		return err
 	}
+	rs.tracer.Event("commit", commitment.Src, "commitment received")
+	rs.run.Votes("Commitment", len(commitment.Votes))
+	return rs.countCommitment(&commitment.Commitment)
+}
 
-	if rs.commits[msg.Tgt].PositiveCounter > 2*rs.faulty {
-		rs.tracker[msg.Tgt] = 1
+//countCommitment adds the decisions of msg.Src to our counters and updates the tracker
+func (rs *RandShare) countCommitment(msg *Commitment) error {
+	if rs.coStringReady {
+		return nil //we are done, the late messages don't change anything
 	}
-	if rs.commits[msg.Tgt].NegativeCounter > 2*rs.faulty {
-		rs.tracker[msg.Tgt] = 0
+	if rs.decided[msg.Src] {
+		return nil //we already counted the decisions of that node
+	}
+	rs.decided[msg.Src] = true
+
+	for j, vote := range msg.Votes {
+		if j >= rs.nodes {
+			break
+		}
+		if _, ok := rs.commits[j]; !ok {
+			rs.commits[j] = &Vote{PositiveCounter: 0, NegativeCounter: 0}
+		}
+		if vote {
+			rs.commits[j].PositiveCounter += 1
+		} else {
+			rs.commits[j].NegativeCounter += 1
+		}
+
+		if rs.commits[j].PositiveCounter > 2*rs.faulty {
+			rs.tracker[j] = 1
+		}
+		if rs.commits[j].NegativeCounter > 2*rs.faulty {
+			rs.tracker[j] = 0
+		}
 	}
 
 	if (len(rs.tracker) == (rs.nodes)) && (rs.nPrime == -1) { // we have all entries in the tracker and didn't send the share already
@@ -227,34 +409,86 @@ func (rs *RandShare) HandleCommitment(commitment StructCommitment) error {
 				rs.nPrime += 1
 			}
 		}
+		rs.run.Phase("commit")
+		rs.run.Excluded(rs.nodes - rs.nPrime)
 		if rs.nPrime <= rs.faulty {
+			rs.tracer.Eventf("commit", trace.NoPeer, "aborted", "%d good dealers", rs.nPrime)
 			return errors.New("aborted, not enough secure nodes")
 		}
-		share := &Share{Tgt: rs.Index(), NPrime: rs.nPrime} //sj(i) the share sent to i by j
+		rs.tracer.Eventf("commit", trace.NoPeer, "decided", "%d good dealers", rs.nPrime)
+		adversary.Hold(rs.Index(), rs.nodes)
 		for j := 0; j < rs.nodes; j++ {
-			if rs.tracker[j] == 1 {
-				share.Src = j
-				share.Share = rs.announces[j].Share
+			if priShare, ok := rs.privShares[j]; ok && rs.tracker[j] == 1 { //we can only give the shares we received
+				//sj(i) the share sent to i by j, a message of its own as it may still be on its way (see package netem)
+				share := &Share{Src: j, Tgt: rs.Index(), NPrime: rs.nPrime, Share: priShare}
+				if hooks.reveal != nil {
+					share.Share = hooks.reveal(rs.Index(), j, priShare)
+				}
 				//we send the share sj(i) to the root so that we can reconstruct the collective random string
-				if err := rs.Broadcast(share); err != nil {
+				if err := rs.broadcast(share); err != nil {
 					return err
 				}
+				rs.run.Sent(share, len(rs.List())-1)
+				rs.tracer.Eventf("reveal", trace.NoPeer, "share revealed", "of %d", j)
 			}
 		}
+		return rs.combine() //the shares may all be in already
 	}
 	return nil
 }
 
+//HandleShare collects the revealed shares of a dealer to recover its secret sj(0)
 func (rs *RandShare) HandleShare(structShare StructShare) error {
+	if rs.crashed() {
+		return nil
+	}
+	rs.handling.Lock()
+	defer rs.handling.Unlock()
+	if err := rs.validRevealed(&structShare.Share, structShare.TreeNode); err != nil {
+		rs.tracer.Eventf("reveal", structShare.Tgt, "invalid revealed share", "%v", err)
+		return err
+	}
+	return rs.reveal(&structShare.Share)
+}
 
-	msg := &structShare.Share
-
+//reveal checks the share msg.Tgt revealed against the commitments of the dealer msg.Src. The valid shares are kept
+//until there are threshold of them to recover sj(0), the invalid ones are dropped and their sender is reported
+//as a liar (see Liars). The shares that arrive once sj(0) is recovered aren't checked anymore.
+func (rs *RandShare) reveal(msg *Share) error {
+	if msg.Src < 0 || msg.Src >= rs.nodes || rs.coStringReady {
+		return nil
+	}
+	announce, ok := rs.announces[msg.Src]
+	if !ok { //we check it once the commitments of the dealer arrive (see HandleAnnounce)
+		rs.pending[msg.Src] = append(rs.pending[msg.Src], msg)
+		rs.tracer.Eventf("reveal", msg.Tgt, "share pending", "of %d", msg.Src)
+		return nil
+	}
+	if _, ok := rs.secrets[msg.Src]; ok {
+		return nil
+	}
 	if _, ok := rs.shares[msg.Src]; !ok {
 		rs.shares[msg.Src] = make(map[int]*share.PriShare)
 	}
+	if _, ok := rs.shares[msg.Src][msg.Tgt]; ok {
+		return nil
+	}
+
+	PubPoly := share.NewPubPoly(rs.Suite(), announce.B, announce.Commits)
+	if msg.Share == nil || msg.Share.V == nil || msg.Share.I != msg.Tgt || !PubPoly.Check(msg.Share) {
+		log.Lvlf2("Node %d revealed an invalid share of dealer %d", msg.Tgt, msg.Src)
+		rs.mutex.Lock()
+		rs.liars[msg.Tgt] = true
+		rs.mutex.Unlock()
+		rs.run.Shares("revealed", 0, 1)
+		rs.tracer.Eventf("reveal", msg.Tgt, "liar", "share of %d", msg.Src)
+		return nil
+	}
 	rs.shares[msg.Src][msg.Tgt] = msg.Share
+	rs.run.Shares("revealed", 1, 0)
+	rs.tracer.Eventf("reveal", msg.Tgt, "share valid", "of %d", msg.Src)
 
-	if len(rs.shares[msg.Src]) == rs.threshold { //if we collected enough shares to recover sj(0)
+	if len(rs.shares[msg.Src]) == rs.threshold { //if we collected enough valid shares to recover sj(0)
 		//gathering shares sj() in a list
 		sharesList := make([]*share.PriShare, len(rs.shares[msg.Src]))
 		i := 0
@@ -263,37 +497,85 @@ func (rs *RandShare) HandleShare(structShare StructShare) error {
 			i++
 		}
 
-		secret, err := share.RecoverSecret(rs.Suite(), sharesList, rs.threshold, msg.NPrime)
+		secret, err := share.RecoverSecret(rs.Suite(), sharesList, rs.threshold, rs.nodes) //the shares are indexed up to nodes, not nPrime
 		if err != nil {
 			return err
 		}
 		rs.secrets[msg.Src] = &secret
+		rs.tracer.Event("reveal", msg.Src, "secret recovered")
 	}
 
-	if (len(rs.secrets) == rs.nPrime) && !rs.coStringReady {
-		coString := rs.Suite().Scalar().Zero()
-		for j := range rs.secrets {
-			abstract.Scalar.Add(coString, coString, *rs.secrets[j])
+	return rs.combine()
+}
+
+//combine computes the collective string once the tracker is complete and the secrets of all the good dealers
+//are recovered. The secrets of the other dealers, recovered from shares revealed anyway, are left out.
+func (rs *RandShare) combine() error {
+	if rs.nPrime <= rs.faulty || rs.coStringReady {
+		return nil //the tracker isn't complete or there are not enough good dealers
+	}
+	coString := rs.Suite().Scalar().Zero()
+	for j := 0; j < rs.nodes; j++ {
+		if rs.tracker[j] != 1 {
+			continue
+		}
+		secret, ok := rs.secrets[j]
+		if !ok {
+			return nil //we wait for the secret of j
 		}
-		//log.Lvlf1("Costring recovered at node %d is %+v", rs.Index(), coString)
-		rs.coString = coString
-		rs.coStringReady = true
-		rs.Done <- true
+		abstract.Scalar.Add(coString, coString, *secret)
+	}
+	//log.Lvlf1("Costring recovered at node %d is %+v", rs.Index(), coString)
+	rs.mutex.Lock()
+	rs.coString = coString
+	rs.coStringReady = true
+	rs.mutex.Unlock()
+	rs.run.Phase("reveal")
+	rs.run.Done()
+	rs.tracer.Event("done", trace.NoPeer, "collective string")
+	select {
+	case rs.Done <- true:
+	default: //nobody waits for that node
 	}
-
 	return nil
 }
 
-func (rs *RandShare) Random() ([]byte, error) {
+//Liars returns the sorted indexes of the nodes that revealed an invalid share to us
+func (rs *RandShare) Liars() []int {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	liars := make([]int, 0, len(rs.liars))
+	for i := range rs.liars {
+		liars = append(liars, i)
+	}
+	sort.Ints(liars)
+	return liars
+}
+
+//Random returns the collective string created by our protocol and the
+//associated transcript so that it can be verified by a third party
+func (rs *RandShare) Random() ([]byte, *Transcript, error) {
 	rs.mutex.Lock()
 	defer rs.mutex.Unlock()
 
 	if !rs.coStringReady {
-		return nil, errors.New("Not ready")
+		return nil, nil, errors.New("Not ready")
 	}
 	rb, err := rs.coString.MarshalBinary()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	transcript := &Transcript{
+		Suite:     rs.Suite().String(),
+		Nodes:     rs.nodes,
+		Faulty:    rs.faulty,
+		Purpose:   rs.purpose,
+		Announces: rs.announces,
+		Votes:     rs.votes,
+		Commits:   rs.commits,
+		Tracker:   rs.tracker,
+		Shares:    rs.shares,
 	}
-	return rb, nil
+	return rb, transcript, nil
 }
@@ -1,11 +1,19 @@
 package randshare
 
 import (
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/dedis/student_17_randomness/adversary"
+	"github.com/dedis/student_17_randomness/metrics"
+	"github.com/dedis/student_17_randomness/netem"
+	"github.com/dedis/student_17_randomness/trace"
+	"gopkg.in/dedis/crypto.v0/random"
+	"gopkg.in/dedis/crypto.v0/share"
 	"gopkg.in/dedis/onet.v1"
 	"gopkg.in/dedis/onet.v1/log"
+	"gopkg.in/dedis/onet.v1/network"
 )
 
 func TestRandShare(t *testing.T) {
@@ -36,7 +44,527 @@ func TestRandShare(t *testing.T) {
 	select {
 	case <-rs.Done:
 		log.Lvlf1("RandShare done")
+		random, transcript, err := rs.Random()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Verify(random, transcript); err != nil {
+			t.Fatal(err)
+		}
 	case <-time.After(time.Second * time.Duration(nodes) * 2):
 		t.Fatal("RandShare timeout")
 	}
 }
+
+//TestRandShareMetrics checks that a run is recorded in the metrics. They are shared by the whole process, so
+//only what this run adds is checked.
+func TestRandShareMetrics(t *testing.T) {
+	nodes, faulty := 4, 1
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol("RandShare", tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	if err := rs.Setup(nodes, faulty, "metrics"); err != nil {
+		t.Fatal(err)
+	}
+	node := rs.ServerIdentity().Address.String()
+	runs := metrics.Default.Get("randshare_runs_total", node, "randshare")
+	verified := metrics.Default.Get("randshare_shares_verified_total", node, "randshare", "private")
+	if err := rs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rs.Done:
+	case <-time.After(time.Second * time.Duration(nodes) * 2):
+		t.Fatal("RandShare timeout")
+	}
+	if metrics.Default.Get("randshare_runs_total", node, "randshare") != runs+1 {
+		t.Fatal("The run wasn't counted")
+	}
+	if metrics.Default.Get("randshare_shares_verified_total", node, "randshare", "private") != verified+float64(nodes-1) {
+		t.Fatal("The verified shares weren't counted")
+	}
+}
+
+//TestBulkVotes feeds Reply and Commitment messages to a node and checks how the votes on every dealer are counted
+func TestBulkVotes(t *testing.T) {
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(1, true) //a single node, Broadcast doesn't send anything
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol("RandShare", tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	nodes, faulty := 4, 1
+	if err := rs.Setup(nodes, faulty, "bulk votes"); err != nil {
+		t.Fatal(err)
+	}
+
+	//nodes 1 and 2 complain about dealer 2, more than faulty complaints make it bad
+	reply := func(src int, complain bool) *Reply {
+		r := &Reply{Src: src}
+		for j := 0; j < nodes; j++ {
+			v := &DealerVote{Tgt: j}
+			if complain && j == 2 {
+				v.Complaint = &share.PriShare{I: src, V: rs.Suite().Scalar().Pick(random.Stream)}
+			}
+			r.Votes = append(r.Votes, v)
+		}
+		return r
+	}
+	if err := rs.countReply(reply(1, true)); err != nil {
+		t.Fatal(err)
+	}
+	if len(rs.accusations[2]) != 1 || rs.votes[2] != nil {
+		t.Fatal("The complaint can't be checked before the Announce of the dealer")
+	}
+	b, commits := share.NewPriPoly(rs.Suite(), 2, nil, random.Stream).Commit(nil).Info()
+	if err := rs.HandleAnnounce(StructAnnounce{TreeNode: &onet.TreeNode{RosterIndex: 2}, Announce: Announce{Src: 2, B: b, Commits: commits}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.countReply(reply(2, true)); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.countReply(reply(2, false)); err != nil { //counted once
+		t.Fatal(err)
+	}
+	if rs.committed || rs.votes[0].PositiveCounter != 2 || rs.votes[2].NegativeCounter != 2 {
+		t.Fatal("Wrong counters", rs.votes[0], rs.votes[2])
+	}
+	if err := rs.countReply(reply(3, false)); err != nil {
+		t.Fatal(err)
+	}
+	if !rs.committed {
+		t.Fatal("Every dealer is decided, the commitment should have been sent")
+	}
+
+	//our own commitment is counted, two more decide every dealer
+	decisions := []bool{true, true, false, true}
+	for _, src := range []int{1, 1, 2} {
+		if err := rs.countCommitment(&Commitment{Src: src, Votes: decisions}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for j, good := range decisions {
+		if (rs.tracker[j] == 1) != good {
+			t.Fatalf("Wrong decision for dealer %d", j)
+		}
+	}
+	if rs.nPrime != 3 {
+		t.Fatal("Wrong number of good dealers", rs.nPrime)
+	}
+}
+
+//TestFalseAccusation checks that a complaint with a valid share or with the share of another node is ignored
+func TestFalseAccusation(t *testing.T) {
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(1, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol("RandShare", tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	if err := rs.Setup(4, 1, "false accusation"); err != nil {
+		t.Fatal(err)
+	}
+	priPoly := share.NewPriPoly(rs.Suite(), 2, nil, random.Stream)
+	b, commits := priPoly.Commit(nil).Info()
+	rs.announces[2] = &Announce{Src: 2, B: b, Commits: commits}
+
+	rs.accuse(1, 2, priPoly.Shares(4)[1])                                              //valid share
+	rs.accuse(1, 2, &share.PriShare{I: 3, V: rs.Suite().Scalar().Pick(random.Stream)}) //not the share of 1
+	if rs.votes[2] != nil {
+		t.Fatal("False accusations were counted", rs.votes[2])
+	}
+	rs.accuse(1, 2, &share.PriShare{I: 1, V: rs.Suite().Scalar().Pick(random.Stream)})
+	if rs.votes[2] == nil || rs.votes[2].NegativeCounter != 1 {
+		t.Fatal("The accusation holds and should be counted")
+	}
+}
+
+//TestForgedReplies checks that a node can't vote under the index of others: one node sends replies claiming
+//every index, each one with a made-up complaint against an honest dealer, and only its own is counted
+func TestForgedReplies(t *testing.T) {
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(1, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol("RandShare", tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	nodes, faulty := 4, 1
+	if err := rs.Setup(nodes, faulty, "forged replies"); err != nil {
+		t.Fatal(err)
+	}
+	b, commits := share.NewPriPoly(rs.Suite(), faulty+1, nil, random.Stream).Commit(nil).Info()
+	rs.announces[2] = &Announce{Src: 2, B: b, Commits: commits}
+
+	faultyNode := &onet.TreeNode{RosterIndex: 3}
+	for src := 0; src < nodes; src++ {
+		reply := &Reply{Src: src}
+		for j := 0; j < nodes; j++ {
+			vote := &DealerVote{Tgt: j}
+			if j == 2 {
+				vote.Complaint = &share.PriShare{I: src, V: rs.Suite().Scalar().Pick(random.Stream)}
+			}
+			reply.Votes = append(reply.Votes, vote)
+		}
+		err := rs.HandleReply(StructReply{TreeNode: faultyNode, Reply: *reply})
+		if (err == nil) != (src == faultyNode.RosterIndex) {
+			t.Fatal("The reply of node 3 in the name of", src, "was handled wrongly:", err)
+		}
+	}
+	if rs.votes[2] == nil || rs.votes[2].NegativeCounter != 1 || len(rs.replied) != 1 {
+		t.Fatal("The forged replies were counted", rs.votes[2], rs.replied)
+	}
+}
+
+//TestBadDealer runs the protocol with a dealer that sends wrong shares to every node, it must be excluded
+func TestBadDealer(t *testing.T) {
+	nodes, faulty, bad := 7, 2, 3
+	hooks.share = func(dealer int, target int, s *share.PriShare) *share.PriShare {
+		if dealer != bad {
+			return s
+		}
+		return &share.PriShare{I: s.I, V: network.Suite.Scalar().Add(s.V, network.Suite.Scalar().One())}
+	}
+	defer func() { hooks.share = nil }()
+
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol("RandShare", tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	if err := rs.Setup(nodes, faulty, "bad dealer"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rs.Done:
+	case <-time.After(time.Second * time.Duration(nodes) * 2):
+		t.Fatal("RandShare timeout")
+	}
+	if rs.tracker[bad] != 0 || rs.nPrime != nodes-1 {
+		t.Fatal("The bad dealer wasn't excluded", rs.tracker, rs.nPrime)
+	}
+	if _, ok := rs.secrets[bad]; ok {
+		t.Fatal("The secret of the bad dealer was recovered")
+	}
+}
+
+//TestAdversary runs the protocol with an invalid dealer and a delayed node, the dealer must be excluded and the
+//run must still end
+func TestAdversary(t *testing.T) {
+	nodes, faulty := 7, 2
+	adversary.Set(&adversary.Scenario{Invalid: 1, Delayed: 1, Delay: 100 * time.Millisecond})
+	defer adversary.Set(nil)
+
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol("RandShare", tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	if err := rs.Setup(nodes, faulty, "adversary"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rs.Done:
+	case <-time.After(time.Second * time.Duration(nodes) * 2):
+		t.Fatal("RandShare timeout")
+	}
+	random, transcript, err := rs.Random()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transcript.Tracker[nodes-1] != 0 || rs.nPrime != nodes-1 {
+		t.Fatal("The invalid dealer wasn't excluded", transcript.Tracker, rs.nPrime)
+	}
+	if err := Verify(random, transcript); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//TestNetwork runs the protocol across an emulated wide area network, its messages delayed, reordered between
+//links and slowed down by lost packets
+func TestNetwork(t *testing.T) {
+	nodes, faulty := 7, 2
+	netem.Set(&netem.Network{
+		Local:   netem.Link{Latency: time.Millisecond},
+		Remote:  netem.Link{Latency: 30 * time.Millisecond, Jitter: 10 * time.Millisecond, Bandwidth: 1e6, Loss: 0.01},
+		Regions: 2,
+	})
+	defer netem.Set(nil)
+
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol("RandShare", tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	if err := rs.Setup(nodes, faulty, "network"); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := rs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rs.Done:
+	case <-time.After(time.Second * time.Duration(nodes) * 2):
+		t.Fatal("RandShare timeout")
+	}
+	if time.Since(start) < 4*20*time.Millisecond {
+		t.Fatal("The four phases should have crossed the network", time.Since(start))
+	}
+	random, transcript, err := rs.Random()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(random, transcript); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//TestCrashed runs the protocol with a crashed node and no timeout: every node waits for the shares of every
+//dealer, so the run can't end
+func TestCrashed(t *testing.T) {
+	nodes, faulty := 4, 1
+	adversary.Set(&adversary.Scenario{Crashed: 1})
+	defer adversary.Set(nil)
+
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol("RandShare", tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	if err := rs.Setup(nodes, faulty, "crashed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rs.Done:
+		t.Fatal("The run ended without the crashed dealer")
+	case <-time.After(time.Second):
+	}
+}
+
+//TestCrashedTimeout runs the protocol with a crashed node and a timeout: the nodes vote the crashed dealer absent
+//once it is over, so the run must end without it
+func TestCrashedTimeout(t *testing.T) {
+	nodes, faulty := 4, 1
+	adversary.Set(&adversary.Scenario{Crashed: 1})
+	defer adversary.Set(nil)
+
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol("RandShare", tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	if err := rs.Setup(nodes, faulty, "crashed"); err != nil {
+		t.Fatal(err)
+	}
+	rs.SetTimeout(500 * time.Millisecond)
+	if err := rs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rs.Done:
+	case <-time.After(time.Second * time.Duration(nodes) * 2):
+		t.Fatal("The run didn't end without the crashed dealer")
+	}
+	random, transcript, err := rs.Random()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transcript.Tracker[nodes-1] != 0 || rs.nPrime != nodes-1 {
+		t.Fatal("The crashed dealer wasn't left out", transcript.Tracker[nodes-1], rs.nPrime)
+	}
+	if err := Verify(random, transcript); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//TestInvalidReveal feeds revealed shares to a node, the invalid ones must be ignored and their senders reported
+func TestInvalidReveal(t *testing.T) {
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(1, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol("RandShare", tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	nodes, faulty := 4, 1
+	if err := rs.Setup(nodes, faulty, "invalid reveal"); err != nil {
+		t.Fatal(err)
+	}
+	priPoly := share.NewPriPoly(rs.Suite(), faulty+1, nil, random.Stream)
+	shares := priPoly.Shares(nodes)
+	b, commits := priPoly.Commit(nil).Info()
+
+	reveal := func(src int, s *share.PriShare) {
+		if err := rs.HandleShare(StructShare{TreeNode: &onet.TreeNode{RosterIndex: src}, Share: Share{Src: 2, Tgt: src, Share: s}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reveal(1, shares[1]) //before the Announce of the dealer
+	if err := rs.HandleAnnounce(StructAnnounce{TreeNode: &onet.TreeNode{RosterIndex: 2}, Announce: Announce{Src: 2, B: b, Commits: commits}}); err != nil {
+		t.Fatal(err)
+	}
+	reveal(3, &share.PriShare{I: 3, V: rs.Suite().Scalar().Pick(random.Stream)}) //wrong value
+	reveal(0, shares[3])                                                         //the share of another node
+	if _, ok := rs.secrets[2]; ok {
+		t.Fatal("The secret was recovered with invalid shares")
+	}
+	if liars := rs.Liars(); len(liars) != 2 || liars[0] != 0 || liars[1] != 3 {
+		t.Fatal("Wrong liars", liars)
+	}
+	reveal(3, shares[3])
+	if secret, ok := rs.secrets[2]; !ok || !(*secret).Equal(priPoly.Secret()) {
+		t.Fatal("Wrong secret recovered")
+	}
+}
+
+//TestLyingNodes runs the protocol with nodes revealing wrong shares, the collective string must not change
+func TestLyingNodes(t *testing.T) {
+	nodes, faulty := 7, 2
+	var mutex sync.Mutex
+	dealt := make(map[int][]*share.PriShare) //the shares sent by every dealer
+	hooks.share = func(dealer int, target int, s *share.PriShare) *share.PriShare {
+		mutex.Lock()
+		defer mutex.Unlock()
+		dealt[dealer] = append(dealt[dealer], s)
+		return s
+	}
+	hooks.reveal = func(node int, dealer int, s *share.PriShare) *share.PriShare {
+		if node == 1 || node == 2 {
+			return &share.PriShare{I: s.I, V: network.Suite.Scalar().Pick(random.Stream)}
+		}
+		return s
+	}
+	defer func() { hooks.share, hooks.reveal = nil, nil }()
+
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol("RandShare", tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	if err := rs.Setup(nodes, faulty, "lying nodes"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rs.Done:
+	case <-time.After(time.Second * time.Duration(nodes) * 2):
+		t.Fatal("RandShare timeout")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	coString := network.Suite.Scalar().Zero()
+	for dealer, shares := range dealt {
+		secret, err := share.RecoverSecret(network.Suite, shares, faulty+1, nodes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !(*rs.secrets[dealer]).Equal(secret) {
+			t.Fatalf("Wrong secret recovered for dealer %d", dealer)
+		}
+		coString.Add(coString, secret)
+	}
+	if !rs.coString.Equal(coString) {
+		t.Fatal("Wrong collective string")
+	}
+}
+
+//TestTrace checks that the events of a run let us follow every node to the collective string
+func TestTrace(t *testing.T) {
+	recorder := &trace.Recorder{}
+	trace.SetSink(recorder)
+	defer trace.SetSink(nil)
+
+	nodes := 4
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+	protocol, err := local.CreateProtocol("RandShare", tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	if err := rs.Setup(nodes, 1, "trace"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rs.Done:
+	case <-time.After(time.Second * 10):
+		t.Fatal("RandShare timeout")
+	}
+	session := rs.Token().RoundID.String()
+
+	//the other nodes may still be combining, we wait for their last event
+	done := func(e *trace.Event) bool { return e.Session == session && e.Phase == "done" }
+	for i := 0; i < 100 && len(recorder.Events(done)) < nodes; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	finished := make(map[int]bool)
+	for _, e := range recorder.Events(done) {
+		finished[e.Node] = true
+	}
+	if len(finished) != nodes {
+		t.Fatal("Every node should have traced its collective string", finished)
+	}
+	received := recorder.Events(func(e *trace.Event) bool {
+		return e.Session == session && e.Node == 0 && e.Outcome == "announce received"
+	})
+	if len(received) != nodes-1 {
+		t.Fatal("The root should have traced the announce of every other node", len(received))
+	}
+}
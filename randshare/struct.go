@@ -4,6 +4,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dedis/student_17_randomness/metrics"
+	"github.com/dedis/student_17_randomness/trace"
 	"gopkg.in/dedis/crypto.v0/abstract"
 	"gopkg.in/dedis/crypto.v0/share"
 	"gopkg.in/dedis/onet.v1"
@@ -14,17 +16,18 @@ import (
 const Name = "RandShare"
 
 func init() {
-	for _, p := range []interface{}{Announce{}, Reply{}, Commitment{}, Share{},
-		StructAnnounce{}, StructReply{}, StructCommitment{}, StructShare{}} {
+	for _, p := range []interface{}{Announce{}, PrivateShare{}, Reply{}, Commitment{}, Share{},
+		StructAnnounce{}, StructPrivateShare{}, StructReply{}, StructCommitment{}, StructShare{}} {
 		network.RegisterMessage(p)
 	}
 }
 
-// Announce is used to pass a message to all children.
+// Announce is broadcast once by each dealer with the commitments to its polynomial.
 type Announce struct {
+	Faulty  int    //the number of faulty nodes given to the root (see Setup)
+	Purpose string //the purpose given to the root
+	Timeout int64  //the milliseconds the root waits for the shares of the dealers (see SetTimeout)
 	Src     int
-	Tgt     int
-	Share   *share.PriShare
 	B       abstract.Point
 	Commits []abstract.Point
 }
@@ -36,11 +39,34 @@ type StructAnnounce struct {
 	Announce
 }
 
-// Reply returns the count of all children.
+//PrivateShare is the share a dealer sends to one node only
+type PrivateShare struct {
+	Faulty  int    //the number of faulty nodes given to the root (see Setup)
+	Purpose string //the purpose given to the root
+	Timeout int64  //the milliseconds the root waits for the shares of the dealers (see SetTimeout)
+	Src     int
+	Tgt     int
+	Share   *share.PriShare
+}
+
+// StructPrivateShare just contains PrivateShare and the data necessary to identify and
+// process the message in the sda framework.
+type StructPrivateShare struct {
+	*onet.TreeNode
+	PrivateShare
+}
+
+//DealerVote is the vote of a node on one dealer
+type DealerVote struct {
+	Tgt       int             //the dealer
+	Complaint *share.PriShare //positive : nil, negative : the share that doesn't match the commitments
+	Absent    bool            //negative : the share or the commitments didn't arrive before the timeout
+}
+
+//Reply carries the votes of a node on every dealer.
 type Reply struct {
-	Src  int
-	Tgt  int
-	Vote *share.PriShare //positive : nil, negative : share
+	Src   int
+	Votes []*DealerVote
 }
 
 // StructReply just contains Reply and the data necessary to identify and
@@ -50,11 +76,10 @@ type StructReply struct {
 	Reply
 }
 
-//Commitment is sent as a vote
+//Commitment carries the decisions of a node on every dealer, Votes[j] is true if dealer j is good
 type Commitment struct {
-	Src  int
-	Tgt  int
-	Vote int
+	Src   int
+	Votes []bool
 }
 
 // StructCommitment just contains Commitment and the data necessary to identify and
@@ -85,6 +110,25 @@ type Vote struct {
 	NegativeCounter int //+1 if received a neg vote
 }
 
+//Transcript is given to a third party so that it can verify the collective string (see Verify)
+type Transcript struct {
+	Suite     string                          //The name of the suite
+	Nodes     int                             //Number of nodes
+	Faulty    int                             //Number of faulty nodes
+	Purpose   string                          //The purpose
+	Announces map[int]*Announce               //The commitments of every dealer
+	Votes     map[int]*Vote                   //The tallies of the replies
+	Commits   map[int]*Vote                   //The tallies of the commitments
+	Tracker   map[int]int                     //1 for the good dealers, 0 for the bad ones
+	Shares    map[int]map[int]*share.PriShare //The revealed shares : Shares[dealer][node]
+}
+
+//accusation is a complaint of src we can't check yet
+type accusation struct {
+	src       int
+	complaint *share.PriShare
+}
+
 type RandShare struct {
 	mutex                  sync.Mutex                      //mutex
 	*onet.TreeNodeInstance                                 //tree
@@ -92,16 +136,27 @@ type RandShare struct {
 	nodes                  int                             //number of nodes
 	threshold              int                             //threhold (faulty + 1)
 	purpose                string                          //purpose of protocol run
+	timeout                time.Duration                   //how long we wait for the shares of the dealers, forever with 0
+	handling               sync.Mutex                      //serialises the handlers and the timeout (see closeDeal)
 	time                   time.Time                       //time ellapsed since protocol started
 	nPrime                 int                             //number of nodes after voting
 	announces              map[int]*Announce               //store announces that we receive
-	replies                map[int]*Reply                  //store replies before sending them 2.1 used in HandleAnnounce
+	privShares             map[int]*share.PriShare         //store the shares sj(i) the dealers sent us
+	replies                map[int]*DealerVote             //store our votes before sending them 2.1 used in HandleAnnounce
+	replied                map[int]bool                    //nodes whose Reply was counted
+	accusations            map[int][]*accusation           //complaints waiting for the Announce of the dealer
 	votes                  map[int]*Vote                   //keep track of votes for secret sj(0) used in HandleReply
+	committed              bool                            //did we send our Commitment ?
+	decided                map[int]bool                    //nodes whose Commitment was counted
 	commits                map[int]*Vote                   //keep track of commits before modif of tracker used in HandleCommitment
 	tracker                map[int]int                     //vector to keep trace of valid secret received (Vi) 2.5 used in HandleCommitment
 	shares                 map[int]map[int]*share.PriShare //store the shares for the recovery of the secret sj(0)
 	secrets                map[int]*abstract.Scalar        //store the recovered secrets to compute the collective random string
+	pending                map[int][]*Share                //revealed shares waiting for the Announce of the dealer
+	liars                  map[int]bool                    //nodes that revealed an invalid share
 	coString               abstract.Scalar                 //collective string
 	coStringReady          bool                            //is the collective string computed yet ?
 	Done                   chan bool                       //are we done ?
+	run                    *metrics.Run                    //the metrics of our run
+	tracer                 *trace.Tracer                   //the events of our run
 }
@@ -0,0 +1,46 @@
+package randshare
+
+import (
+	"time"
+
+	"gopkg.in/dedis/onet.v1/log"
+)
+
+//SetTimeout sets how long a node waits for the commitments and the share of every dealer. Once it is over the
+//node votes against the dealers it is missing something from, so the nodes decide without them and they end up
+//with 0 in the tracker, out of n'. Without it (0, the default) the nodes wait for every dealer, so a single
+//crashed node stops the run.
+//It is called by the root before Start, the other nodes learn it from the announce or the share of the root.
+func (rs *RandShare) SetTimeout(timeout time.Duration) {
+	rs.handling.Lock()
+	defer rs.handling.Unlock()
+	rs.timeout = timeout
+}
+
+//deadline calls end once the timeout is over, if there is one. end has to do nothing if the phase ended before.
+func (rs *RandShare) deadline(end func() error) {
+	if rs.timeout <= 0 {
+		return
+	}
+	time.AfterFunc(rs.timeout, func() {
+		if err := end(); err != nil {
+			log.Error("Node", rs.Index(), "couldn't go on after the timeout:", err)
+		}
+	})
+}
+
+//closeDeal ends the deal phase: the dealers we have no checked share from are voted absent and we send our votes
+func (rs *RandShare) closeDeal() error {
+	rs.handling.Lock()
+	defer rs.handling.Unlock()
+	if len(rs.replies) == rs.nodes {
+		return nil //our votes are sent
+	}
+	for j := 0; j < rs.nodes; j++ {
+		if _, ok := rs.replies[j]; !ok {
+			rs.replies[j] = &DealerVote{Tgt: j, Absent: true}
+			rs.tracer.Event("deal", j, "absent dealer")
+		}
+	}
+	return rs.sendReplies()
+}
@@ -0,0 +1,110 @@
+package randshare
+
+import (
+	"fmt"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/share"
+	"gopkg.in/dedis/onet.v1"
+)
+
+//validPoint checks that p is a point of suite, i.e. that it is set and decodes back to itself (on the curve)
+func validPoint(suite abstract.Suite, p abstract.Point) bool {
+	if p == nil {
+		return false
+	}
+	b, err := p.MarshalBinary()
+	if err != nil {
+		return false
+	}
+	q := suite.Point()
+	return q.UnmarshalBinary(b) == nil && q.Equal(p)
+}
+
+//validShare checks that a share is set and is the share of node i
+func validShare(s *share.PriShare, i int) bool {
+	return s != nil && s.V != nil && s.I == i
+}
+
+//validIndex checks that i is the index of a node of the session
+func (rs *RandShare) validIndex(i int) bool {
+	return i >= 0 && i < rs.nodes
+}
+
+//validSender checks that the index a message claims is the one of the node that sent it
+func validSender(src int, from *onet.TreeNode) bool {
+	return from != nil && from.RosterIndex == src
+}
+
+//validAnnounce checks that an announce comes from its dealer and that the commitments are threshold valid points
+func (rs *RandShare) validAnnounce(msg *Announce, from *onet.TreeNode) error {
+	if !rs.validIndex(msg.Src) || !validSender(msg.Src, from) {
+		return fmt.Errorf("Wrong announce sender %d", msg.Src)
+	}
+	if len(msg.Commits) != rs.threshold {
+		return fmt.Errorf("Announce of %d has %d commitments for a threshold of %d", msg.Src, len(msg.Commits), rs.threshold)
+	}
+	if msg.B != nil && !validPoint(rs.Suite(), msg.B) {
+		return fmt.Errorf("Malformed base point in the announce of %d", msg.Src)
+	}
+	for _, c := range msg.Commits {
+		if !validPoint(rs.Suite(), c) {
+			return fmt.Errorf("Malformed commitment in the announce of %d", msg.Src)
+		}
+	}
+	return nil
+}
+
+//validPrivateShare checks that a share comes from its dealer and is the one of its target
+func (rs *RandShare) validPrivateShare(msg *PrivateShare, from *onet.TreeNode) error {
+	if !rs.validIndex(msg.Src) || !rs.validIndex(msg.Tgt) || !validSender(msg.Src, from) {
+		return fmt.Errorf("Wrong private share from %d to %d", msg.Src, msg.Tgt)
+	}
+	if !validShare(msg.Share, msg.Tgt) {
+		return fmt.Errorf("Malformed private share from %d", msg.Src)
+	}
+	return nil
+}
+
+//validReply checks that a reply comes from its sender and holds one vote per dealer, in order, and that the
+//complaints are shares of the sender, which can't also report the dealer absent
+func (rs *RandShare) validReply(msg *Reply, from *onet.TreeNode) error {
+	if !rs.validIndex(msg.Src) || !validSender(msg.Src, from) {
+		return fmt.Errorf("Wrong reply sender %d", msg.Src)
+	}
+	if len(msg.Votes) != rs.nodes {
+		return fmt.Errorf("Reply of %d has %d votes for %d nodes", msg.Src, len(msg.Votes), rs.nodes)
+	}
+	for j, vote := range msg.Votes {
+		if vote == nil || vote.Tgt != j {
+			return fmt.Errorf("Malformed vote on %d from %d", j, msg.Src)
+		}
+		if vote.Complaint != nil && (vote.Absent || !validShare(vote.Complaint, msg.Src)) {
+			return fmt.Errorf("Malformed complaint on %d from %d", j, msg.Src)
+		}
+	}
+	return nil
+}
+
+//validCommitment checks that a commitment comes from its sender and holds one decision per dealer
+func (rs *RandShare) validCommitment(msg *Commitment, from *onet.TreeNode) error {
+	if !rs.validIndex(msg.Src) || !validSender(msg.Src, from) {
+		return fmt.Errorf("Wrong commitment sender %d", msg.Src)
+	}
+	if len(msg.Votes) != rs.nodes {
+		return fmt.Errorf("Commitment of %d has %d decisions for %d nodes", msg.Src, len(msg.Votes), rs.nodes)
+	}
+	return nil
+}
+
+//validRevealed checks the indexes of a revealed share and that it comes from the node revealing it, Tgt, whether
+//the share is the right one is checked against the commitments of the dealer (see reveal)
+func (rs *RandShare) validRevealed(msg *Share, from *onet.TreeNode) error {
+	if !rs.validIndex(msg.Src) || !rs.validIndex(msg.Tgt) || !validSender(msg.Tgt, from) {
+		return fmt.Errorf("Wrong revealed share of %d from %d", msg.Src, msg.Tgt)
+	}
+	if msg.Share == nil || msg.Share.V == nil {
+		return fmt.Errorf("Malformed revealed share of %d from %d", msg.Src, msg.Tgt)
+	}
+	return nil
+}
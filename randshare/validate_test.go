@@ -0,0 +1,123 @@
+package randshare
+
+import (
+	"testing"
+
+	"github.com/dedis/student_17_randomness/msgtest"
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/nist"
+	"gopkg.in/dedis/crypto.v0/random"
+	"gopkg.in/dedis/crypto.v0/share"
+	"gopkg.in/dedis/onet.v1"
+)
+
+func TestMalformedMessages(t *testing.T) {
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(1, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol("RandShare", tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	nodes, faulty := 4, 1
+	if err := rs.Setup(nodes, faulty, "malformed"); err != nil {
+		t.Fatal(err)
+	}
+	suite := rs.Suite()
+	priPoly := share.NewPriPoly(suite, faulty+1, nil, random.Stream)
+	shares := priPoly.Shares(nodes)
+	b, commits := priPoly.Commit(nil).Info()
+	foreign := nist.NewAES128SHA256P256().Point().Base() //not a point of our suite
+	scalar := func() abstract.Scalar { return suite.Scalar().Pick(random.Stream) }
+	from := &onet.TreeNode{RosterIndex: 1} //every message comes from node 1
+
+	msgtest.Malformed(t, "announce", []func(interface{}){
+		func(m interface{}) { m.(*Announce).Src = -1 },
+		func(m interface{}) { m.(*Announce).Src = nodes },
+		func(m interface{}) { m.(*Announce).Src = 2 },
+		func(m interface{}) { m.(*Announce).Commits[0] = nil },
+		func(m interface{}) { m.(*Announce).Commits[1] = foreign },
+		func(m interface{}) { m.(*Announce).B = foreign },
+		func(m interface{}) { m.(*Announce).Commits = m.(*Announce).Commits[:1] },
+		func(m interface{}) { m.(*Announce).Commits = append(m.(*Announce).Commits, commits[0], commits[1]) },
+	}, func() interface{} {
+		return &Announce{Faulty: faulty, Src: 1, B: b, Commits: append([]abstract.Point{}, commits...)}
+	}, func(m interface{}) error {
+		return rs.validAnnounce(m.(*Announce), from)
+	}, func(m interface{}) error {
+		return rs.HandleAnnounce(StructAnnounce{TreeNode: from, Announce: *m.(*Announce)})
+	})
+
+	msgtest.Malformed(t, "private share", []func(interface{}){
+		func(m interface{}) { m.(*PrivateShare).Src = nodes },
+		func(m interface{}) { m.(*PrivateShare).Src = 2 },
+		func(m interface{}) { m.(*PrivateShare).Tgt = -1 },
+		func(m interface{}) { m.(*PrivateShare).Share = nil },
+		func(m interface{}) { m.(*PrivateShare).Share = &share.PriShare{I: 0} },
+		func(m interface{}) { m.(*PrivateShare).Share = shares[2] },
+	}, func() interface{} {
+		return &PrivateShare{Faulty: faulty, Src: 1, Tgt: 0, Share: shares[0]}
+	}, func(m interface{}) error {
+		return rs.validPrivateShare(m.(*PrivateShare), from)
+	}, func(m interface{}) error {
+		return rs.HandlePrivateShare(StructPrivateShare{TreeNode: from, PrivateShare: *m.(*PrivateShare)})
+	})
+
+	msgtest.Malformed(t, "reply", []func(interface{}){
+		func(m interface{}) { m.(*Reply).Src = -1 },
+		func(m interface{}) { m.(*Reply).Src = 2 },
+		func(m interface{}) { m.(*Reply).Votes[2] = nil },
+		func(m interface{}) { m.(*Reply).Votes[3] = &DealerVote{Tgt: 1} },
+		func(m interface{}) {
+			m.(*Reply).Votes[0] = &DealerVote{Tgt: 0, Complaint: &share.PriShare{I: 2, V: scalar()}}
+		},
+		func(m interface{}) { m.(*Reply).Votes[1] = &DealerVote{Tgt: 1, Complaint: &share.PriShare{I: 1}} },
+		func(m interface{}) { m.(*Reply).Votes[2] = &DealerVote{Tgt: 2, Complaint: shares[1], Absent: true} },
+		func(m interface{}) { m.(*Reply).Votes = m.(*Reply).Votes[:nodes-1] },
+		func(m interface{}) { m.(*Reply).Votes = append(m.(*Reply).Votes, &DealerVote{Tgt: nodes}) },
+	}, func() interface{} {
+		r := &Reply{Src: 1}
+		for j := 0; j < nodes; j++ {
+			r.Votes = append(r.Votes, &DealerVote{Tgt: j})
+		}
+		return r
+	}, func(m interface{}) error {
+		return rs.validReply(m.(*Reply), from)
+	}, func(m interface{}) error {
+		return rs.HandleReply(StructReply{TreeNode: from, Reply: *m.(*Reply)})
+	})
+
+	msgtest.Malformed(t, "commitment", []func(interface{}){
+		func(m interface{}) { m.(*Commitment).Src = nodes },
+		func(m interface{}) { m.(*Commitment).Src = 2 },
+		func(m interface{}) { m.(*Commitment).Votes = m.(*Commitment).Votes[:1] },
+		func(m interface{}) { m.(*Commitment).Votes = append(m.(*Commitment).Votes, true) },
+	}, func() interface{} {
+		return &Commitment{Src: 1, Votes: make([]bool, nodes)}
+	}, func(m interface{}) error {
+		return rs.validCommitment(m.(*Commitment), from)
+	}, func(m interface{}) error {
+		return rs.HandleCommitment(StructCommitment{TreeNode: from, Commitment: *m.(*Commitment)})
+	})
+
+	msgtest.Malformed(t, "revealed share", []func(interface{}){
+		func(m interface{}) { m.(*Share).Src = -1 },
+		func(m interface{}) { m.(*Share).Tgt = nodes },
+		func(m interface{}) { m.(*Share).Tgt = 3 },
+		func(m interface{}) { m.(*Share).Share = nil },
+		func(m interface{}) { m.(*Share).Share = &share.PriShare{I: 1} },
+	}, func() interface{} {
+		return &Share{Src: 2, Tgt: 1, Share: shares[1]}
+	}, func(m interface{}) error {
+		return rs.validRevealed(m.(*Share), from)
+	}, func(m interface{}) error {
+		return rs.HandleShare(StructShare{TreeNode: from, Share: *m.(*Share)})
+	})
+
+	//nothing was stored
+	if len(rs.announces) != 0 || len(rs.privShares) != 0 || len(rs.replied) != 0 || len(rs.decided) != 0 || len(rs.pending) != 0 {
+		t.Fatal("A malformed message changed the state")
+	}
+}
@@ -0,0 +1,87 @@
+package randshare
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"gopkg.in/dedis/crypto.v0/share"
+	"gopkg.in/dedis/onet.v1/network"
+)
+
+//Verify checks that random is the collective string of the transcript : the tracker must follow the tallies of
+//the commitments, and the secrets of the good dealers, recovered from the revealed shares that match their
+//commitments, must add up to random.
+func Verify(random []byte, transcript *Transcript) error {
+	suite := network.Suite
+	if transcript.Suite != suite.String() {
+		return fmt.Errorf("Unknown suite %s", transcript.Suite)
+	}
+	if transcript.Nodes <= 0 || transcript.Faulty < 0 {
+		return errors.New("Wrong number of nodes")
+	}
+	threshold := transcript.Faulty + 1
+
+	//a dealer is good with more than 2*faulty positive commitments and bad with more than 2*faulty negative ones
+	if len(transcript.Tracker) != transcript.Nodes {
+		return errors.New("The tracker isn't complete")
+	}
+	var good []int
+	for j := 0; j < transcript.Nodes; j++ {
+		tracked, ok := transcript.Tracker[j]
+		commits := transcript.Commits[j]
+		switch {
+		case !ok || commits == nil:
+			return fmt.Errorf("No decision on dealer %d", j)
+		case tracked == 1 && commits.PositiveCounter > 2*transcript.Faulty:
+			good = append(good, j)
+		case tracked == 0 && commits.NegativeCounter > 2*transcript.Faulty:
+		default:
+			return fmt.Errorf("The tracker doesn't follow the commitments on dealer %d", j)
+		}
+	}
+	if len(good) <= transcript.Faulty {
+		return errors.New("Not enough good dealers")
+	}
+
+	coString := suite.Scalar().Zero()
+	for _, j := range good {
+		announce := transcript.Announces[j]
+		if announce == nil || len(announce.Commits) != threshold {
+			return fmt.Errorf("Wrong commitments for dealer %d", j)
+		}
+		PubPoly := share.NewPubPoly(suite, announce.B, announce.Commits)
+
+		//the valid shares, sorted by node so that the recovery doesn't depend on the order of the map
+		var nodes []int
+		for i := range transcript.Shares[j] {
+			nodes = append(nodes, i)
+		}
+		sort.Ints(nodes)
+		var shares []*share.PriShare
+		for _, i := range nodes {
+			s := transcript.Shares[j][i]
+			if s != nil && s.V != nil && s.I == i && i >= 0 && i < transcript.Nodes && PubPoly.Check(s) {
+				shares = append(shares, s)
+			}
+		}
+		if len(shares) < threshold {
+			return fmt.Errorf("Not enough valid shares for dealer %d", j)
+		}
+		secret, err := share.RecoverSecret(suite, shares[:threshold], threshold, transcript.Nodes)
+		if err != nil {
+			return err
+		}
+		coString.Add(coString, secret)
+	}
+
+	bs, err := coString.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(bs, random) {
+		return errors.New("CoString isn't correct")
+	}
+	return nil
+}
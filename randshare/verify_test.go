@@ -0,0 +1,101 @@
+package randshare
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/dedis/crypto.v0/random"
+	"gopkg.in/dedis/crypto.v0/share"
+	"gopkg.in/dedis/onet.v1"
+	"gopkg.in/dedis/onet.v1/network"
+)
+
+func TestVerify(t *testing.T) {
+	nodes, faulty, bad := 7, 2, 5
+	hooks.share = func(dealer int, target int, s *share.PriShare) *share.PriShare {
+		if dealer != bad {
+			return s
+		}
+		return &share.PriShare{I: s.I, V: network.Suite.Scalar().Pick(random.Stream)}
+	}
+	defer func() { hooks.share = nil }()
+
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol("RandShare", tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	if err := rs.Setup(nodes, faulty, "verify"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rs.Done:
+	case <-time.After(time.Second * time.Duration(nodes) * 2):
+		t.Fatal("RandShare timeout")
+	}
+	rb, transcript, err := rs.Random()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(rb, transcript); err != nil {
+		t.Fatal(err)
+	}
+
+	wrong := append([]byte{}, rb...)
+	wrong[0] ^= 1
+	if Verify(wrong, transcript) == nil {
+		t.Fatal("A wrong string was accepted")
+	}
+
+	//the bad dealer can't be counted in
+	transcript.Tracker[bad] = 1
+	if Verify(rb, transcript) == nil {
+		t.Fatal("A tracker that doesn't follow the commitments was accepted")
+	}
+	transcript.Tracker[bad] = 0
+
+	//the share of index -1 is the secret itself, it can't stand in for the share of a node
+	var kept []*share.PriShare
+	for _, s := range transcript.Shares[0] {
+		kept = append(kept, s)
+	}
+	secret, err := share.RecoverSecret(network.Suite, kept, faulty+1, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range kept[faulty:] {
+		delete(transcript.Shares[0], s.I)
+	}
+	transcript.Shares[0][-1] = &share.PriShare{I: -1, V: secret}
+	if Verify(rb, transcript) == nil {
+		t.Fatal("A share of negative index was accepted")
+	}
+	delete(transcript.Shares[0], -1)
+	for _, s := range kept[faulty:] {
+		transcript.Shares[0][s.I] = s
+	}
+
+	//an invalid share is skipped, but the node only kept threshold valid ones so they are all needed
+	for i := 0; i < nodes; i++ {
+		if _, ok := transcript.Shares[0][i]; !ok {
+			transcript.Shares[0][i] = &share.PriShare{I: i, V: network.Suite.Scalar().Pick(random.Stream)}
+			break
+		}
+	}
+	if err := Verify(rb, transcript); err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range transcript.Shares[0] {
+		transcript.Shares[0][i] = &share.PriShare{I: i, V: network.Suite.Scalar().Add(s.V, network.Suite.Scalar().One())}
+	}
+	if Verify(rb, transcript) == nil {
+		t.Fatal("A secret was recovered from invalid shares")
+	}
+}
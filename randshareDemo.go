@@ -14,7 +14,7 @@ func main() {
 	var input int
 	fmt.Scanln(&input)
 
-	var name = "RandShare"
+	var name = demo.Name
 	var nodes = input
 	var faulty = nodes / 3
 	var purpose = "RandShare test run"
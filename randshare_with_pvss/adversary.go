@@ -0,0 +1,25 @@
+package randsharepvss
+
+import (
+	"github.com/dedis/student_17_randomness/adversary"
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/random"
+	"gopkg.in/dedis/crypto.v0/share"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+)
+
+//crashed tells if the scenario of the simulation crashed our node, it then drops every message (see package adversary)
+func (rs *RandShare) crashed() bool {
+	return adversary.Of(rs.Index(), len(rs.List())) == adversary.Crashed
+}
+
+//invalidShares returns shares whose values don't match the proofs, as an invalid dealer sends them: they are well
+//formed but every node finds them bad
+func invalidShares(suite abstract.Suite, shares []*pvss.PubVerShare) []*pvss.PubVerShare {
+	invalid := make([]*pvss.PubVerShare, len(shares))
+	for i, s := range shares {
+		v, _ := suite.Point().Pick(nil, random.Stream)
+		invalid[i] = &pvss.PubVerShare{S: share.PubShare{I: s.S.I, V: v}, P: s.P}
+	}
+	return invalid
+}
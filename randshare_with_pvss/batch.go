@@ -0,0 +1,243 @@
+package randsharepvss
+
+import (
+	"sort"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/random"
+	"gopkg.in/dedis/crypto.v0/share"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+)
+
+//batchMin is the size under which a batch that failed is checked share by share instead of being split again
+const batchMin = 2
+
+//pippenger lists the suites whose point additions are cheap enough for the bucket method to beat plain
+//multiplications. P256 points are added in affine coordinates by Go's elliptic package, which is slower.
+var pippenger = map[string]bool{Ed25519: true, BN256: true}
+
+//VerifyEncSharesBatch checks the DLEQ proofs of the encrypted shares of one dealer against its pubPoly.
+//Instead of evaluating the pubPoly and verifying each proof (n*(t+4) multiplications), the proofs are combined
+//with random weights z_i into the two equations
+//	sum z_i*VG_i == (sum z_i*R_i)*H + sum_k (sum_i z_i*C_i*x_i^k)*A_k
+//	sum z_i*VH_i == sum (z_i*R_i)*X_i + sum (z_i*C_i)*S_i
+//that only hold (but with negligible probability) if every proof does. If the batch fails, it is split in two
+//until the invalid shares are found. It returns the positions in shares of the invalid ones, nil if all are valid.
+func VerifyEncSharesBatch(suite abstract.Suite, H abstract.Point, X []abstract.Point, pubPoly *share.PubPoly, shares []*pvss.PubVerShare) []int {
+	var bad []int
+	var positions []int
+	for p, s := range shares {
+		if !wellFormed(s) || s.S.I < 0 || s.S.I >= len(X) {
+			bad = append(bad, p)
+		} else {
+			positions = append(positions, p)
+		}
+	}
+	_, commits := pubPoly.Info()
+
+	batch := func(positions []int) bool {
+		var scalars []abstract.Scalar
+		var points []abstract.Point
+		sumR := suite.Scalar().Zero()
+		coeffs := make([]abstract.Scalar, len(commits)) //coeffs[k] = sum z_i*C_i*x_i^k
+		for k := range coeffs {
+			coeffs[k] = suite.Scalar().Zero()
+		}
+		for _, p := range positions {
+			s := shares[p]
+			z := suite.Scalar().Pick(random.Stream)
+			w := suite.Scalar().Pick(random.Stream) //independent weight for the second equation
+			zR := suite.Scalar().Mul(z, s.P.R)
+			sumR.Add(sumR, zR)
+
+			xi := suite.Scalar().SetInt64(1 + int64(s.S.I))
+			zCxk := suite.Scalar().Mul(z, s.P.C)
+			for k := range coeffs {
+				coeffs[k].Add(coeffs[k], zCxk)
+				zCxk = suite.Scalar().Mul(zCxk, xi)
+			}
+
+			scalars = append(scalars, z, suite.Scalar().Neg(suite.Scalar().Mul(w, s.P.R)),
+				suite.Scalar().Neg(suite.Scalar().Mul(w, s.P.C)), w)
+			points = append(points, s.P.VG, X[s.S.I], s.S.V, s.P.VH)
+		}
+		scalars = append(scalars, suite.Scalar().Neg(sumR))
+		points = append(points, H)
+		for k, c := range coeffs {
+			scalars = append(scalars, suite.Scalar().Neg(c))
+			points = append(points, commits[k])
+		}
+		return multiMul(suite, scalars, points).Equal(suite.Point().Null())
+	}
+	single := func(p int) bool {
+		s := shares[p]
+		return pvss.VerifyEncShare(suite, H, X[s.S.I], pubPoly.Eval(s.S.I).V, s) == nil
+	}
+
+	bad = append(bad, findInvalid(positions, batch, single)...)
+	sort.Ints(bad)
+	return bad
+}
+
+//VerifyDecSharesBatch checks the DLEQ proofs of decrypted shares all created by the owner of the public key X.
+//encShares[i] is the encrypted share that was decrypted into decShares[i]. The proofs are combined with random
+//weights in the same way as VerifyEncSharesBatch, with G the base point:
+//	sum z_i*VG_i == (sum z_i*R_i)*G + (sum z_i*C_i)*X
+//	sum z_i*VH_i == sum (z_i*R_i)*D_i + sum (z_i*C_i)*E_i
+//Suites without cheap additions (see pippenger) verify the proofs one by one. It returns the positions of the
+//invalid decrypted shares, nil if all are valid.
+func VerifyDecSharesBatch(suite abstract.Suite, X abstract.Point, encShares []*pvss.PubVerShare, decShares []*pvss.PubVerShare) []int {
+	var bad []int
+	var positions []int
+	for p := range decShares {
+		if p >= len(encShares) || encShares[p] == nil || encShares[p].S.V == nil || !wellFormed(decShares[p]) {
+			bad = append(bad, p)
+		} else {
+			positions = append(positions, p)
+		}
+	}
+
+	single := func(p int) bool {
+		return pvss.VerifyDecShare(suite, nil, X, encShares[p], decShares[p]) == nil
+	}
+	if !pippenger[suite.String()] { //without the bucket method, the batch costs as much as the proofs themselves
+		for _, p := range positions {
+			if !single(p) {
+				bad = append(bad, p)
+			}
+		}
+		sort.Ints(bad)
+		return bad
+	}
+
+	batch := func(positions []int) bool {
+		var scalars []abstract.Scalar
+		var points []abstract.Point
+		sumR := suite.Scalar().Zero()
+		sumC := suite.Scalar().Zero()
+		for _, p := range positions {
+			d := decShares[p]
+			z := suite.Scalar().Pick(random.Stream)
+			w := suite.Scalar().Pick(random.Stream)
+			sumR.Add(sumR, suite.Scalar().Mul(z, d.P.R))
+			sumC.Add(sumC, suite.Scalar().Mul(z, d.P.C))
+
+			scalars = append(scalars, z, suite.Scalar().Neg(suite.Scalar().Mul(w, d.P.R)),
+				suite.Scalar().Neg(suite.Scalar().Mul(w, d.P.C)), w)
+			points = append(points, d.P.VG, d.S.V, encShares[p].S.V, d.P.VH)
+		}
+		scalars = append(scalars, suite.Scalar().Neg(sumR), suite.Scalar().Neg(sumC))
+		points = append(points, suite.Point().Base(), X)
+		return multiMul(suite, scalars, points).Equal(suite.Point().Null())
+	}
+
+	bad = append(bad, findInvalid(positions, batch, single)...)
+	sort.Ints(bad)
+	return bad
+}
+
+//wellFormed checks that none of the values used by the verification of a share is missing
+func wellFormed(s *pvss.PubVerShare) bool {
+	return s != nil && s.S.V != nil && s.P.C != nil && s.P.R != nil && s.P.VG != nil && s.P.VH != nil
+}
+
+//findInvalid returns the positions rejected by single, by checking the batch first and splitting it in halves when it fails
+func findInvalid(positions []int, batch func([]int) bool, single func(int) bool) []int {
+	if len(positions) == 0 || batch(positions) {
+		return nil
+	}
+	if len(positions) <= batchMin {
+		var bad []int
+		for _, p := range positions {
+			if !single(p) {
+				bad = append(bad, p)
+			}
+		}
+		return bad
+	}
+	mid := len(positions) / 2
+	return append(findInvalid(positions[:mid], batch, single), findInvalid(positions[mid:], batch, single)...)
+}
+
+//multiMul computes sum scalars[i]*points[i] with the bucket method of Pippenger: the scalars are cut in windows
+//of c bits and, for each window, the points are added into the bucket of their digit. This costs about
+//(bits/c)*(n + 2^(c+1)) additions instead of n full multiplications. Suites that are not in pippenger
+//simply add the products.
+func multiMul(suite abstract.Suite, scalars []abstract.Scalar, points []abstract.Point) abstract.Point {
+	if !pippenger[suite.String()] {
+		result := suite.Point().Null()
+		for i := range points {
+			result.Add(result, suite.Point().Mul(points[i], scalars[i]))
+		}
+		return result
+	}
+
+	c := 1
+	for (1 << uint(c+2)) <= len(points) {
+		c++
+	}
+	digits := make([][]byte, len(scalars))
+	for i, s := range scalars {
+		digits[i] = bigEndian(suite, s)
+	}
+	bits := 8 * suite.ScalarLen()
+
+	result := suite.Point().Null()
+	for top := bits; top > 0; top -= c {
+		width := c
+		if top < c {
+			width = top
+		}
+		for j := 0; j < width; j++ {
+			result = suite.Point().Add(result, result)
+		}
+		buckets := make([]abstract.Point, 1<<uint(width))
+		for i, d := range digits {
+			digit := window(d, bits, top-width, width)
+			if digit == 0 {
+				continue
+			}
+			if buckets[digit] == nil {
+				buckets[digit] = suite.Point().Set(points[i])
+			} else {
+				buckets[digit] = suite.Point().Add(buckets[digit], points[i])
+			}
+		}
+		//sum digit*bucket[digit] as a sum of running sums
+		running := suite.Point().Null()
+		sum := suite.Point().Null()
+		for digit := len(buckets) - 1; digit > 0; digit-- {
+			if buckets[digit] != nil {
+				running = suite.Point().Add(running, buckets[digit])
+			}
+			sum = suite.Point().Add(sum, running)
+		}
+		result = suite.Point().Add(result, sum)
+	}
+	return result
+}
+
+//window returns the width bits of the big-endian number b (of bits bits) starting at bit low, counted from the least significant one
+func window(b []byte, bits int, low int, width int) int {
+	digit := 0
+	for j := low + width - 1; j >= low; j-- {
+		pos := bits - 1 - j //position from the most significant bit
+		bit := int(b[pos/8]>>uint(7-pos%8)) & 1
+		digit = digit<<1 | bit
+	}
+	return digit
+}
+
+//bigEndian returns the big-endian encoding of s on ScalarLen bytes, whatever the byte order used by the suite
+func bigEndian(suite abstract.Suite, s abstract.Scalar) []byte {
+	b, _ := s.MarshalBinary()
+	one, _ := suite.Scalar().One().MarshalBinary()
+	if len(one) > 1 && one[0] == 1 { //little-endian suite
+		for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+			b[i], b[j] = b[j], b[i]
+		}
+	}
+	out := make([]byte, suite.ScalarLen())
+	copy(out[len(out)-len(b):], b)
+	return out
+}
@@ -0,0 +1,163 @@
+package randsharepvss
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/proof"
+	"gopkg.in/dedis/crypto.v0/random"
+	"gopkg.in/dedis/crypto.v0/share"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+)
+
+//dealing is one dealer's PVSS output along with the keys it was encrypted for
+type dealing struct {
+	suite     abstract.Suite
+	H         abstract.Point
+	x         []abstract.Scalar
+	X         []abstract.Point
+	pubPoly   *share.PubPoly
+	encShares []*pvss.PubVerShare
+}
+
+func newDealing(t testing.TB, suiteName string, n int) *dealing {
+	suite, err := SuiteByName(suiteName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &dealing{suite: suite}
+	d.H, _ = suite.Point().Pick(nil, suite.Cipher([]byte("batch test")))
+	for i := 0; i < n; i++ {
+		d.x = append(d.x, suite.NewKey(nil))
+		d.X = append(d.X, suite.Point().Mul(nil, d.x[i]))
+	}
+	d.encShares, d.pubPoly, err = pvss.EncShares(suite, d.H, d.X, nil, n/3+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+//decryptions returns n encrypted shares and their decryptions with the private key x, as a node would send them
+//for the shares of n dealers
+func decryptions(t testing.TB, suite abstract.Suite, x abstract.Scalar, n int) ([]*pvss.PubVerShare, []*pvss.PubVerShare) {
+	encShares := make([]*pvss.PubVerShare, n)
+	decShares := make([]*pvss.PubVerShare, n)
+	for i := 0; i < n; i++ {
+		D, _ := suite.Point().Pick(nil, random.Stream)
+		P, _, E, err := proof.NewDLEQProof(suite, suite.Point().Base(), D, x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encShares[i] = &pvss.PubVerShare{S: share.PubShare{I: 0, V: E}}
+		decShares[i] = &pvss.PubVerShare{S: share.PubShare{I: 0, V: D}, P: *P}
+	}
+	return encShares, decShares
+}
+
+func TestMultiMul(t *testing.T) {
+	for _, name := range SuiteNames() {
+		suite, _ := SuiteByName(name)
+		for _, n := range []int{1, 3, 40} {
+			var scalars []abstract.Scalar
+			var points []abstract.Point
+			expected := suite.Point().Null()
+			for i := 0; i < n; i++ {
+				s := suite.NewKey(nil)
+				p := suite.Point().Mul(nil, suite.NewKey(nil))
+				scalars = append(scalars, s)
+				points = append(points, p)
+				expected.Add(expected, suite.Point().Mul(p, s))
+			}
+			if !multiMul(suite, scalars, points).Equal(expected) {
+				t.Fatalf("%s: wrong multiMul of %d points", name, n)
+			}
+		}
+	}
+}
+
+func TestVerifyEncSharesBatch(t *testing.T) {
+	for _, name := range SuiteNames() {
+		d := newDealing(t, name, 10)
+		if bad := VerifyEncSharesBatch(d.suite, d.H, d.X, d.pubPoly, d.encShares); bad != nil {
+			t.Fatalf("%s: valid shares rejected %v", name, bad)
+		}
+
+		//we swap the values of two shares, break the proof of another one and drop the last one
+		d.encShares[2].S.V, d.encShares[7].S.V = d.encShares[7].S.V, d.encShares[2].S.V
+		d.encShares[4].P.R = d.suite.Scalar().Add(d.encShares[4].P.R, d.suite.Scalar().One())
+		d.encShares[9] = nil
+		bad := VerifyEncSharesBatch(d.suite, d.H, d.X, d.pubPoly, d.encShares)
+		if !reflect.DeepEqual(bad, []int{2, 4, 7, 9}) {
+			t.Fatalf("%s: wrong invalid shares %v", name, bad)
+		}
+	}
+}
+
+func TestVerifyDecSharesBatch(t *testing.T) {
+	for _, name := range SuiteNames() {
+		suite, _ := SuiteByName(name)
+		x := suite.NewKey(nil)
+		X := suite.Point().Mul(nil, x)
+		encShares, decShares := decryptions(t, suite, x, 10)
+		if bad := VerifyDecSharesBatch(suite, X, encShares, decShares); bad != nil {
+			t.Fatalf("%s: valid shares rejected %v", name, bad)
+		}
+
+		decShares[3].S.V = suite.Point().Add(decShares[3].S.V, suite.Point().Base())
+		decShares[8].P.C = suite.Scalar().Zero()
+		bad := VerifyDecSharesBatch(suite, X, encShares, decShares)
+		if !reflect.DeepEqual(bad, []int{3, 8}) {
+			t.Fatalf("%s: wrong invalid shares %v", name, bad)
+		}
+	}
+}
+
+func benchmarkEncShares(b *testing.B, n int, batch bool) {
+	d := newDealing(b, Ed25519, n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if batch {
+			if bad := VerifyEncSharesBatch(d.suite, d.H, d.X, d.pubPoly, d.encShares); bad != nil {
+				b.Fatal("valid shares rejected")
+			}
+		} else {
+			for j, s := range d.encShares {
+				if err := pvss.VerifyEncShare(d.suite, d.H, d.X[j], d.pubPoly.Eval(j).V, s); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+}
+
+func benchmarkDecShares(b *testing.B, n int, batch bool) {
+	suite, _ := SuiteByName(Ed25519)
+	x := suite.NewKey(nil)
+	X := suite.Point().Mul(nil, x)
+	encShares, decShares := decryptions(b, suite, x, n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if batch {
+			if bad := VerifyDecSharesBatch(suite, X, encShares, decShares); bad != nil {
+				b.Fatal("valid shares rejected")
+			}
+		} else {
+			for j := range decShares {
+				if err := pvss.VerifyDecShare(suite, nil, X, encShares[j], decShares[j]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkEncSharesPerShare16(b *testing.B)  { benchmarkEncShares(b, 16, false) }
+func BenchmarkEncSharesBatch16(b *testing.B)     { benchmarkEncShares(b, 16, true) }
+func BenchmarkEncSharesPerShare128(b *testing.B) { benchmarkEncShares(b, 128, false) }
+func BenchmarkEncSharesBatch128(b *testing.B)    { benchmarkEncShares(b, 128, true) }
+func BenchmarkDecSharesPerShare16(b *testing.B)  { benchmarkDecShares(b, 16, false) }
+func BenchmarkDecSharesBatch16(b *testing.B)     { benchmarkDecShares(b, 16, true) }
+func BenchmarkDecSharesPerShare128(b *testing.B) { benchmarkDecShares(b, 128, false) }
+func BenchmarkDecSharesBatch128(b *testing.B)    { benchmarkDecShares(b, 128, true) }
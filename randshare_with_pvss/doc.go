@@ -4,6 +4,69 @@ The protocol has three messages:
 	- the vote V1 which is used to brodcast votes
 	- the reply R1 which is used to brodcast decrypted shares
 
+The announce carries what the root gave Setup (the number of faulty nodes, the purpose, the time, the suite
+and the scheme), the other nodes set up from the first one they receive.
+
+The PVSS runs over the suite named in Setup (Ed25519, P256 or BN256, see suite.go). As onet
+encodes points with network.Suite, the roster keys have to be in that same suite.
+
+The dealers use the scheme named in Setup. With Feldman they publish the coefficients of their polynomial
+as pvss.EncShares does; with Scrape (scrape.go) they publish the n commitments H*p(i), whose validity is
+checked at once with the Reed-Solomon dual code, so each share only needs its own DLEQ proof. Checked
+one by one, Feldman shares cost O(n*t) multiplications per dealer and SCRAPE ones O(n); with the batches
+below both are close to linear.
+
+A node can deal ahead of its sessions with Precompute (precompute.go): a pool keeps dealings ready for the
+roster keys, and the session takes one and binds it. Only the encrypted shares and half of their proofs can be
+computed before the session, as the commitments and the rest of the proofs depend on H, which is hashed from
+the SessionID so that a dealing can't be replayed; binding costs about half of pvss.EncShares. Each dealing
+leaves its pool once and can only be bound once.
+
+Incoming shares are verified in batches (batch.go), split between a pool of workers shared by the
+whole conode (verifier.go, see SetVerifyWorkers).
+Transcripts are checked by a Verifier (verify.go) that verifies the dealers in parallel, caches the
+Lagrange coefficients and recovers the collective string with one multi-scalar multiplication.
+
+Every message is checked by validate.go before it is used or forwarded: the indexes must be those of
+nodes of the session, the slices must have one entry per node (or threshold commitments) and the points
+must be set and decode in the suite. A malformed message is refused with an error.
+
+The secret of a dealer is recovered from its decrypted shares as soon as there are threshold of them, whatever
+the order the messages arrive in, and only the dealers with more than faulty votes make up the collective string.
+Verify checks with the dual code that the decrypted shares of each good dealer lie on one polynomial, otherwise a
+transcript could pick the shares, and so the secret, of a dealing the nodes never agreed on.
+
+fuzz_test.go holds two fuzz targets: FuzzHandlers feeds a node sequences of valid and corrupted messages (the
+collective string must never change and must verify) and FuzzVerify gives Verify altered transcripts and
+random strings (it must only accept the string a reference recovery finds). Run them with go test -fuzz.
+
+Messages are broadcast by default. With SetTree (tree.go) they travel along the onet tree instead: the
+announces and replies are forwarded from neighbour to neighbour and the votes are summed on the way up.
+simulation/test_data/pvss/broadcast_local.csv and tree_local.csv compare both on localhost: the votes get
+cheaper, but the encrypted shares still cross every link, so the total only drops by a few percent while
+the inner nodes of the tree send more than the leaves. A node only counts the first sums of each child and
+refuses those larger than the child's subtree, and replies to the first totals only, but the tree trusts the
+inner nodes with the votes of their subtree: a byzantine inner node can still add or drop up to that many votes
+for any dealer, and so change n'. They are trusted to forward the announces and replies unchanged too: a node
+checks that the sender of a broadcast message or of the votes is the node the message claims, but a relayed
+message only has to come from a neighbour. Use the broadcast when the inner nodes may be byzantine.
+
+A node that gets the votes of the others before it is done with the announces adds its own to them and only
+sends its own, then replies as soon as every vote is in. A dealer with too few valid encrypted shares is counted
+as bad (-1 in the tracker), so the nodes don't wait for it. A crashed node would still stop the run, unless the
+root sets a timeout with SetTimeout (timeout.go): once it is over a node tracks the dealers it has no announce
+from as absent (0), votes against them and goes on without the votes that didn't arrive, so the run ends with
+the crashed dealers out of n'. adversary.go lets the simulations crash nodes, make
+dealers send invalid shares and delay nodes, see package adversary, and netem.go sends the messages across the
+emulated network of the simulation, see package netem. Admit, if set, is asked by Setup whether the session may
+run, given its SessionID and time, package session uses it to run many sessions on the same roster and refuse a
+SessionID used before. A node that can't set up from an announce closes its instance, so the later messages of
+the session are dropped.
+
+Each run records its phases (deal, vote, reveal), the encrypted and decrypted shares it checked, the votes it
+received, the dealers it excluded and the messages it sent in the metrics package, see metrics/doc.go.
+Its steps are traced as events stamped with the session, node, phase, peer and outcome, see trace/doc.go.
+
 A simple protocol uses three files:
 - struct.go defines the messages sent around
 - randshare_with_pvss.go defines the actions for each message
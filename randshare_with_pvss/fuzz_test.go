@@ -0,0 +1,319 @@
+package randsharepvss
+
+import (
+	"bytes"
+	"testing"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/share"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+	"gopkg.in/dedis/onet.v1"
+)
+
+//copyTranscript copies the maps of a transcript so that it can be changed without touching the original
+func copyTranscript(tr *Transcript) *Transcript {
+	c := *tr
+	c.SessionID = append([]byte{}, tr.SessionID...)
+	c.X = append([]abstract.Point{}, tr.X...)
+	c.EncShares = make(map[int]map[int]*pvss.PubVerShare)
+	c.DecShares = make(map[int]map[int]*pvss.PubVerShare)
+	c.Votes = make(map[int]*Vote)
+	for i, row := range tr.EncShares {
+		c.EncShares[i] = make(map[int]*pvss.PubVerShare)
+		for j, s := range row {
+			c.EncShares[i][j] = s
+		}
+	}
+	for i, row := range tr.DecShares {
+		c.DecShares[i] = make(map[int]*pvss.PubVerShare)
+		for j, s := range row {
+			c.DecShares[i][j] = s
+		}
+	}
+	for i, v := range tr.Votes {
+		if v != nil {
+			vote := *v
+			c.Votes[i] = &vote
+		}
+	}
+	return &c
+}
+
+//mutate changes the transcript following ops, three bytes per change
+func mutate(tr *Transcript, ops []byte) {
+	n := len(tr.X)
+	for k := 0; k+2 < len(ops); k += 3 {
+		a, b := int(ops[k+1])%n, int(ops[k+2])%n
+		switch ops[k] % 14 {
+		case 0:
+			delete(tr.DecShares[a], b)
+		case 1: //share b of dealer a and a+1 are swapped, each one is still valid
+			c := (a + 1) % n
+			tr.EncShares[a][b], tr.EncShares[c][b] = tr.EncShares[c][b], tr.EncShares[a][b]
+			tr.DecShares[a][b], tr.DecShares[c][b] = tr.DecShares[c][b], tr.DecShares[a][b]
+		case 2:
+			tr.Votes[a] = &Vote{Voted: true, Vote: b}
+		case 3:
+			tr.DecShares[a][b] = tr.DecShares[a][(b+1)%n]
+		case 4:
+			tr.Faulty = b
+		case 5:
+			if len(tr.SessionID) > 0 {
+				tr.SessionID[b%len(tr.SessionID)] ^= 1
+			}
+		case 6:
+			tr.Nodes = b
+		case 7:
+			delete(tr.Votes, a)
+		case 8:
+			tr.DecShares[a][b] = nil
+		case 9:
+			tr.X[b] = nil
+		case 10:
+			tr.EncShares[a][b] = nil
+		case 11:
+			tr.H = nil
+		case 12: //the decrypted share takes the value of the encrypted one, its proof fails
+			if s := tr.DecShares[a][b]; s != nil && tr.EncShares[a][b] != nil {
+				c := *s
+				c.S.V = tr.EncShares[a][b].S.V
+				tr.DecShares[a][b] = &c
+			}
+		case 13:
+			delete(tr.DecShares, a)
+		}
+	}
+}
+
+//reference computes the collective string of a transcript the slow way : every decrypted share of a good
+//dealer must be valid or is dropped, and all the valid ones must lie on the same polynomial.
+//It returns false if the transcript has no collective string.
+func reference(tr *Transcript) ([]byte, bool) {
+	suite, err := SuiteByName(tr.Suite)
+	if err != nil || tr.Nodes != len(tr.X) || tr.Faulty < 0 {
+		return nil, false
+	}
+	for _, x := range tr.X {
+		if x == nil {
+			return nil, false
+		}
+	}
+	if !bytes.Equal(tr.SessionID, SessionID(suite, tr.Scheme, tr.Nodes, tr.Faulty, tr.X, tr.Purpose, tr.Time)) {
+		return nil, false
+	}
+	t := tr.Faulty + 1
+	coString := suite.Point().Null()
+	for dealer, vote := range tr.Votes {
+		if vote == nil || vote.Vote <= tr.Faulty {
+			continue
+		}
+		var shares []*share.PubShare
+		for j := 0; j < tr.Nodes; j++ {
+			enc, dec := tr.EncShares[dealer][j], tr.DecShares[dealer][j]
+			if enc != nil && wellFormed(dec) && dec.S.I == j && pvss.VerifyDecShare(suite, nil, tr.X[j], enc, dec) == nil {
+				shares = append(shares, &dec.S)
+			}
+		}
+		if len(shares) < t {
+			return nil, false
+		}
+		secret, err := share.RecoverCommit(suite, shares[:t], t, tr.Nodes)
+		if err != nil {
+			return nil, false
+		}
+		//each other share with the first t-1 must give the same secret, i.e. it is on the same polynomial
+		for _, s := range shares[t:] {
+			other, err := share.RecoverCommit(suite, append(append([]*share.PubShare{}, shares[:t-1]...), s), t, tr.Nodes)
+			if err != nil || !other.Equal(secret) {
+				return nil, false
+			}
+		}
+		coString.Add(coString, secret)
+	}
+	random, err := coString.MarshalBinary()
+	if err != nil {
+		return nil, false
+	}
+	return random, true
+}
+
+//FuzzVerify gives Verify transcripts changed by the fuzz input, with the collective string of the original or
+//the one of the fuzz input. Verify must not panic and must only accept the collective string of the transcript.
+func FuzzVerify(f *testing.F) {
+	random, base := newTranscript(f, Ed25519, 4)
+	f.Add([]byte{}, []byte{})
+	f.Add([]byte{0, 1, 2}, []byte{})
+	f.Add([]byte{1, 0, 3}, []byte{})
+	f.Add([]byte{2, 1, 0, 12, 2, 2}, []byte{})
+	f.Add([]byte{9, 0, 0}, random)
+
+	f.Fuzz(func(t *testing.T, ops []byte, candidate []byte) {
+		if len(candidate) == 0 {
+			candidate = random
+		}
+		tr := copyTranscript(base)
+		mutate(tr, ops)
+		NewVerifier().VerifyFast(candidate, tr)
+		if Verify(candidate, tr) != nil {
+			return
+		}
+		expected, ok := reference(tr)
+		if !ok || !bytes.Equal(expected, candidate) {
+			t.Fatalf("Verify accepted %x for a transcript whose collective string is %x", candidate, expected)
+		}
+	})
+}
+
+//fuzzSession is a session of nodes whose private keys we know, our node being the first
+type fuzzSession struct {
+	nodes, faulty int
+	purpose       string
+	time          int64
+	X             []abstract.Point
+	sessionID     []byte
+	H             abstract.Point
+	announces     []*A1                 //the dealing of every node
+	decShares     [][]*pvss.PubVerShare //decShares[dealer][j] is the decrypted share of node j
+}
+
+//setup sets rs up for the session : Setup takes the keys of the roster of a single node, they are replaced by ours
+func (fs *fuzzSession) setup(t testing.TB, rs *RandShare) {
+	if err := rs.Setup(fs.nodes, fs.faulty, fs.purpose, fs.time, Ed25519, Feldman); err != nil {
+		t.Fatal(err)
+	}
+	rs.X = fs.X
+	rs.sessionID = fs.sessionID
+	rs.H = fs.H
+}
+
+func newFuzzSession(t testing.TB, local *onet.LocalTest, server *onet.Server, nodes int) *fuzzSession {
+	suite, _ := SuiteByName(Ed25519)
+	fs := &fuzzSession{nodes: nodes, faulty: (nodes - 1) / 3, purpose: "fuzz", time: 1}
+	x := make([]abstract.Scalar, nodes)
+	fs.X = make([]abstract.Point, nodes)
+	x[0] = local.GetPrivate(server)
+	fs.X[0] = server.ServerIdentity.Public
+	for i := 1; i < nodes; i++ {
+		x[i] = suite.NewKey(nil)
+		fs.X[i] = suite.Point().Mul(nil, x[i])
+	}
+	fs.sessionID = SessionID(suite, Feldman, nodes, fs.faulty, fs.X, fs.purpose, fs.time)
+	fs.H, _ = suite.Point().Pick(nil, suite.Cipher(fs.sessionID))
+
+	for d := 0; d < nodes; d++ {
+		encShares, pubPoly, err := pvss.EncShares(suite, fs.H, fs.X, nil, fs.faulty+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, commits := pubPoly.Info()
+		fs.announces = append(fs.announces, &A1{SessionID: fs.sessionID, Scheme: Feldman, Src: d, B: b, Commits: commits, Shares: encShares})
+		var decShares []*pvss.PubVerShare
+		for j := 0; j < nodes; j++ {
+			decShare, err := pvss.DecShare(suite, fs.H, fs.X[j], pubPoly.Eval(j).V, x[j], encShares[j])
+			if err != nil {
+				t.Fatal(err)
+			}
+			decShares = append(decShares, decShare)
+		}
+		fs.decShares = append(fs.decShares, decShares)
+	}
+	return fs
+}
+
+//handle gives rs the message described by kind, a and v, built from the dealings of the session
+func (fs *fuzzSession) handle(rs *RandShare, kind byte, a byte, v byte) {
+	src := int(a) % (fs.nodes + 1) //nodes is a wrong index
+	dealer := src % fs.nodes
+	from := &onet.TreeNode{RosterIndex: src}
+	switch kind % 3 {
+	case 0:
+		announce := *fs.announces[dealer]
+		announce.Src = src
+		announce.Shares = copyShares(announce.Shares)
+		if v&1 != 0 { //a share gets the value of another one, its proof fails
+			i := int(v>>1) % fs.nodes
+			announce.Shares[i].S.V = announce.Shares[(i+1)%fs.nodes].S.V
+		}
+		if v&8 != 0 {
+			announce.SessionID = []byte("another session")
+		}
+		rs.HandleA1(StructA1{TreeNode: from, A1: announce})
+	case 1:
+		votes := make(map[int]*Vote)
+		for i := 0; i < fs.nodes; i++ {
+			votes[i] = &Vote{Vote: int(v>>uint(i)) & 1}
+			if v&16 != 0 {
+				votes[i].Vote *= 2
+			}
+		}
+		rs.HandleV1(StructV1{TreeNode: from, V1: V1{SessionID: fs.sessionID, Src: src, Votes: votes}})
+	case 2:
+		reply := R1{SessionID: fs.sessionID, Src: src}
+		for d := 0; d < fs.nodes; d++ {
+			if v&(1<<uint(d)) != 0 {
+				decShare := *fs.decShares[d][dealer]
+				reply.Shares = append(reply.Shares, &Share{Row: d, PubVerShare: &decShare})
+			}
+		}
+		if v&16 != 0 && len(reply.Shares) > 0 { //the first share gets the value of another one, its proof fails
+			reply.Shares[0].PubVerShare.S.V = fs.decShares[reply.Shares[0].Row][(dealer+1)%fs.nodes].S.V
+		}
+		rs.HandleR1(StructR1{TreeNode: from, R1: reply})
+	}
+}
+
+//FuzzHandlers drives the handlers of a node with the messages described by the fuzz input, three bytes each.
+//They must not panic, and once there is a collective string it must not change and must verify.
+func FuzzHandlers(f *testing.F) {
+	local := onet.NewLocalTest()
+	servers, _, tree := local.GenTree(1, true)
+	defer local.CloseAll()
+	fs := newFuzzSession(f, local, servers[0], 4)
+
+	//an honest run : every announce, every vote and every reply
+	var honest []byte
+	for a := byte(0); a < 4; a++ {
+		honest = append(honest, 0, a, 0)
+	}
+	for a := byte(0); a < 4; a++ {
+		honest = append(honest, 1, a, 15)
+	}
+	for a := byte(1); a < 4; a++ {
+		honest = append(honest, 2, a, 15)
+	}
+	f.Add(honest)
+	f.Add(append([]byte{2, 1, 15, 2, 2, 15, 2, 3, 15}, honest...))
+	f.Add([]byte{0, 4, 1, 1, 4, 16, 2, 4, 31})
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		protocol, err := local.CreateProtocol(Name, tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs := protocol.(*RandShare)
+		defer rs.TreeNodeInstance.Done()
+		fs.setup(t, rs)
+
+		var coString abstract.Point
+		for k := 0; k+2 < len(ops); k += 3 {
+			fs.handle(rs, ops[k], ops[k+1], ops[k+2])
+			if !rs.coStringReady {
+				continue
+			}
+			if coString != nil {
+				if !coString.Equal(rs.coString) {
+					t.Fatal("The collective string changed")
+				}
+				continue
+			}
+			coString = rs.coString.Clone()
+			random, transcript, err := rs.Random()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := Verify(random, transcript); err != nil {
+				t.Fatal("The collective string doesn't verify:", err)
+			}
+		}
+	})
+}
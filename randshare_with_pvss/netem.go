@@ -0,0 +1,41 @@
+package randsharepvss
+
+import (
+	"github.com/dedis/student_17_randomness/netem"
+	"gopkg.in/dedis/onet.v1"
+)
+
+//sendTo sends msg to node, across the emulated network if the simulation sets one (see package netem)
+func (rs *RandShare) sendTo(node *onet.TreeNode, msg interface{}) error {
+	if !netem.Enabled() {
+		return rs.SendTo(node, msg)
+	}
+	netem.Send(rs.TreeNode(), node, msg, func() error { return rs.SendTo(node, msg) })
+	return nil
+}
+
+//broadcast sends msg to every other node, across the emulated network if the simulation sets one
+func (rs *RandShare) broadcast(msg interface{}) error {
+	if !netem.Enabled() {
+		return rs.Broadcast(msg)
+	}
+	for _, node := range rs.List() {
+		if node.ID.Equal(rs.TreeNode().ID) {
+			continue
+		}
+		if err := rs.sendTo(node, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//multicast sends msg to nodes, across the emulated network if the simulation sets one
+func (rs *RandShare) multicast(msg interface{}, nodes ...*onet.TreeNode) error {
+	for _, node := range nodes {
+		if err := rs.sendTo(node, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package randsharepvss
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/hash"
+	"gopkg.in/dedis/crypto.v0/proof"
+	"gopkg.in/dedis/crypto.v0/random"
+	"gopkg.in/dedis/crypto.v0/share"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+)
+
+//Dealing is the part of a dealing that doesn't depend on the session: the polynomial p, the shares encrypted
+//with the roster keys X_i*p(i) and the nonces v_i of their proofs with X_i*v_i. The commitments and the other
+//half of the proofs need the second base point H, which is hashed from the SessionID so that a dealing can't be
+//replayed in another session: Bind computes them once the session is known, which is about half of the
+//multiplications of pvss.EncShares.
+type Dealing struct {
+	mutex     sync.Mutex
+	suite     abstract.Suite
+	threshold int
+	poly      *share.PriPoly    //p, nil once bound
+	shares    []abstract.Scalar //p(i)
+	encrypted []abstract.Point  //X_i*p(i)
+	nonces    []abstract.Scalar //v_i
+	vX        []abstract.Point  //X_i*v_i
+}
+
+//NewDealing deals a random secret to the keys X with threshold t, to be bound to a session later
+func NewDealing(suite abstract.Suite, X []abstract.Point, t int) *Dealing {
+	n := len(X)
+	d := &Dealing{
+		suite:     suite,
+		threshold: t,
+		poly:      share.NewPriPoly(suite, t, nil, random.Stream),
+		shares:    make([]abstract.Scalar, n),
+		encrypted: make([]abstract.Point, n),
+		nonces:    make([]abstract.Scalar, n),
+		vX:        make([]abstract.Point, n),
+	}
+	for i, s := range d.poly.Shares(n) {
+		d.shares[i] = s.V
+		d.encrypted[i] = suite.Point().Mul(X[i], s.V)
+		d.nonces[i] = suite.Scalar().Pick(random.Stream)
+		d.vX[i] = suite.Point().Mul(X[i], d.nonces[i])
+	}
+	return d
+}
+
+//Bind completes the dealing for the session of H with scheme, as pvss.EncShares (Feldman) or EncSharesScrape
+//would: it returns the encrypted shares with their proofs and the pubPoly (Feldman) or the commitments H*p(i)
+//(Scrape). H must be the one of a session of scheme, as SessionID hashes the scheme. A dealing is bound once, its
+//secrets are then forgotten and a second Bind fails.
+func (d *Dealing) Bind(H abstract.Point, scheme string) ([]*pvss.PubVerShare, *share.PubPoly, []abstract.Point, error) {
+	if err := checkScheme(scheme); err != nil {
+		return nil, nil, nil, err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.poly == nil {
+		return nil, nil, nil, errors.New("The dealing was bound to a session already")
+	}
+	suite := d.suite
+	n := len(d.shares)
+	values := make([]abstract.Point, n) //H*p(i)
+	vH := make([]abstract.Point, n)     //H*v_i
+	for i := 0; i < n; i++ {
+		values[i] = suite.Point().Mul(H, d.shares[i])
+		vH[i] = suite.Point().Mul(H, d.nonces[i])
+	}
+
+	//pvss.EncShares gives every proof the challenge of the whole batch, EncSharesScrape one per share
+	challenges := make([]abstract.Scalar, n)
+	if scheme == Feldman {
+		c, err := challenge(suite, values, d.encrypted, vH, d.vX)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for i := range challenges {
+			challenges[i] = c
+		}
+	} else {
+		for i := range challenges {
+			c, err := challenge(suite, values[i], d.encrypted[i], vH[i], d.vX[i])
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			challenges[i] = c
+		}
+	}
+
+	encShares := make([]*pvss.PubVerShare, n)
+	for i := 0; i < n; i++ {
+		r := suite.Scalar()
+		r.Mul(d.shares[i], challenges[i]).Sub(d.nonces[i], r)
+		P := proof.DLEQProof{C: challenges[i], R: r, VG: vH[i], VH: d.vX[i]}
+		encShares[i] = &pvss.PubVerShare{S: share.PubShare{I: i, V: d.encrypted[i]}, P: P}
+	}
+	var pubPoly *share.PubPoly
+	if scheme == Feldman {
+		pubPoly, values = d.poly.Commit(H), nil
+	}
+	d.poly, d.shares, d.nonces = nil, nil, nil
+	return encShares, pubPoly, values, nil
+}
+
+//challenge hashes the points of DLEQ proofs with base points H and X_i into their challenge, as proof does
+func challenge(suite abstract.Suite, xH interface{}, xX interface{}, vH interface{}, vX interface{}) (abstract.Scalar, error) {
+	cb, err := hash.Structures(suite.Hash(), xH, xX, vH, vX)
+	if err != nil {
+		return nil, err
+	}
+	return suite.Scalar().Pick(suite.Cipher(cb)), nil
+}
+
+//Pool keeps dealings ready for the keys X with threshold t: a goroutine deals a new one whenever one is taken,
+//so that the sessions only bind them. Each dealing leaves the pool once.
+type Pool struct {
+	suite     abstract.Suite
+	X         []abstract.Point
+	threshold int
+	ready     chan *Dealing
+	stop      chan struct{}
+	once      sync.Once
+}
+
+//NewPool starts dealing size dealings for the keys X with threshold t in the background
+func NewPool(suite abstract.Suite, X []abstract.Point, t int, size int) *Pool {
+	p := &Pool{suite: suite, X: X, threshold: t, ready: make(chan *Dealing, size), stop: make(chan struct{})}
+	go p.fill()
+	return p
+}
+
+//fill deals until the pool is full, and again whenever a dealing is taken
+func (p *Pool) fill() {
+	for {
+		d := NewDealing(p.suite, p.X, p.threshold)
+		select {
+		case p.ready <- d:
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+//Take returns a ready dealing or, if the sessions took them all, one dealt now
+func (p *Pool) Take() *Dealing {
+	select {
+	case d := <-p.ready:
+		return d
+	default:
+		return NewDealing(p.suite, p.X, p.threshold)
+	}
+}
+
+//Close stops dealing, the dealings left are dropped
+func (p *Pool) Close() {
+	p.once.Do(func() { close(p.stop) })
+}
+
+//pools holds the pools of every conode of the process, by node and keys (see poolKey)
+var pools = struct {
+	sync.Mutex
+	m map[string]*Pool
+}{m: make(map[string]*Pool)}
+
+//poolKey identifies the sessions a pool deals for: the dealings are those of node, their shares encrypted with
+//the keys X in that order, which is the order of the roster of the tree
+func poolKey(node string, suite abstract.Suite, X []abstract.Point, t int) string {
+	h := sha256.New()
+	h.Write([]byte(node))
+	h.Write([]byte(suite.String()))
+	h.Write([]byte{byte(t >> 24), byte(t >> 16), byte(t >> 8), byte(t)})
+	for _, x := range X {
+		b, _ := x.MarshalBinary()
+		h.Write(b)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+//Precompute makes node deal size dealings ahead of its sessions with the keys X and threshold t, which then
+//only bind one (see Dealing). A size of 0 stops it. X is the roster of the tree of the sessions, root first.
+func Precompute(node string, suite abstract.Suite, X []abstract.Point, t int, size int) {
+	key := poolKey(node, suite, X, t)
+	pools.Lock()
+	defer pools.Unlock()
+	if p, ok := pools.m[key]; ok {
+		p.Close()
+		delete(pools.m, key)
+	}
+	if size > 0 {
+		pools.m[key] = NewPool(suite, X, t, size)
+	}
+}
+
+//precomputed returns a dealing of the pool of our node for the session, nil if there is none
+func (rs *RandShare) precomputed() *Dealing {
+	key := poolKey(rs.ServerIdentity().Address.String(), rs.suite, rs.X, rs.threshold)
+	pools.Lock()
+	p, ok := pools.m[key]
+	pools.Unlock()
+	if !ok {
+		return nil
+	}
+	return p.Take()
+}
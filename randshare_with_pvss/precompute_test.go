@@ -0,0 +1,127 @@
+package randsharepvss
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dedis/student_17_randomness/trace"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+	"gopkg.in/dedis/onet.v1"
+)
+
+func TestBind(t *testing.T) {
+	n := 10
+	d := newDealing(t, Ed25519, n)
+	for _, scheme := range []string{Feldman, Scrape} {
+		dealing := NewDealing(d.suite, d.X, n/3+1)
+		encShares, pubPoly, values, err := dealing.Bind(d.H, scheme)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if scheme == Feldman {
+			if bad := VerifyEncSharesParallel(d.suite, d.H, d.X, pubPoly, encShares); bad != nil {
+				t.Fatal("Valid Feldman shares rejected", bad)
+			}
+			for i, s := range encShares {
+				if pvss.VerifyEncShare(d.suite, d.H, d.X[i], pubPoly.Eval(i).V, s) != nil {
+					t.Fatal("pvss rejects share", i)
+				}
+			}
+		} else if bad := VerifyEncSharesScrape(d.suite, d.H, d.X, values, encShares, n/3+1); bad != nil {
+			t.Fatal("Valid SCRAPE shares rejected", bad)
+		}
+		if _, _, _, err := dealing.Bind(d.H, scheme); err == nil {
+			t.Fatal("A dealing was bound twice")
+		}
+	}
+}
+
+func TestPool(t *testing.T) {
+	d := newDealing(t, Ed25519, 4)
+	p := NewPool(d.suite, d.X, 2, 2)
+	defer p.Close()
+	time.Sleep(100 * time.Millisecond)
+	taken := make(map[*Dealing]bool)
+	for i := 0; i < 5; i++ { //the last ones are dealt on the spot
+		dealing := p.Take()
+		if taken[dealing] {
+			t.Fatal("A dealing left the pool twice")
+		}
+		taken[dealing] = true
+	}
+}
+
+//TestPrecomputed runs sessions whose nodes bind dealings of their pools
+func TestPrecomputed(t *testing.T) {
+	nodes, faulty, sessions := 7, 2, 3
+	r := &trace.Recorder{}
+	trace.SetSink(r)
+	defer trace.SetSink(nil)
+
+	for _, scheme := range []string{Feldman, Scrape} {
+		local := onet.NewLocalTest()
+		servers, _, tree := local.GenTree(nodes, true)
+		suite, _ := SuiteByName(Ed25519)
+		for _, s := range servers {
+			Precompute(s.ServerIdentity.Address.String(), suite, tree.Roster.Publics(), faulty+1, 2)
+		}
+
+		for i := 0; i < sessions; i++ {
+			protocol, err := local.CreateProtocol(Name, tree)
+			if err != nil {
+				t.Fatal("couldn't initialize", err)
+			}
+			rs := protocol.(*RandShare)
+			if err = rs.Setup(nodes, faulty, "RandShare precomputed test", int64(i), Ed25519, scheme); err != nil {
+				t.Fatal("couldn't initialize", err)
+			}
+			if err = rs.Start(); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-rs.Done:
+				random, transcript, err := rs.Random()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err = Verify(random, transcript); err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(time.Second * time.Duration(nodes) * 2):
+				t.Fatal("RandShare timeout with", scheme)
+			}
+		}
+		for _, s := range servers {
+			Precompute(s.ServerIdentity.Address.String(), suite, tree.Roster.Publics(), faulty+1, 0)
+		}
+		local.CloseAll()
+	}
+	bound := r.Events(func(e *trace.Event) bool { return e.Outcome == "precomputed dealing bound" })
+	if len(bound) != 2*sessions*nodes {
+		t.Fatal("Wrong number of precomputed dealings", len(bound))
+	}
+}
+
+func BenchmarkBind128(b *testing.B) {
+	d := newDealing(b, Ed25519, 128)
+	dealings := make([]*Dealing, b.N)
+	for i := range dealings {
+		dealings[i] = NewDealing(d.suite, d.X, 128/3+1)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, _, err := dealings[i].Bind(d.H, Feldman); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncShares128(b *testing.B) {
+	d := newDealing(b, Ed25519, 128)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := pvss.EncShares(d.suite, d.H, d.X, nil, 128/3+1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
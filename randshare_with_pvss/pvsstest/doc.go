@@ -0,0 +1,4 @@
+/*Package pvsstest runs the PVSS protocol of randsharepvss for the tests of the packages built on its collective
+string, such as sampling and lottery.
+*/
+package pvsstest
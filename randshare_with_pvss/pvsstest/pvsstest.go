@@ -0,0 +1,38 @@
+package pvsstest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dedis/student_17_randomness/randshare_with_pvss"
+	"gopkg.in/dedis/onet.v1"
+)
+
+//Run runs the PVSS protocol on nodes local nodes for purpose and returns its collective string and transcript
+func Run(t testing.TB, nodes int, purpose string) ([]byte, *randsharepvss.Transcript) {
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol(randsharepvss.Name, tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*randsharepvss.RandShare)
+	if err = rs.Setup(nodes, nodes/3, purpose, time.Now().Unix(), randsharepvss.Ed25519, randsharepvss.Feldman); err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	if err = rs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rs.Done:
+	case <-time.After(time.Second * time.Duration(nodes) * 2):
+		t.Fatal("RandShare timeout")
+	}
+	random, transcript, err := rs.Random()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return random, transcript
+}
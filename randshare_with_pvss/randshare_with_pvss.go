@@ -6,16 +6,22 @@ import (
 	"fmt"
 
 	"encoding/binary"
+	"encoding/hex"
+	"time"
 
+	"github.com/dedis/student_17_randomness/adversary"
+	"github.com/dedis/student_17_randomness/metrics"
+	"github.com/dedis/student_17_randomness/trace"
 	"gopkg.in/dedis/crypto.v0/abstract"
 	"gopkg.in/dedis/crypto.v0/share"
 	"gopkg.in/dedis/crypto.v0/share/pvss"
 	"gopkg.in/dedis/onet.v1"
 	"gopkg.in/dedis/onet.v1/crypto"
+	"gopkg.in/dedis/onet.v1/log"
 )
 
 func init() {
-	onet.GlobalProtocolRegister("RandShare", NewRandShare)
+	onet.GlobalProtocolRegister(Name, NewRandShare)
 }
 
 //NewRandShare initialises the tree and network
@@ -27,8 +33,22 @@ func NewRandShare(n *onet.TreeNodeInstance) (onet.ProtocolInstance, error) {
 	return t, err
 }
 
-//Setup initializes RandShare struct, computes the private keys and the second base point based on the sessionID
-func (rs *RandShare) Setup(nodes int, faulty int, purpose string, time int64) error {
+//Setup initializes RandShare struct, computes the private keys and the second base point based on the sessionID.
+//suite is the name of the suite (see SuiteByName) used for the PVSS, the roster keys must belong to it.
+//scheme is the PVSS scheme, Feldman or Scrape (see scrape.go)
+func (rs *RandShare) Setup(nodes int, faulty int, purpose string, time int64, suite string, scheme string) error {
+	s, err := SuiteByName(suite)
+	if err != nil {
+		return err
+	}
+	if err := checkScheme(scheme); err != nil {
+		return err
+	}
+	if rs.Suite().String() != s.String() {
+		return fmt.Errorf("Roster keys are %s points, not %s", rs.Suite().String(), s.String())
+	}
+	rs.suite = s
+	rs.scheme = scheme
 	rs.startingTime = time
 	rs.nodes = nodes
 	rs.nPrime = 0
@@ -37,144 +57,264 @@ func (rs *RandShare) Setup(nodes int, faulty int, purpose string, time int64) er
 	rs.purpose = purpose
 	rs.X = rs.Roster().Publics()
 
-	rs.sessionID = SessionID(rs.Suite(), rs.nodes, rs.faulty, rs.X, rs.purpose, time)
-	rs.H, _ = rs.Suite().Point().Pick(nil, rs.Suite().Cipher(rs.sessionID))
+	rs.sessionID = SessionID(rs.suite, rs.scheme, rs.nodes, rs.faulty, rs.X, rs.purpose, time)
+	rs.H, _ = rs.suite.Point().Pick(nil, rs.suite.Cipher(rs.sessionID))
 
 	rs.pubPolys = make([]*share.PubPoly, rs.nodes)
+	rs.values = make([][]abstract.Point, rs.nodes)
 	rs.encShares = make(map[int]map[int]*pvss.PubVerShare)
 	rs.tracker = make(map[int]int)
 	rs.votes = make(map[int]*Vote)
 	rs.decShares = make(map[int]map[int]*pvss.PubVerShare)
+	rs.replied = make(map[int]bool)
 	for i := 0; i < rs.nodes; i++ {
 		rs.encShares[i] = make(map[int]*pvss.PubVerShare)
 		rs.decShares[i] = make(map[int]*pvss.PubVerShare)
 		rs.votes[i] = &Vote{Voted: false, Vote: 0}
 	}
 	rs.secrets = make(map[int]abstract.Point)
+	rs.subtree = make(map[int]int)
+	rs.reported = make(map[onet.TreeNodeID]bool)
+	rs.ownVoted = false
+	rs.final = false
+	rs.tracked = false
+	rs.decided = false
 	rs.coStringReady = false
-	rs.Done = make(chan bool, 0)
+	rs.Done = make(chan bool, 1) //buffered so that nodes nobody waits for don't block their handlers
+	if rs.Admit != nil {
+		if err := rs.Admit(rs.sessionID, time); err != nil {
+			rs.nodes = 0 //the instance stays idle, the messages of the session are refused
+			rs.sessionID = nil
+			return err
+		}
+	}
+	rs.run = metrics.NewRun(rs.ServerIdentity().Address.String(), "pvss")
+	rs.tracer = trace.New("pvss", hex.EncodeToString(rs.sessionID), rs.Index())
+	rs.tracer.Eventf("setup", trace.NoPeer, "started", "%d nodes, %d faulty, %s, %s", nodes, faulty, suite, scheme)
 
 	return nil
 }
 
 //Start initiates the protocol from node 0
 func (rs *RandShare) Start() error {
-
-	encShares, pubPoly, err := pvss.EncShares(rs.Suite(), rs.H, rs.X, nil, rs.threshold)
+	adversary.Hold(rs.Index(), rs.nodes)
+	rs.mutex.Lock()
+	announce, err := rs.deal()
+	rs.mutex.Unlock()
 	if err != nil {
 		return err
 	}
-	rs.mutex.Lock()
-	rs.pubPolys[rs.Index()] = pubPoly
-	b, commits := pubPoly.Info()
+	return rs.send(announce)
+}
 
+//deal encrypts the shares of our secret with the scheme of the session, stores them and returns the announce to send.
+//The deal phase ends once the announces of every dealer are in or the timeout is over (see closeDeal).
+func (rs *RandShare) deal() (*A1, error) {
 	announce := &A1{
 		SessionID: rs.sessionID,
 		Src:       rs.Index(),
-		Shares:    encShares,
-		B:         b,
-		Commits:   commits,
 		Purpose:   rs.purpose,
 		Time:      rs.startingTime,
+		Faulty:    rs.faulty,
+		Suite:     rs.suite.String(),
+		Scheme:    rs.scheme,
+		Tree:      rs.tree,
+		Timeout:   int64(rs.timeout / time.Millisecond),
+	}
+	if d := rs.precomputed(); d != nil {
+		encShares, pubPoly, values, err := d.Bind(rs.H, rs.scheme)
+		if err != nil {
+			return nil, err
+		}
+		announce.Shares = encShares
+		if rs.scheme == Scrape {
+			rs.values[rs.Index()] = values
+			announce.Values = values
+		} else {
+			rs.pubPolys[rs.Index()] = pubPoly
+			announce.B, announce.Commits = pubPoly.Info()
+		}
+		rs.tracer.Event("deal", trace.NoPeer, "precomputed dealing bound")
+	} else {
+		switch rs.scheme {
+		case Scrape:
+			encShares, values, err := EncSharesScrape(rs.suite, rs.H, rs.X, nil, rs.threshold)
+			if err != nil {
+				return nil, err
+			}
+			rs.values[rs.Index()] = values
+			announce.Shares = encShares
+			announce.Values = values
+		default:
+			encShares, pubPoly, err := pvss.EncShares(rs.suite, rs.H, rs.X, nil, rs.threshold)
+			if err != nil {
+				return nil, err
+			}
+			rs.pubPolys[rs.Index()] = pubPoly
+			announce.Shares = encShares
+			announce.B, announce.Commits = pubPoly.Info()
+		}
 	}
 
 	for j := 0; j < rs.nodes; j++ {
 		//we know they are correct, we can store them, put the tracker to 1
-		rs.encShares[rs.Index()][j] = encShares[j]
+		rs.encShares[rs.Index()][j] = announce.Shares[j]
 		rs.tracker[rs.Index()] = 1
 	}
-	rs.mutex.Unlock()
-	return rs.Broadcast(announce)
+	if adversary.Of(rs.Index(), rs.nodes) == adversary.Invalid {
+		announce.Shares = invalidShares(rs.suite, announce.Shares)
+	}
+	rs.tracer.Event("deal", trace.NoPeer, "dealt")
+	rs.deadline(rs.closeDeal)
+	return announce, nil
 }
 
 //HandleA1 handles the announces of the session
 func (rs *RandShare) HandleA1(announce StructA1) error {
+	if rs.crashed() {
+		return nil
+	}
 
 	msg := &announce.A1
 
 	if rs.nodes == 0 { //we need to setup rs and brodcast our encrypted shares
-		rs.mutex.Lock()
 		nodes := len(rs.List())
-		if err := rs.Setup(nodes, nodes/3, msg.Purpose, msg.Time); err != nil {
+		if msg.Faulty < 0 || 3*msg.Faulty >= nodes {
+			return errors.New("Wrong number of faulty nodes")
+		}
+		if msg.Timeout < 0 {
+			return errors.New("Negative timeout")
+		}
+		adversary.Hold(rs.Index(), nodes)
+		rs.mutex.Lock()
+		if err := rs.Setup(nodes, msg.Faulty, msg.Purpose, msg.Time, msg.Suite, msg.Scheme); err != nil {
+			rs.mutex.Unlock()
+			rs.TreeNodeInstance.Done() //the later messages of the session are dropped instead of setting us up again
 			return err
 		}
-		encShares, pubPoly, err := pvss.EncShares(rs.Suite(), rs.H, rs.X, nil, rs.threshold)
+		rs.tree = msg.Tree
+		rs.timeout = time.Duration(msg.Timeout) * time.Millisecond
+		announce, err := rs.deal()
+		rs.mutex.Unlock()
 		if err != nil {
 			return err
 		}
-		rs.pubPolys[rs.Index()] = pubPoly
-		b, commits := pubPoly.Info()
-		announce := &A1{
-			SessionID: rs.sessionID,
-			Src:       rs.Index(),
-			Shares:    encShares,
-			B:         b,
-			Commits:   commits,
-			Purpose:   rs.purpose,
-			Time:      rs.startingTime,
-		}
-		for j := 0; j < rs.nodes; j++ {
-			//we know they are correct, we can store them
-			rs.encShares[rs.Index()][j] = encShares[j]
-			rs.tracker[rs.Index()] = 1
-		}
-		rs.mutex.Unlock()
-		if err := rs.Broadcast(announce); err != nil {
+		if err := rs.send(announce); err != nil {
 			return err
 		}
 
 	}
+	if err := rs.validA1(msg, announce.TreeNode); err != nil {
+		rs.tracer.Eventf("deal", msg.Src, "invalid announce", "%v", err)
+		return err
+	}
+	if err := rs.relay(announce.TreeNode, msg); err != nil {
+		return err
+	}
 
 	if _, ok := rs.tracker[msg.Src]; ok || !bytes.Equal(msg.SessionID, rs.sessionID) {
 		return nil //If the sessionID is not correct or we already got shares from that sender we don't deal with the announce
 	}
 
 	rs.mutex.Lock()
-	pubPolySrc := share.NewPubPoly(rs.Suite(), msg.B, msg.Commits)
-	rs.pubPolys[msg.Src] = pubPolySrc
-	//rs.mutex.Unlock()
-	for _, share := range msg.Shares {
-		shareIndex := share.S.I
-		value := pubPolySrc.Eval(shareIndex).V
-		_, ok := rs.tracker[msg.Src]
-		if err := pvss.VerifyEncShare(rs.Suite(), rs.H, rs.X[shareIndex], value, share); err == nil && !ok {
+	if _, ok := rs.tracker[msg.Src]; ok { //the timeout may have given up on that dealer in the meantime
+		rs.mutex.Unlock()
+		return nil
+	}
+	//the shares are verified in batches by the workers of the conode, bad is sorted so we can skip the invalid ones in order
+	var bad []int
+	switch rs.scheme {
+	case Scrape:
+		rs.values[msg.Src] = msg.Values
+		bad = VerifyEncSharesScrape(rs.suite, rs.H, rs.X, msg.Values, msg.Shares, rs.threshold)
+	default:
+		pubPolySrc := share.NewPubPoly(rs.suite, msg.B, msg.Commits)
+		rs.pubPolys[msg.Src] = pubPolySrc
+		bad = VerifyEncSharesParallel(rs.suite, rs.H, rs.X, pubPolySrc, msg.Shares)
+	}
+	rs.run.Shares("encrypted", len(msg.Shares)-len(bad), len(bad))
+	rs.tracer.Eventf("deal", msg.Src, "announce verified", "%d bad shares", len(bad))
+	for position, share := range msg.Shares {
+		if len(bad) > 0 && bad[0] == position {
+			bad = bad[1:]
+			continue
+		}
+		if _, ok := rs.tracker[msg.Src]; !ok {
 			//share is correct, we store it in the encShares map
-			//	rs.mutex.Lock()
-			rs.encShares[msg.Src][shareIndex] = share
-			//	rs.mutex.Unlock()
+			rs.encShares[msg.Src][share.S.I] = share
 		}
 
 		if len(rs.encShares[msg.Src]) > 2*rs.faulty {
-			//	rs.mutex.Lock()
 			rs.tracker[msg.Src] = 1
-			//	rs.mutex.Unlock()
 		}
+	}
+	if _, ok := rs.tracker[msg.Src]; !ok { //the dealer is bad, we still have to count it or we would wait for it forever
+		rs.tracker[msg.Src] = -1
+	}
 
-		if len(rs.tracker) == rs.nodes { //we had announce from everyone
-			for index, vote := range rs.tracker {
-				if vote == 1 { //we have at least 2*rs.faulty correct encrypted shares for that index
-					//rs.mutex.Lock()
-					rs.votes[index].Vote = 1
-					//rs.mutex.Unlock()
-				}
-			}
-			rs.votes[rs.Index()].Voted = true
-			//we say that we are done by sending our votes
-			step := &V1{SessionID: rs.sessionID, Src: rs.Index(), Votes: rs.votes}
-			if err := rs.Broadcast(step); err != nil {
-				return err
-			}
+	rs.mutex.Unlock()
+	return rs.sendVotes()
+}
+
+//sendVotes sends our votes once the tracker has an entry for every dealer, which is only done once
+func (rs *RandShare) sendVotes() error {
+	rs.mutex.Lock()
+	if rs.tracked || len(rs.tracker) != rs.nodes { //we wait for the announce of everyone or the timeout
+		rs.mutex.Unlock()
+		return nil
+	}
+	rs.tracked = true
+	own := make(map[int]*Vote)
+	for index, vote := range rs.tracker {
+		own[index] = &Vote{}
+		if vote == 1 { //we have at least 2*rs.faulty correct encrypted shares for that index
+			own[index].Vote = 1
 		}
 	}
+	if rs.tree { //our votes are added to those of our subtree
+		rs.mutex.Unlock()
+		rs.run.Phase("deal")
+		rs.tracer.Event("vote", trace.NoPeer, "votes ready")
+		rs.deadline(rs.closeVotes)
+		return rs.voteTree(own, nil)
+	}
+	//the votes of the others may be in already, ours are added to them and only ours are sent
+	for index, vote := range own {
+		rs.votes[index].Vote += vote.Vote
+	}
+	rs.votes[rs.Index()].Voted = true
 	rs.mutex.Unlock()
-	return nil
+	//we say that we are done by sending our votes, a delayed node doesn't hold the lock meanwhile so it still
+	//handles the messages of the others
+	step := &V1{SessionID: rs.sessionID, Src: rs.Index(), Votes: own}
+	adversary.Hold(rs.Index(), rs.nodes)
+	if err := rs.broadcast(step); err != nil {
+		return err
+	}
+	rs.run.Sent(step, len(rs.List())-1)
+	rs.run.Phase("deal")
+	rs.tracer.Event("vote", trace.NoPeer, "votes sent")
+	rs.deadline(rs.closeVotes)
+	return rs.replyIfVoted()
 }
 
 //HandleV1 sends the decrypted shares when has received everyone's vote (means they are done storing their encrypted shares)
 func (rs *RandShare) HandleV1(step StructV1) error {
+	if rs.crashed() {
+		return nil
+	}
 
 	msg := &step.V1
 
+	if err := rs.validV1(msg, step.TreeNode); err != nil {
+		rs.tracer.Eventf("vote", msg.Src, "invalid votes", "%v", err)
+		return err
+	}
+	rs.tracer.Event("vote", msg.Src, "votes received")
+	rs.run.Votes("V1", len(msg.Votes))
+	if rs.tree {
+		return rs.handleTreeVotes(step.TreeNode, msg)
+	}
 	if !bytes.Equal(msg.SessionID, rs.sessionID) || rs.votes[msg.Src].Voted {
 		return nil //If the sessionID is not correct or we already have a vote from that node we don't deal with the message
 	}
@@ -185,33 +325,47 @@ func (rs *RandShare) HandleV1(step StructV1) error {
 	}
 	rs.votes[msg.Src].Voted = true
 	rs.mutex.Unlock()
+	return rs.replyIfVoted()
+}
+
+//replyIfVoted replies once every node voted, ourselves included
+func (rs *RandShare) replyIfVoted() error {
 	for _, vote := range rs.votes {
 		if !vote.Voted { //one node hasn't voted yet
 			return nil
 		}
 	}
+	return rs.reply()
+}
 
+//reply is called once every vote is in. It computes n' and sends our decrypted shares, once.
+func (rs *RandShare) reply() error {
 	//if we reach this step, everyone voted so we can
-	//Compute the number n' of good nodes (thos with a vote greater than faulty), clean our tracker to use it in the next step and brodcast our shares
+	//Compute the number n' of good nodes (thos with a vote greater than faulty) and brodcast our shares
+	rs.mutex.Lock()
+	if rs.decided {
+		rs.mutex.Unlock()
+		return nil
+	}
+	rs.decided = true
 	for _, vote := range rs.votes {
 		if vote.Vote > rs.faulty { //good node
-			rs.mutex.Lock()
 			rs.nPrime++
-			rs.mutex.Unlock()
 		}
 	}
+	rs.mutex.Unlock()
 
+	rs.run.Excluded(rs.nodes - rs.nPrime)
 	if rs.nPrime < rs.faulty {
+		rs.tracer.Eventf("vote", trace.NoPeer, "aborted", "%d good dealers", rs.nPrime)
 		return errors.New("Too many faulty nodes")
 	}
-	rs.mutex.Lock()
-	rs.tracker = make(map[int]int)
-	rs.mutex.Unlock()
+	rs.tracer.Eventf("vote", trace.NoPeer, "decided", "%d good dealers", rs.nPrime)
 
 	var decShares []*Share //The list we will send
 	for j := 0; j < rs.nodes; j++ {
 		if encShare, ok := rs.encShares[j][rs.Index()]; ok { //we have an encrypted share, we can thus verify the decryted share
-			decShare, err := pvss.DecShare(rs.Suite(), rs.H, rs.X[rs.Index()], rs.pubPolys[j].Eval(rs.Index()).V, rs.Private(), encShare)
+			decShare, err := pvss.DecShare(rs.suite, rs.H, rs.X[rs.Index()], rs.commitment(j, rs.Index()), rs.Private(), encShare)
 			if err != nil {
 				return err
 			}
@@ -224,67 +378,138 @@ func (rs *RandShare) HandleV1(step StructV1) error {
 		}
 	}
 	reply := &R1{SessionID: rs.sessionID, Src: rs.Index(), Shares: decShares}
-	return rs.Broadcast(reply)
+	adversary.Hold(rs.Index(), rs.nodes)
+	if err := rs.send(reply); err != nil {
+		return err
+	}
+	rs.run.Phase("vote")
+	rs.tracer.Eventf("reveal", trace.NoPeer, "reply sent", "%d shares", len(decShares))
+	//the decrypted shares of the others may all be in already
+	for j := 0; j < rs.nodes; j++ {
+		if err := rs.recover(j); err != nil {
+			return err
+		}
+	}
+	return rs.combine()
+}
+
+//commitment returns H*p(i), the commitment of dealer to the share of node i
+func (rs *RandShare) commitment(dealer int, i int) abstract.Point {
+	if rs.scheme == Scrape {
+		return rs.values[dealer][i]
+	}
+	return rs.pubPolys[dealer].Eval(i).V
 }
 
 //HandleR1 stores the decrypted shares and when we have enough, recovers the secret of good nodes
 func (rs *RandShare) HandleR1(reply StructR1) error {
+	if rs.crashed() {
+		return nil
+	}
 
 	msg := &reply.R1
 
-	if _, ok := rs.tracker[msg.Src]; ok || !bytes.Equal(msg.SessionID, rs.sessionID) {
+	if err := rs.validR1(msg, reply.TreeNode); err != nil {
+		rs.tracer.Eventf("reveal", msg.Src, "invalid reply", "%v", err)
+		return err
+	}
+	if err := rs.relay(reply.TreeNode, msg); err != nil {
+		return err
+	}
+	if rs.replied[msg.Src] || !bytes.Equal(msg.SessionID, rs.sessionID) {
 		return nil //If the sessionID is not correct or we had decrypted shares from that node already, we don't deal with the reply
 	}
 	rs.mutex.Lock()
-	rs.tracker[msg.Src] = 1 //we received something
+	rs.replied[msg.Src] = true //we received something
 	rs.mutex.Unlock()
+	//we gather the shares we have to deal with to verify them in parallel
+	var rows []int
+	var encShareList []*pvss.PubVerShare
+	var decShareList []*pvss.PubVerShare
 	for _, shareWr := range msg.Shares {
-		if _, ok := rs.secrets[shareWr.Row]; !ok || (rs.votes[shareWr.Row].Vote <= rs.faulty) { //if the share.src-th secret is already recovered or has too many negative votes we don't deal with this share
+		if _, ok := rs.secrets[shareWr.Row]; !ok { //if the share.src-th secret is already recovered we don't deal with this share, the votes may not all be in yet (see combine)
 			if encShare, ok := rs.encShares[shareWr.Row][msg.Src]; ok {
-				if err := pvss.VerifyDecShare(rs.Suite(), nil, rs.X[msg.Src], encShare, shareWr.PubVerShare); err == nil {
-					rs.mutex.Lock()
-					rs.decShares[shareWr.Row][msg.Src] = shareWr.PubVerShare
-					rs.mutex.Unlock()
-				}
-
-				if len(rs.decShares[shareWr.Row]) == rs.threshold { //we can recover src-th secret
-					var encShareList []*pvss.PubVerShare
-					var decShareList []*pvss.PubVerShare
-					var keys []abstract.Point
-
-					for i := 0; i < rs.nodes; i++ {
-						if decShare, ok := rs.decShares[shareWr.Row][i]; ok {
-							encShareList = append(encShareList, rs.encShares[shareWr.Row][i]) //we are sure to have an encShare as we verified it
-							decShareList = append(decShareList, decShare)
-							keys = append(keys, rs.X[i])
-						}
-					}
-
-					secret, err := pvss.RecoverSecret(rs.Suite(), nil, keys, encShareList, decShareList, rs.threshold, rs.nPrime)
-					if err != nil {
-						return err
-					}
-
-					rs.mutex.Lock()
-					rs.secrets[shareWr.Row] = secret
-					rs.mutex.Unlock()
-				}
+				rows = append(rows, shareWr.Row)
+				encShareList = append(encShareList, encShare)
+				decShareList = append(decShareList, shareWr.PubVerShare)
 			}
+		}
+	}
+	bad := VerifyDecSharesParallel(rs.suite, rs.X[msg.Src], encShareList, decShareList)
+	rs.run.Shares("decrypted", len(rows)-len(bad), len(bad))
+	rs.tracer.Eventf("reveal", msg.Src, "reply verified", "%d shares, %d bad", len(rows), len(bad))
+
+	for position, row := range rows {
+		if len(bad) > 0 && bad[0] == position {
+			bad = bad[1:]
+			continue
+		}
+		rs.mutex.Lock()
+		rs.decShares[row][msg.Src] = decShareList[position]
+		rs.mutex.Unlock()
+		if err := rs.recover(row); err != nil {
+			return err
+		}
+	}
 
-			if (len(rs.secrets) == rs.nPrime) && !rs.coStringReady { //we can recover the secret for all good nodes
-				coString := rs.Suite().Point().Null()
-				for j := range rs.secrets {
-					abstract.Point.Add(coString, coString, rs.secrets[j])
-				}
-				rs.mutex.Lock()
-				rs.coString = coString
-				rs.mutex.Unlock()
-				fmt.Printf("Collective String recovered at node %d %+v\n", rs.Index()+1, coString)
-				rs.coStringReady = true
-				rs.Done <- true
-			}
+	return rs.combine()
+}
+
+//recover recovers the secret of row once we have threshold of its decrypted shares
+func (rs *RandShare) recover(row int) error {
+	if _, ok := rs.secrets[row]; ok || len(rs.decShares[row]) < rs.threshold {
+		return nil
+	}
+	var shares []*share.PubShare
+	for i := 0; i < rs.nodes; i++ {
+		if decShare, ok := rs.decShares[row][i]; ok {
+			shares = append(shares, &decShare.S)
 		}
 	}
+
+	//the decrypted shares were verified when stored, we don't use pvss.RecoverSecret that would verify them again
+	secret, err := share.RecoverCommit(rs.suite, shares, rs.threshold, rs.nodes)
+	if err != nil {
+		return err
+	}
+
+	rs.mutex.Lock()
+	rs.secrets[row] = secret
+	rs.mutex.Unlock()
+	rs.tracer.Event("reveal", row, "secret recovered")
+	return nil
+}
+
+//combine computes the collective string once every vote is in and the secrets of all the good nodes are
+//recovered. The secrets of the other nodes, recovered from decrypted shares sent before the votes were in,
+//are left out.
+func (rs *RandShare) combine() error {
+	if rs.nPrime == 0 || rs.coStringReady {
+		return nil //the votes aren't all in yet (see reply)
+	}
+	coString := rs.suite.Point().Null()
+	for j := 0; j < rs.nodes; j++ {
+		if rs.votes[j].Vote <= rs.faulty {
+			continue
+		}
+		secret, ok := rs.secrets[j]
+		if !ok {
+			return nil //we wait for the secret of j
+		}
+		coString.Add(coString, secret)
+	}
+	rs.mutex.Lock()
+	rs.coString = coString
+	rs.coStringReady = true
+	rs.mutex.Unlock()
+	rs.run.Phase("reveal")
+	rs.run.Done()
+	rs.tracer.Event("done", trace.NoPeer, "collective string")
+	log.Lvlf2("Collective String recovered at node %d %+v", rs.Index(), coString)
+	select {
+	case rs.Done <- true:
+	default: //nobody waits for that node
+	}
 	return nil
 }
 
@@ -303,7 +528,8 @@ func (rs *RandShare) Random() ([]byte, *Transcript, error) {
 	}
 	transcript := &Transcript{
 		SessionID: rs.sessionID,
-		Suite:     rs.Suite(),
+		Suite:     rs.suite.String(),
+		Scheme:    rs.scheme,
 		Nodes:     rs.nodes,
 		Faulty:    rs.faulty,
 		Purpose:   rs.purpose,
@@ -317,58 +543,17 @@ func (rs *RandShare) Random() ([]byte, *Transcript, error) {
 	return rb, transcript, nil
 }
 
-//Verify is a method that verifies that we created the random collective string following the transcript
-func Verify(random []byte, transcript *Transcript) error {
-
-	//verification of sessionID
-	sid := SessionID(transcript.Suite, transcript.Nodes, transcript.Faulty, transcript.X, transcript.Purpose, transcript.Time)
-	if !bytes.Equal(transcript.SessionID, sid) {
-		return errors.New("Wrong session identifier")
-	}
-
-	//verification of the final coString
-	//first we compute the secrets of honest nodes according to results of vote
-	var secrets []abstract.Point
-	for id, vote := range transcript.Votes {
-		if vote.Vote > transcript.Faulty {
-			var encShareList []*pvss.PubVerShare
-			var decShareList []*pvss.PubVerShare
-			var keys []abstract.Point
-			for j, share := range transcript.DecShares[id] {
-				encShareList = append(encShareList, transcript.EncShares[id][j])
-				decShareList = append(decShareList, share)
-				keys = append(keys, transcript.X[j])
-			}
-
-			secret, err := pvss.RecoverSecret(transcript.Suite, nil, keys, encShareList, decShareList, transcript.Faulty+1, transcript.Nodes)
-			if err != nil {
-				return err
-			}
-			secrets = append(secrets, secret)
-		}
-	}
-	//then we combine them
-	coString := transcript.Suite.Point().Null()
-	for _, secret := range secrets {
-		abstract.Point.Add(coString, coString, secret)
-	}
-	bs, err := coString.MarshalBinary()
-	if err != nil {
-		return err
-	}
-	if !bytes.Equal(bs, random) {
-		return errors.New("CoString isn't correct")
-	}
-
-	//everything was correct
-	return nil
-}
-
-//SessionID hashes the data(suite, nodes, faulty, public keys, purpose, strating time) that caracterizes a particualar randShare protocol into a session identifier
-func SessionID(suite abstract.Suite, nodes int, faulty int, X []abstract.Point, purpose string, time int64) []byte {
+//SessionID hashes the data(suite name, PVSS scheme, nodes, faulty, public keys, purpose, strating time) that caracterizes a particualar randShare protocol into a session identifier
+func SessionID(suite abstract.Suite, scheme string, nodes int, faulty int, X []abstract.Point, purpose string, time int64) []byte {
 
 	//We put all the data into a byte buffer
 	buf := new(bytes.Buffer)
+	if _, err := buf.WriteString(suite.String()); err != nil {
+		return nil
+	}
+	if _, err := buf.WriteString(scheme); err != nil {
+		return nil
+	}
 	if err := binary.Write(buf, binary.LittleEndian, uint32(nodes)); err != nil {
 		return nil
 	}
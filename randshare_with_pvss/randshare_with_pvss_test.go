@@ -1,16 +1,22 @@
 package randsharepvss
 
 import (
+	"encoding/hex"
 	"testing"
 	"time"
 
+	"github.com/dedis/student_17_randomness/adversary"
+	"github.com/dedis/student_17_randomness/metrics"
+	"github.com/dedis/student_17_randomness/netem"
+	"github.com/dedis/student_17_randomness/trace"
 	"gopkg.in/dedis/onet.v1"
 	"gopkg.in/dedis/onet.v1/log"
+	"gopkg.in/dedis/onet.v1/network"
 )
 
 func TestRandShare(t *testing.T) {
 
-	var name = "RandShare"
+	var name = Name
 	var nodes = 13
 	// 2/3 would prevent network splitting attacks
 	var faulty = nodes / 3
@@ -27,7 +33,7 @@ func TestRandShare(t *testing.T) {
 	}
 	rs := protocol.(*RandShare)
 	startingTime := time.Now().Unix()
-	err = rs.Setup(nodes, faulty, purpose, startingTime)
+	err = rs.Setup(nodes, faulty, purpose, startingTime, Ed25519, Feldman)
 	if err != nil {
 		t.Fatal("couldn't initialize", err)
 	}
@@ -49,3 +55,465 @@ func TestRandShare(t *testing.T) {
 		t.Fatal("RandShare timeout")
 	}
 }
+
+//TestRandShareMetrics checks that a run is recorded in the metrics. They are shared by the whole process, so
+//only what this run adds is checked.
+func TestRandShareMetrics(t *testing.T) {
+	nodes, faulty := 4, 1
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol(Name, tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	if err := rs.Setup(nodes, faulty, "metrics", time.Now().Unix(), Ed25519, Feldman); err != nil {
+		t.Fatal(err)
+	}
+	node := rs.ServerIdentity().Address.String()
+	runs := metrics.Default.Get("randshare_runs_total", node, "pvss")
+	verified := metrics.Default.Get("randshare_shares_verified_total", node, "pvss", "encrypted")
+	sent := metrics.Default.Get("randshare_messages_sent_total", node, "pvss", "A1")
+	if err := rs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rs.Done:
+	case <-time.After(time.Second * time.Duration(nodes) * 2):
+		t.Fatal("RandShare timeout")
+	}
+	if metrics.Default.Get("randshare_runs_total", node, "pvss") != runs+1 {
+		t.Fatal("The run wasn't counted")
+	}
+	if metrics.Default.Get("randshare_shares_verified_total", node, "pvss", "encrypted") != verified+float64(nodes*(nodes-1)) {
+		t.Fatal("The verified shares weren't counted")
+	}
+	if metrics.Default.Get("randshare_messages_sent_total", node, "pvss", "A1") != sent+float64(nodes-1) {
+		t.Fatal("The sent messages weren't counted")
+	}
+}
+
+//TestRandShareSuites runs the whole protocol with the roster keys and the network encoding in each suite
+func TestRandShareSuites(t *testing.T) {
+	defaultSuite := network.Suite
+	defer func() { network.Suite = defaultSuite }()
+
+	for _, name := range SuiteNames() {
+		suite, err := SuiteByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		network.Suite = suite
+		log.Lvlf1("RandShare with suite %s", name)
+		runSuite(t, name, Feldman)
+	}
+}
+
+//TestRandShareTree runs the whole protocol with the messages sent along the binary tree of the local test
+func TestRandShareTree(t *testing.T) {
+	var nodes = 13
+
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol(Name, tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	if err = rs.Setup(nodes, nodes/3, "RandShare tree test", time.Now().Unix(), Ed25519, Feldman); err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs.SetTree(true)
+	if err = rs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rs.Done:
+		random, transcript, err := rs.Random()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = Verify(random, transcript); err != nil {
+			t.Fatal(err)
+		}
+		for index, vote := range transcript.Votes {
+			if vote.Vote != nodes {
+				t.Fatalf("Dealer %d got %d votes instead of %d", index, vote.Vote, nodes)
+			}
+		}
+	case <-time.After(time.Second * time.Duration(nodes) * 2):
+		t.Fatal("RandShare timeout")
+	}
+}
+
+//TestTreeVotes gives the root repeated and inflated sums from its children and the totals twice
+func TestTreeVotes(t *testing.T) {
+	var nodes = 4
+
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true) //the root has a child with a child and a leaf
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol(Name, tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	defer rs.TreeNodeInstance.Done()
+	if err = rs.Setup(nodes, 2, "RandShare tree votes test", time.Now().Unix(), Ed25519, Feldman); err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs.SetTree(true)
+	var inner, leaf *onet.TreeNode
+	for _, child := range rs.Children() {
+		if child.SubtreeCount() == 0 {
+			leaf = child
+		} else {
+			inner = child
+		}
+	}
+	if inner == nil || leaf == nil {
+		t.Fatal("Unexpected tree")
+	}
+
+	for k := 0; k < 2; k++ {
+		if err := rs.voteTree(map[int]*Vote{0: {Vote: 1}}, leaf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if rs.subtree[0] != 1 || len(rs.reported) != 1 {
+		t.Fatal("The repeated sums of a child were counted twice")
+	}
+	if err := rs.voteTree(map[int]*Vote{0: {Vote: inner.SubtreeCount() + 2}}, inner); err != nil {
+		t.Fatal(err)
+	}
+	if rs.subtree[0] != 1 || len(rs.reported) != 2 {
+		t.Fatal("Sums larger than the subtree of the child were counted")
+	}
+
+	//n' is 1 for 2 faulty nodes, the reply aborts before sending anything
+	rs.votes[0].Vote = nodes
+	for k := 0; k < 2; k++ {
+		rs.reply()
+	}
+	if rs.nPrime != 1 {
+		t.Fatal("The reply counted n' again", rs.nPrime)
+	}
+}
+
+//TestRandShareFaulty runs the whole protocol with fewer faulty nodes than nodes/3, the other nodes learn it from
+//the announce of the root
+func TestRandShareFaulty(t *testing.T) {
+	var nodes = 10
+	var faulty = 1
+
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol(Name, tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	if err = rs.Setup(nodes, faulty, "RandShare faulty test", time.Now().Unix(), Ed25519, Feldman); err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	if err = rs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rs.Done:
+		random, transcript, err := rs.Random()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if transcript.Faulty != faulty {
+			t.Fatal("Wrong number of faulty nodes in transcript", transcript.Faulty)
+		}
+		if err = Verify(random, transcript); err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second * time.Duration(nodes) * 2):
+		t.Fatal("RandShare timeout")
+	}
+}
+
+//TestAdversary runs the protocol with an invalid dealer and a delayed node, broadcasting and along the tree: the
+//dealer must be excluded and the run must still end
+func TestAdversary(t *testing.T) {
+	nodes, faulty := 7, 2
+	adversary.Set(&adversary.Scenario{Invalid: 1, Delayed: 1, Delay: 100 * time.Millisecond})
+	defer adversary.Set(nil)
+
+	for _, tree := range []bool{false, true} {
+		local := onet.NewLocalTest()
+		_, _, onetTree := local.GenTree(nodes, true)
+
+		protocol, err := local.CreateProtocol(Name, onetTree)
+		if err != nil {
+			t.Fatal("couldn't initialize", err)
+		}
+		rs := protocol.(*RandShare)
+		if err = rs.Setup(nodes, faulty, "RandShare adversary test", time.Now().Unix(), Ed25519, Feldman); err != nil {
+			t.Fatal("couldn't initialize", err)
+		}
+		rs.SetTree(tree)
+		if err = rs.Start(); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-rs.Done:
+			random, transcript, err := rs.Random()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if transcript.Votes[nodes-1].Vote > faulty || rs.nPrime != nodes-1 {
+				t.Fatal("The invalid dealer wasn't excluded", transcript.Votes[nodes-1].Vote, rs.nPrime)
+			}
+			if err = Verify(random, transcript); err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(time.Second * time.Duration(nodes) * 2):
+			t.Fatal("RandShare timeout with tree", tree)
+		}
+		local.CloseAll()
+	}
+}
+
+//TestCrashed runs the protocol with a crashed node, broadcasting and along the tree: once the timeout is over the
+//nodes go on without it, so the run must end with the crashed dealer left out of n'
+func TestCrashed(t *testing.T) {
+	nodes, faulty := 7, 2
+	adversary.Set(&adversary.Scenario{Crashed: 1})
+	defer adversary.Set(nil)
+
+	for _, tree := range []bool{false, true} {
+		local := onet.NewLocalTest()
+		_, _, onetTree := local.GenTree(nodes, true)
+
+		protocol, err := local.CreateProtocol(Name, onetTree)
+		if err != nil {
+			t.Fatal("couldn't initialize", err)
+		}
+		rs := protocol.(*RandShare)
+		if err = rs.Setup(nodes, faulty, "RandShare crashed test", time.Now().Unix(), Ed25519, Feldman); err != nil {
+			t.Fatal("couldn't initialize", err)
+		}
+		rs.SetTree(tree)
+		rs.SetTimeout(500 * time.Millisecond)
+		if err = rs.Start(); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-rs.Done:
+			random, transcript, err := rs.Random()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rs.tracker[nodes-1] != 0 || transcript.Votes[nodes-1].Vote > faulty || rs.nPrime != nodes-1 {
+				t.Fatal("The crashed dealer wasn't left out", rs.tracker[nodes-1], transcript.Votes[nodes-1].Vote, rs.nPrime)
+			}
+			if err = Verify(random, transcript); err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(time.Second * time.Duration(nodes) * 2):
+			t.Fatal("RandShare timeout with tree", tree)
+		}
+		local.CloseAll()
+	}
+}
+
+//TestNetwork runs the protocol, broadcasting and along the tree, across an emulated wide area network
+func TestNetwork(t *testing.T) {
+	nodes, faulty := 7, 2
+	netem.Set(&netem.Network{
+		Local:   netem.Link{Latency: time.Millisecond},
+		Remote:  netem.Link{Latency: 30 * time.Millisecond, Jitter: 10 * time.Millisecond, Bandwidth: 1e6, Loss: 0.01},
+		Regions: 2,
+	})
+	defer netem.Set(nil)
+
+	for _, tree := range []bool{false, true} {
+		local := onet.NewLocalTest()
+		_, _, onetTree := local.GenTree(nodes, true)
+
+		protocol, err := local.CreateProtocol(Name, onetTree)
+		if err != nil {
+			t.Fatal("couldn't initialize", err)
+		}
+		rs := protocol.(*RandShare)
+		if err = rs.Setup(nodes, faulty, "RandShare network test", time.Now().Unix(), Ed25519, Feldman); err != nil {
+			t.Fatal("couldn't initialize", err)
+		}
+		rs.SetTree(tree)
+		if err = rs.Start(); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-rs.Done:
+			random, transcript, err := rs.Random()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = Verify(random, transcript); err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(time.Second * time.Duration(nodes) * 2):
+			t.Fatal("RandShare timeout with tree", tree)
+		}
+		local.CloseAll()
+	}
+}
+
+//TestVotesFirst gives a node the votes of the others before the announces, as they come when the node is slower:
+//its own votes must be added to theirs and it must still reply once they are in
+func TestVotesFirst(t *testing.T) {
+	local := onet.NewLocalTest()
+	servers, _, tree := local.GenTree(1, true)
+	defer local.CloseAll()
+	fs := newFuzzSession(t, local, servers[0], 4)
+
+	protocol, err := local.CreateProtocol(Name, tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := protocol.(*RandShare)
+	defer rs.TreeNodeInstance.Done()
+	fs.setup(t, rs)
+
+	for a := byte(1); a < 4; a++ {
+		fs.handle(rs, 1, a, 15)
+	}
+	for a := byte(0); a < 4; a++ {
+		fs.handle(rs, 0, a, 0)
+	}
+	if rs.nPrime != 4 {
+		t.Fatal("Our votes weren't added to those of the others", rs.nPrime)
+	}
+	for a := byte(1); a < 4; a++ {
+		fs.handle(rs, 2, a, 15)
+	}
+	random, transcript, err := rs.Random()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(random, transcript); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//TestRandShareScrape runs the whole protocol with the SCRAPE dealings
+func TestRandShareScrape(t *testing.T) {
+	runSuite(t, Ed25519, Scrape)
+}
+
+func runSuite(t *testing.T, suite string, scheme string) {
+	var nodes = 7
+
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol(Name, tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	if err = rs.Setup(nodes, nodes/3, "RandShare suite test", time.Now().Unix(), suite, scheme); err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	if err = rs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rs.Done:
+		random, transcript, err := rs.Random()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if transcript.Suite != suite || transcript.Scheme != scheme {
+			t.Fatal("Wrong suite or scheme in transcript", transcript.Suite, transcript.Scheme)
+		}
+		if err = Verify(random, transcript); err != nil {
+			t.Fatal(err)
+		}
+		log.Lvlf1("RandShare verified with suite %s and scheme %s", suite, scheme)
+	case <-time.After(time.Second * time.Duration(nodes) * 4):
+		t.Fatal("RandShare timeout with suite", suite, scheme)
+	}
+}
+
+func TestSetupWrongSuite(t *testing.T) {
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(4, true)
+	defer local.CloseAll()
+
+	protocol, err := local.CreateProtocol(Name, tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	if err := rs.Setup(4, 1, "", time.Now().Unix(), P256, Feldman); err == nil {
+		t.Fatal("Setup should refuse a suite that doesn't match the roster keys")
+	}
+	if err := rs.Setup(4, 1, "", time.Now().Unix(), "unknown", Feldman); err == nil {
+		t.Fatal("Setup should refuse an unknown suite")
+	}
+	if err := rs.Setup(4, 1, "", time.Now().Unix(), Ed25519, "unknown"); err == nil {
+		t.Fatal("Setup should refuse an unknown scheme")
+	}
+}
+
+//TestTrace checks that the events of a run are stamped with the SessionID and let us follow every node to the
+//collective string
+func TestTrace(t *testing.T) {
+	recorder := &trace.Recorder{}
+	trace.SetSink(recorder)
+	defer trace.SetSink(nil)
+
+	nodes := 7
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+	protocol, err := local.CreateProtocol(Name, tree)
+	if err != nil {
+		t.Fatal("couldn't initialize", err)
+	}
+	rs := protocol.(*RandShare)
+	if err := rs.Setup(nodes, nodes/3, "trace", time.Now().Unix(), Ed25519, Feldman); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rs.Done:
+	case <-time.After(time.Second * time.Duration(nodes) * 2):
+		t.Fatal("RandShare timeout")
+	}
+	session := hex.EncodeToString(rs.sessionID)
+
+	//the other nodes may still be combining, we wait for their last event
+	done := func(e *trace.Event) bool { return e.Session == session && e.Phase == "done" }
+	for i := 0; i < 100 && len(recorder.Events(done)) < nodes; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	finished := make(map[int]bool)
+	for _, e := range recorder.Events(done) {
+		finished[e.Node] = true
+	}
+	if len(finished) != nodes {
+		t.Fatal("Every node should have traced its collective string", finished)
+	}
+	verified := recorder.Events(func(e *trace.Event) bool {
+		return e.Session == session && e.Node == 0 && e.Outcome == "announce verified"
+	})
+	if len(verified) != nodes-1 {
+		t.Fatal("The root should have traced the announce of every other node", len(verified))
+	}
+}
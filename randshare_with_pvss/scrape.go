@@ -0,0 +1,194 @@
+package randsharepvss
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/proof"
+	"gopkg.in/dedis/crypto.v0/random"
+	"gopkg.in/dedis/crypto.v0/share"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+)
+
+//Names of the PVSS schemes that can be given to Setup
+const (
+	Feldman = "Feldman" //the dealer publishes the t coefficients of its polynomial, as pvss.EncShares does
+	Scrape  = "SCRAPE"  //the dealer publishes the n evaluations of its polynomial, checked with the dual code
+)
+
+//checkScheme returns an error if scheme isn't Feldman or Scrape
+func checkScheme(scheme string) error {
+	if scheme != Feldman && scheme != Scrape {
+		return fmt.Errorf("Unknown PVSS scheme %s", scheme)
+	}
+	return nil
+}
+
+//EncSharesScrape deals secret (a random one if nil) to the n public keys X with threshold t as SCRAPE does: the
+//share of node i is encrypted into X_i*p(i) and committed to with v_i = H*p(i), with a DLEQ proof linking both.
+//It returns the encrypted shares and the commitments v_i, that replace the pubPoly of pvss.EncShares.
+func EncSharesScrape(suite abstract.Suite, H abstract.Point, X []abstract.Point, secret abstract.Scalar, t int) ([]*pvss.PubVerShare, []abstract.Point, error) {
+	n := len(X)
+	priPoly := share.NewPriPoly(suite, t, secret, random.Stream)
+	priShares := priPoly.Shares(n)
+
+	encShares := make([]*pvss.PubVerShare, n)
+	values := make([]abstract.Point, n)
+	for i := 0; i < n; i++ {
+		P, v, Y, err := proof.NewDLEQProof(suite, H, X[i], priShares[i].V)
+		if err != nil {
+			return nil, nil, err
+		}
+		values[i] = v
+		encShares[i] = &pvss.PubVerShare{S: share.PubShare{I: i, V: Y}, P: *P}
+	}
+	return encShares, values, nil
+}
+
+//DualCodeCheck tells whether the commitments v_1..v_n are evaluations H*p(i) of a polynomial p of degree < t, i.e.
+//whether (p(1),..,p(n)) is a word of the Reed-Solomon code. A random word c of the dual code is drawn,
+//c_i = f(i)/prod_{j!=i}(i-j) with f random of degree <= n-t-1, and sum c_i*v_i must be the null point.
+//A wrong dealing passes with probability 1/|group|. It costs n multiplications instead of the n*t of Feldman.
+func DualCodeCheck(suite abstract.Suite, values []abstract.Point, t int) bool {
+	indexes := make([]int, len(values))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	return dualCheck(suite, indexes, values, t)
+}
+
+//dualCheck is DualCodeCheck for the values of the shares with the given distinct indexes (x = index+1) only :
+//the code is then punctured to these positions and the weights are 1/prod_{j!=i}(x_i-x_j) over them
+func dualCheck(suite abstract.Suite, indexes []int, values []abstract.Point, t int) bool {
+	n := len(values)
+	if t < 1 || t > n || len(indexes) != n {
+		return false
+	}
+	for _, v := range values {
+		if v == nil {
+			return false
+		}
+	}
+	if t == n { //every vector is a word of the code
+		return true
+	}
+
+	//f has n-t random coefficients
+	f := make([]abstract.Scalar, n-t)
+	for k := range f {
+		f[k] = suite.Scalar().Pick(random.Stream)
+	}
+	weights := puncturedWeights(suite, indexes)
+	c := make([]abstract.Scalar, n)
+	for i := range c {
+		x := suite.Scalar().SetInt64(int64(indexes[i] + 1))
+		fx := suite.Scalar().Zero()
+		for k := len(f) - 1; k >= 0; k-- { //Horner
+			fx.Mul(fx, x)
+			fx.Add(fx, f[k])
+		}
+		c[i] = suite.Scalar().Mul(fx, weights[i])
+	}
+	return multiMul(suite, c, values).Equal(suite.Point().Null())
+}
+
+//puncturedWeights returns 1/prod_{j!=i}(x_i-x_j) over the indexes, with the factorials of dualWeights when they
+//are 0..n-1
+func puncturedWeights(suite abstract.Suite, indexes []int) []abstract.Scalar {
+	full := true
+	for i, index := range indexes {
+		full = full && index == i
+	}
+	if full {
+		return dualWeights(suite, len(indexes))
+	}
+	weights := make([]abstract.Scalar, len(indexes))
+	for k, i := range indexes {
+		w := suite.Scalar().One()
+		for _, j := range indexes {
+			if j != i {
+				w.Mul(w, suite.Scalar().SetInt64(int64(i-j)))
+			}
+		}
+		weights[k] = w.Inv(w)
+	}
+	return weights
+}
+
+//dualWeights returns 1/prod_{j!=i}(i-j) for i, j in 1..n, that is ((-1)^(n-i)*(i-1)!*(n-i)!)^-1
+func dualWeights(suite abstract.Suite, n int) []abstract.Scalar {
+	fact := make([]abstract.Scalar, n)
+	fact[0] = suite.Scalar().One()
+	for k := 1; k < n; k++ {
+		fact[k] = suite.Scalar().Mul(fact[k-1], suite.Scalar().SetInt64(int64(k)))
+	}
+	weights := make([]abstract.Scalar, n)
+	for i := 1; i <= n; i++ {
+		w := suite.Scalar().Mul(fact[i-1], fact[n-i])
+		if (n-i)%2 == 1 {
+			w.Neg(w)
+		}
+		weights[i-1] = w.Inv(w)
+	}
+	return weights
+}
+
+//VerifyEncSharesScrape checks the encrypted shares of one SCRAPE dealer: the commitments values must pass the
+//DualCodeCheck, otherwise every share is invalid, and the DLEQ proof of each share must hold against the
+//commitment of its index. The proofs are batched as in VerifyEncSharesBatch and split between the workers of
+//the conode. It returns the positions in shares of the invalid ones, nil if all are valid.
+func VerifyEncSharesScrape(suite abstract.Suite, H abstract.Point, X []abstract.Point, values []abstract.Point, shares []*pvss.PubVerShare, t int) []int {
+	if len(values) != len(X) || !DualCodeCheck(suite, values, t) {
+		bad := make([]int, len(shares))
+		for p := range bad {
+			bad[p] = p
+		}
+		return bad
+	}
+	return pool().verifyChunks(len(shares), func(start, end int) []int {
+		return verifyScrapeProofs(suite, H, X, values, shares[start:end])
+	})
+}
+
+//verifyScrapeProofs checks the DLEQ proofs of the shares against the commitments, with random weights z_i, w_i:
+//	sum z_i*VG_i == (sum z_i*R_i)*H + sum (z_i*C_i)*v_i
+//	sum w_i*VH_i == sum (w_i*R_i)*X_i + sum (w_i*C_i)*S_i
+func verifyScrapeProofs(suite abstract.Suite, H abstract.Point, X []abstract.Point, values []abstract.Point, shares []*pvss.PubVerShare) []int {
+	var bad []int
+	var positions []int
+	for p, s := range shares {
+		if !wellFormed(s) || s.S.I < 0 || s.S.I >= len(X) {
+			bad = append(bad, p)
+		} else {
+			positions = append(positions, p)
+		}
+	}
+
+	batch := func(positions []int) bool {
+		var scalars []abstract.Scalar
+		var points []abstract.Point
+		sumR := suite.Scalar().Zero()
+		for _, p := range positions {
+			s := shares[p]
+			z := suite.Scalar().Pick(random.Stream)
+			w := suite.Scalar().Pick(random.Stream)
+			sumR.Add(sumR, suite.Scalar().Mul(z, s.P.R))
+			scalars = append(scalars, z, suite.Scalar().Neg(suite.Scalar().Mul(z, s.P.C)),
+				suite.Scalar().Neg(suite.Scalar().Mul(w, s.P.R)), suite.Scalar().Neg(suite.Scalar().Mul(w, s.P.C)), w)
+			points = append(points, s.P.VG, values[s.S.I], X[s.S.I], s.S.V, s.P.VH)
+		}
+		scalars = append(scalars, suite.Scalar().Neg(sumR))
+		points = append(points, H)
+		return multiMul(suite, scalars, points).Equal(suite.Point().Null())
+	}
+	single := func(p int) bool {
+		s := shares[p]
+		return pvss.VerifyEncShare(suite, H, X[s.S.I], values[s.S.I], s) == nil
+	}
+
+	bad = append(bad, findInvalid(positions, batch, single)...)
+	sort.Ints(bad)
+	return bad
+}
+
@@ -0,0 +1,95 @@
+package randsharepvss
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+)
+
+//scrapeDealing is one SCRAPE dealing along with the keys it was encrypted for
+func scrapeDealing(t testing.TB, suiteName string, n int) (*dealing, []abstract.Point) {
+	d := newDealing(t, suiteName, n)
+	encShares, values, err := EncSharesScrape(d.suite, d.H, d.X, nil, n/3+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.encShares = encShares
+	d.pubPoly = nil
+	return d, values
+}
+
+func TestDualCodeCheck(t *testing.T) {
+	for _, name := range SuiteNames() {
+		d, values := scrapeDealing(t, name, 10)
+		if !DualCodeCheck(d.suite, values, 4) {
+			t.Fatalf("%s: valid commitments rejected", name)
+		}
+		//the degree of the polynomial is checked too
+		if DualCodeCheck(d.suite, values, 3) {
+			t.Fatalf("%s: commitments accepted for a lower degree", name)
+		}
+		values[5] = d.suite.Point().Add(values[5], d.suite.Point().Base())
+		if DualCodeCheck(d.suite, values, 4) {
+			t.Fatalf("%s: modified commitments accepted", name)
+		}
+	}
+
+	//the commitments of a Feldman dealing are a word of the same code
+	d := newDealing(t, Ed25519, 10)
+	values := make([]abstract.Point, 10)
+	for i := range values {
+		values[i] = d.pubPoly.Eval(i).V
+	}
+	if !DualCodeCheck(d.suite, values, 4) {
+		t.Fatal("Feldman commitments rejected")
+	}
+}
+
+func TestVerifyEncSharesScrape(t *testing.T) {
+	d, values := scrapeDealing(t, Ed25519, 40)
+	if bad := VerifyEncSharesScrape(d.suite, d.H, d.X, values, d.encShares, 14); bad != nil {
+		t.Fatal("Valid shares rejected", bad)
+	}
+	for _, s := range d.encShares {
+		if err := pvss.VerifyEncShare(d.suite, d.H, d.X[s.S.I], values[s.S.I], s); err != nil {
+			t.Fatal("pvss doesn't accept the SCRAPE share", err)
+		}
+	}
+
+	d.encShares[3].S.V, d.encShares[30].S.V = d.encShares[30].S.V, d.encShares[3].S.V
+	d.encShares[17] = nil
+	if bad := VerifyEncSharesScrape(d.suite, d.H, d.X, values, d.encShares, 14); !reflect.DeepEqual(bad, []int{3, 17, 30}) {
+		t.Fatal("Wrong invalid shares", bad)
+	}
+
+	//with commitments outside the code every share is invalid
+	values[0] = d.suite.Point().Base()
+	if bad := VerifyEncSharesScrape(d.suite, d.H, d.X, values, d.encShares, 14); len(bad) != 40 {
+		t.Fatal("Shares accepted with invalid commitments", bad)
+	}
+	if bad := VerifyEncSharesScrape(d.suite, d.H, d.X, values[:39], d.encShares, 14); len(bad) != 40 {
+		t.Fatal("Shares accepted with missing commitments", bad)
+	}
+}
+
+func BenchmarkEncSharesFeldman128(b *testing.B) {
+	d := newDealing(b, Ed25519, 128)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if bad := VerifyEncSharesParallel(d.suite, d.H, d.X, d.pubPoly, d.encShares); bad != nil {
+			b.Fatal("valid shares rejected")
+		}
+	}
+}
+
+func BenchmarkEncSharesScrape128(b *testing.B) {
+	d, values := scrapeDealing(b, Ed25519, 128)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if bad := VerifyEncSharesScrape(d.suite, d.H, d.X, values, d.encShares, 128/3+1); bad != nil {
+			b.Fatal("valid shares rejected")
+		}
+	}
+}
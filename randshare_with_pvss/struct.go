@@ -2,7 +2,10 @@ package randsharepvss
 
 import (
 	"sync"
+	"time"
 
+	"github.com/dedis/student_17_randomness/metrics"
+	"github.com/dedis/student_17_randomness/trace"
 	"gopkg.in/dedis/crypto.v0/abstract"
 	"gopkg.in/dedis/crypto.v0/share/pvss"
 
@@ -12,7 +15,7 @@ import (
 )
 
 //Name can be used from other packages to refer to this protocol.
-const Name = "RandShare"
+const Name = "RandSharePVSS"
 
 //init registers the handlers
 func init() {
@@ -39,9 +42,15 @@ type A1 struct {
 	SessionID []byte              //SessionID to verify the validity of the message
 	Purpose   string              //the purpose of the current ProtocolInstance
 	Time      int64               //time given by initializer to compute sessionID
+	Faulty    int                 //the number of faulty nodes given to the root (see Setup)
+	Suite     string              //the name of the suite used for the PVSS
+	Scheme    string              //the PVSS scheme, Feldman or Scrape
+	Tree      bool                //do messages travel along the tree ? (see SetTree)
+	Timeout   int64               //the milliseconds a node waits for each phase, 0 waits for everyone (see SetTimeout)
 	Src       int                 //The sender
-	B         abstract.Point      //Info about pubPoly of Src
-	Commits   []abstract.Point    //Commits used with B to reconstruct pubPoly
+	B         abstract.Point      //Info about pubPoly of Src (Feldman)
+	Commits   []abstract.Point    //Commits used with B to reconstruct pubPoly (Feldman)
+	Values    []abstract.Point    //The commitments H*p(i) to the shares (Scrape)
 	Shares    []*pvss.PubVerShare //The encrypted shares or src-th node
 }
 
@@ -56,7 +65,8 @@ type StructA1 struct {
 type V1 struct {
 	SessionID []byte        //SessionID to verify the validity
 	Src       int           //The sender
-	Votes     map[int]*Vote //Its votes
+	Votes     map[int]*Vote //Its votes, or the sums of the votes of its subtree (see SetTree)
+	Final     bool          //are these the totals sent down the tree by the root ?
 }
 
 // StructV1 just contains V1 and the data necessary to identify and
@@ -83,7 +93,8 @@ type StructR1 struct {
 // Transcript is given to a third party so that it can verify the process of creation of our random srting
 type Transcript struct {
 	SessionID []byte                            //The sessionID
-	Suite     abstract.Suite                    //The suite (rs.Suite())
+	Suite     string                            //The name of the suite, see SuiteByName
+	Scheme    string                            //The PVSS scheme, Feldman or Scrape
 	Nodes     int                               //Number of nodes
 	Faulty    int                               //Number of faulty nodes
 	Purpose   string                            //The purpose
@@ -99,6 +110,8 @@ type Transcript struct {
 type RandShare struct {
 	*onet.TreeNodeInstance                                   //The tree of nodes
 	mutex                  sync.Mutex                        //Mutex to avoid concurrency
+	suite                  abstract.Suite                    //The suite used for the PVSS
+	scheme                 string                            //The PVSS scheme, Feldman or Scrape
 	nodes                  int                               //Number of nodes
 	faulty                 int                               //Number of faulty nodes
 	threshold              int                               //The threshold to recover values
@@ -106,15 +119,28 @@ type RandShare struct {
 	startingTime           int64                             //starting time of the randshare protocol run
 	sessionID              []byte                            //The SessionID number (see method SessionID)
 	H                      abstract.Point                    //Our second base point created with SessionID
-	pubPolys               []*share.PubPoly                  //The pubPoly of every node
+	pubPolys               []*share.PubPoly                  //The pubPoly of every node (Feldman)
+	values                 [][]abstract.Point                //The commitments to the shares of every node (Scrape)
 	X                      []abstract.Point                  //The public keys
 	encShares              map[int]map[int]*pvss.PubVerShare //Matrix of encrypted shares : ES_i(j) = encShare[i][j]
 	tracker                map[int]int                       //tracker[i] can be -1 not enough enc share verified, 0 nothing received, 1 we have enough enc shares
 	votes                  map[int]*Vote                     //Indexes of good nodes is set at 1, sent when receieved an announce from everyone
 	nPrime                 int                               //Number of "good nodes" after voting process
+	replied                map[int]bool                      //Nodes whose decrypted shares we received
 	decShares              map[int]map[int]*pvss.PubVerShare //Matrix of decrypted shares : DS_i(j) = decShare[i][j]
 	secrets                map[int]abstract.Point            //Recovered secrets
 	coStringReady          bool                              //Is the coString available ?
 	coString               abstract.Point                    //Collective random string computed with the secrets
+	tree                   bool                              //Do messages travel along the tree instead of being broadcast ?
+	subtree                map[int]int                       //Sums of the votes of our subtree (tree mode)
+	reported               map[onet.TreeNodeID]bool          //The children that sent the sums of their subtree (tree mode)
+	ownVoted               bool                              //Are our own votes in subtree ? (tree mode)
+	final                  bool                              //Did we get the totals from our parent ? (tree mode)
+	timeout                time.Duration                     //How long we wait for the announces and the votes of the others, forever with 0
+	tracked                bool                              //Does the tracker have an entry for every dealer, are our votes sent ?
+	decided                bool                              //Did we compute n' and send our decrypted shares ?
 	Done                   chan bool                         //Is the protocol done ?
+	Admit                  func(id []byte, time int64) error //Called by Setup with the SessionID and the time, an error refuses the session (see package session)
+	run                    *metrics.Run                      //The metrics of our run
+	tracer                 *trace.Tracer                     //The events of our run
 }
@@ -0,0 +1,38 @@
+package randsharepvss
+
+import (
+	"fmt"
+
+	"github.com/dedis/student_17_randomness/bn256"
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/ed25519"
+	"gopkg.in/dedis/crypto.v0/nist"
+)
+
+//Names of the suites accepted by Setup and Verify
+const (
+	Ed25519 = "Ed25519" //the default onet suite
+	P256    = "P256"    //NIST P-256
+	BN256   = "BN256"   //G1 of the BN256 pairing-friendly curve
+)
+
+//suites maps a suite name to its constructor
+var suites = map[string]func() abstract.Suite{
+	Ed25519: func() abstract.Suite { return ed25519.NewAES128SHA256Ed25519(false) },
+	P256:    nist.NewAES128SHA256P256,
+	BN256:   bn256.NewAES128SHA256BN256,
+}
+
+//SuiteByName returns the suite registered under name
+func SuiteByName(name string) (abstract.Suite, error) {
+	newSuite, ok := suites[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown suite %s", name)
+	}
+	return newSuite(), nil
+}
+
+//SuiteNames lists the names of all the supported suites
+func SuiteNames() []string {
+	return []string{Ed25519, P256, BN256}
+}
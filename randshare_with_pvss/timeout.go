@@ -0,0 +1,80 @@
+package randsharepvss
+
+import (
+	"time"
+
+	"gopkg.in/dedis/onet.v1/log"
+)
+
+//SetTimeout sets how long a node waits for the announces, and then for the votes, of the others. Once it is over
+//the node goes on without the missing ones: the dealers it has no announce from are tracked as absent (0) and
+//left out of n', the nodes that didn't vote count as voting for nobody. Without it (0, the default) the nodes
+//wait for everyone, so a single crashed node stops the run.
+//It is called by the root before Start, the other nodes learn it from the announce.
+func (rs *RandShare) SetTimeout(timeout time.Duration) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.timeout = timeout
+}
+
+//deadline calls end once the timeout is over, if there is one. end has to do nothing if the phase ended before.
+func (rs *RandShare) deadline(end func() error) {
+	if rs.timeout <= 0 {
+		return
+	}
+	time.AfterFunc(rs.timeout, func() {
+		if err := end(); err != nil {
+			log.Error("Node", rs.Index(), "couldn't go on after the timeout:", err)
+		}
+	})
+}
+
+//closeDeal ends the deal phase: the dealers whose announce didn't arrive are tracked as absent and we vote
+func (rs *RandShare) closeDeal() error {
+	rs.mutex.Lock()
+	if rs.tracked {
+		rs.mutex.Unlock()
+		return nil
+	}
+	for j := 0; j < rs.nodes; j++ {
+		if _, ok := rs.tracker[j]; !ok {
+			rs.tracker[j] = 0
+			rs.tracer.Event("deal", j, "absent dealer")
+		}
+	}
+	rs.mutex.Unlock()
+	return rs.sendVotes()
+}
+
+//closeVotes ends the vote phase: the nodes that didn't vote, or in tree mode the children that didn't send the
+//sums of their subtree, count as voting for nobody. A crashed inner node of the tree still cuts its subtree off,
+//and a node whose parent crashed never gets the totals.
+func (rs *RandShare) closeVotes() error {
+	if rs.tree {
+		for _, child := range rs.Children() {
+			rs.mutex.Lock()
+			reported := rs.reported[child.ID]
+			rs.mutex.Unlock()
+			if !reported {
+				rs.tracer.Event("vote", child.RosterIndex, "absent voter")
+				if err := rs.voteTree(nil, child); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	rs.mutex.Lock()
+	if rs.decided {
+		rs.mutex.Unlock()
+		return nil
+	}
+	for index, vote := range rs.votes {
+		if !vote.Voted {
+			vote.Voted = true
+			rs.tracer.Event("vote", index, "absent voter")
+		}
+	}
+	rs.mutex.Unlock()
+	return rs.replyIfVoted()
+}
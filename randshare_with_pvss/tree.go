@@ -0,0 +1,161 @@
+package randsharepvss
+
+import (
+	"bytes"
+
+	"github.com/dedis/student_17_randomness/adversary"
+	"github.com/dedis/student_17_randomness/trace"
+	"gopkg.in/dedis/onet.v1"
+)
+
+//SetTree chooses how messages travel. By default every message is broadcast, which costs n^2 messages per phase.
+//With tree set, the announces and replies are sent to the tree neighbours and forwarded by every node to its
+//other neighbours, and the votes are summed up the tree, the root sending the totals back down. Each node then
+//only talks to its parent and children, and the n votes messages of n entries become 2(n-1) messages.
+//It is called by the root before Start, the other nodes learn it from the announce.
+func (rs *RandShare) SetTree(tree bool) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.tree = tree
+}
+
+//neighbours returns the parent and children of our node in the tree
+func (rs *RandShare) neighbours() []*onet.TreeNode {
+	neighbours := append([]*onet.TreeNode{}, rs.Children()...)
+	if !rs.IsRoot() {
+		neighbours = append(neighbours, rs.Parent())
+	}
+	return neighbours
+}
+
+//send gives a message of ours to every other node, by broadcast or along the tree
+func (rs *RandShare) send(msg interface{}) error {
+	if !rs.tree {
+		if err := rs.broadcast(msg); err != nil {
+			return err
+		}
+		rs.run.Sent(msg, len(rs.List())-1)
+		return nil
+	}
+	neighbours := rs.neighbours()
+	if err := rs.multicast(msg, neighbours...); err != nil {
+		return err
+	}
+	rs.run.Sent(msg, len(neighbours))
+	return nil
+}
+
+//relay forwards a message received from a tree neighbour to our other neighbours. As the tree has no cycle,
+//every node gets each message once. It does nothing without the tree.
+func (rs *RandShare) relay(from *onet.TreeNode, msg interface{}) error {
+	if !rs.tree {
+		return nil
+	}
+	for _, n := range rs.neighbours() {
+		if from == nil || !n.ID.Equal(from.ID) {
+			if err := rs.sendTo(n, msg); err != nil {
+				return err
+			}
+			rs.run.Sent(msg, 1)
+		}
+	}
+	return nil
+}
+
+//handleTreeVotes deals with the votes in tree mode: the sums of a child's subtree are added to ours, and the
+//totals coming from the parent are forwarded to the children before we reply
+func (rs *RandShare) handleTreeVotes(from *onet.TreeNode, msg *V1) error {
+	if !bytes.Equal(msg.SessionID, rs.sessionID) {
+		return nil //If the sessionID is not correct we don't deal with the message
+	}
+	if msg.Final {
+		if rs.IsRoot() || from == nil || !from.ID.Equal(rs.Parent().ID) {
+			return nil //only our parent can give us the totals
+		}
+		return rs.finalVotes(msg.Votes)
+	}
+	for _, child := range rs.Children() {
+		if from != nil && child.ID.Equal(from.ID) {
+			return rs.voteTree(msg.Votes, child)
+		}
+	}
+	return nil //only our children send us sums
+}
+
+//voteTree adds votes, ours (from nil) or the sums of the subtree of the child from, to the sums of our subtree.
+//Only the first sums of a child count, and none of them can be larger than the number of nodes of its subtree,
+//otherwise the child is counted with no votes. Once our own votes and those of every child are in, the sums go
+//to our parent or, at the root, the totals go back down the tree.
+func (rs *RandShare) voteTree(votes map[int]*Vote, from *onet.TreeNode) error {
+	rs.mutex.Lock()
+	if from == nil {
+		rs.ownVoted = true
+	} else {
+		if rs.reported[from.ID] {
+			rs.mutex.Unlock()
+			return nil
+		}
+		rs.reported[from.ID] = true
+		size := from.SubtreeCount() + 1
+		for index, vote := range votes {
+			if vote != nil && vote.Vote > size {
+				rs.tracer.Eventf("vote", from.RosterIndex, "invalid sums", "%d votes for %d from a subtree of %d", vote.Vote, index, size)
+				votes = nil
+				break
+			}
+		}
+	}
+	for index, vote := range votes {
+		if index >= 0 && index < rs.nodes && vote != nil {
+			rs.subtree[index] += vote.Vote
+		}
+	}
+	if !rs.ownVoted || len(rs.reported) < len(rs.Children()) {
+		rs.mutex.Unlock()
+		return nil
+	}
+	sums := make(map[int]*Vote)
+	for index := 0; index < rs.nodes; index++ {
+		sums[index] = &Vote{Vote: rs.subtree[index]}
+	}
+	rs.mutex.Unlock()
+
+	if !rs.IsRoot() {
+		step := &V1{SessionID: rs.sessionID, Src: rs.Index(), Votes: sums}
+		adversary.Hold(rs.Index(), rs.nodes)
+		if err := rs.sendTo(rs.Parent(), step); err != nil {
+			return err
+		}
+		rs.run.Sent(step, 1)
+		rs.tracer.Event("vote", rs.Parent().RosterIndex, "sums sent")
+		return nil
+	}
+	return rs.finalVotes(sums)
+}
+
+//finalVotes sends the totals to our children, stores them as everyone's votes and replies, for the first
+//totals only
+func (rs *RandShare) finalVotes(totals map[int]*Vote) error {
+	rs.mutex.Lock()
+	if rs.final {
+		rs.mutex.Unlock()
+		return nil
+	}
+	rs.final = true
+	rs.mutex.Unlock()
+	step := &V1{SessionID: rs.sessionID, Src: rs.Index(), Votes: totals, Final: true}
+	if err := rs.multicast(step, rs.Children()...); err != nil {
+		return err
+	}
+	rs.run.Sent(step, len(rs.Children()))
+	rs.tracer.Event("vote", trace.NoPeer, "totals received")
+	rs.mutex.Lock()
+	for index := 0; index < rs.nodes; index++ {
+		rs.votes[index] = &Vote{Voted: true}
+		if vote, ok := totals[index]; ok && vote != nil {
+			rs.votes[index].Vote = vote.Vote
+		}
+	}
+	rs.mutex.Unlock()
+	return rs.reply()
+}
@@ -0,0 +1,133 @@
+package randsharepvss
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+	"gopkg.in/dedis/onet.v1"
+)
+
+//validPoint checks that p is a point of suite, i.e. that it is set and decodes back to itself (on the curve)
+func validPoint(suite abstract.Suite, p abstract.Point) bool {
+	if p == nil {
+		return false
+	}
+	b, err := p.MarshalBinary()
+	if err != nil {
+		return false
+	}
+	q := suite.Point()
+	return q.UnmarshalBinary(b) == nil && q.Equal(p)
+}
+
+//validShare checks that the values of an encrypted or decrypted share are set and that its points are valid
+func validShare(suite abstract.Suite, s *pvss.PubVerShare) bool {
+	return wellFormed(s) && validPoint(suite, s.S.V) && validPoint(suite, s.P.VG) && validPoint(suite, s.P.VH)
+}
+
+//validIndex checks that i is the index of a node of the session
+func (rs *RandShare) validIndex(i int) bool {
+	return i >= 0 && i < rs.nodes && i < len(rs.X)
+}
+
+//validSender checks that the index a message claims is the one of the node that sent it. In tree mode the
+//announces and the replies are relayed, so they only have to come from a neighbour, trusted to forward them
+//unchanged.
+func (rs *RandShare) validSender(src int, from *onet.TreeNode, relayed bool) bool {
+	if from == nil {
+		return false
+	}
+	if rs.tree && relayed {
+		for _, n := range rs.neighbours() {
+			if n.ID.Equal(from.ID) {
+				return true
+			}
+		}
+		return false
+	}
+	return from.RosterIndex == src
+}
+
+//validA1 checks an announce before it is used : it comes from its sender, with one valid encrypted share per
+//node, in order, and the commitments of the scheme of the session
+func (rs *RandShare) validA1(msg *A1, from *onet.TreeNode) error {
+	if !rs.validIndex(msg.Src) || !rs.validSender(msg.Src, from, true) {
+		return fmt.Errorf("Wrong announce sender %d", msg.Src)
+	}
+	if msg.Scheme != rs.scheme {
+		return fmt.Errorf("Announce of %d uses %s instead of %s", msg.Src, msg.Scheme, rs.scheme)
+	}
+	if len(msg.Shares) != rs.nodes {
+		return fmt.Errorf("Announce of %d has %d shares for %d nodes", msg.Src, len(msg.Shares), rs.nodes)
+	}
+	for i, s := range msg.Shares {
+		if !validShare(rs.suite, s) || s.S.I != i {
+			return fmt.Errorf("Malformed share %d in the announce of %d", i, msg.Src)
+		}
+	}
+	switch rs.scheme {
+	case Scrape:
+		if len(msg.Values) != rs.nodes {
+			return fmt.Errorf("Announce of %d has %d commitments for %d nodes", msg.Src, len(msg.Values), rs.nodes)
+		}
+		for _, v := range msg.Values {
+			if !validPoint(rs.suite, v) {
+				return fmt.Errorf("Malformed commitment in the announce of %d", msg.Src)
+			}
+		}
+	default:
+		if len(msg.Commits) != rs.threshold {
+			return fmt.Errorf("Announce of %d has %d commitments for a threshold of %d", msg.Src, len(msg.Commits), rs.threshold)
+		}
+		if msg.B != nil && !validPoint(rs.suite, msg.B) {
+			return fmt.Errorf("Malformed base point in the announce of %d", msg.Src)
+		}
+		for _, c := range msg.Commits {
+			if !validPoint(rs.suite, c) {
+				return fmt.Errorf("Malformed commitment in the announce of %d", msg.Src)
+			}
+		}
+	}
+	return nil
+}
+
+//validV1 checks that the votes come from their sender, are about nodes of the session and can't add up to more
+//than the number of nodes
+func (rs *RandShare) validV1(msg *V1, from *onet.TreeNode) error {
+	if !rs.validIndex(msg.Src) || !rs.validSender(msg.Src, from, false) {
+		return fmt.Errorf("Wrong vote sender %d", msg.Src)
+	}
+	if len(msg.Votes) > rs.nodes {
+		return fmt.Errorf("Vote of %d has %d entries for %d nodes", msg.Src, len(msg.Votes), rs.nodes)
+	}
+	for index, vote := range msg.Votes {
+		if !rs.validIndex(index) || vote == nil || vote.Vote < 0 || vote.Vote > rs.nodes {
+			return fmt.Errorf("Malformed vote on %d from %d", index, msg.Src)
+		}
+	}
+	return nil
+}
+
+//validR1 checks that a reply comes from its sender and that its decrypted shares are valid shares of it, at most
+//one per dealer
+func (rs *RandShare) validR1(msg *R1, from *onet.TreeNode) error {
+	if !rs.validIndex(msg.Src) || !rs.validSender(msg.Src, from, true) {
+		return fmt.Errorf("Wrong reply sender %d", msg.Src)
+	}
+	if len(msg.Shares) > rs.nodes {
+		return errors.New("Too many shares in the reply")
+	}
+	rows := make(map[int]bool)
+	for _, s := range msg.Shares {
+		if s == nil || !rs.validIndex(s.Row) || rows[s.Row] {
+			return fmt.Errorf("Wrong share row in the reply of %d", msg.Src)
+		}
+		rows[s.Row] = true
+		if !validShare(rs.suite, s.PubVerShare) || s.PubVerShare.S.I != msg.Src {
+			return fmt.Errorf("Malformed share of row %d in the reply of %d", s.Row, msg.Src)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package randsharepvss
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dedis/student_17_randomness/msgtest"
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/nist"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+	"gopkg.in/dedis/onet.v1"
+)
+
+//copyShares copies the shares so that the mutations don't change the originals
+func copyShares(shares []*pvss.PubVerShare) []*pvss.PubVerShare {
+	copied := make([]*pvss.PubVerShare, len(shares))
+	for i, s := range shares {
+		c := *s
+		copied[i] = &c
+	}
+	return copied
+}
+
+func TestMalformedMessages(t *testing.T) {
+	nodes, faulty := 4, 1
+	local := onet.NewLocalTest()
+	_, _, tree := local.GenTree(nodes, true)
+	defer local.CloseAll()
+
+	setup := func(scheme string) *RandShare {
+		protocol, err := local.CreateProtocol(Name, tree)
+		if err != nil {
+			t.Fatal("couldn't initialize", err)
+		}
+		rs := protocol.(*RandShare)
+		if err := rs.Setup(nodes, faulty, "malformed", time.Now().Unix(), Ed25519, scheme); err != nil {
+			t.Fatal(err)
+		}
+		return rs
+	}
+	rs := setup(Feldman)
+	foreign := nist.NewAES128SHA256P256().Point().Base() //not a point of our suite
+	from := &onet.TreeNode{RosterIndex: 1}               //every message comes from node 1
+	encShares, pubPoly, err := pvss.EncShares(rs.suite, rs.H, rs.X, nil, rs.threshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, commits := pubPoly.Info()
+
+	shareMutations := func(shares func(m interface{}) []*pvss.PubVerShare) []func(interface{}) {
+		return []func(interface{}){
+			func(m interface{}) { shares(m)[0] = nil },
+			func(m interface{}) { shares(m)[1].S.I = 2 },
+			func(m interface{}) { shares(m)[2].S.V = foreign },
+			func(m interface{}) { shares(m)[3].P.VG = nil },
+			func(m interface{}) { shares(m)[3].P.R = nil },
+		}
+	}
+
+	a1 := func(m interface{}) []*pvss.PubVerShare { return m.(*A1).Shares }
+	msgtest.Malformed(t, "announce", append(append([]func(interface{}){
+		func(m interface{}) { m.(*A1).Src = nodes },
+		func(m interface{}) { m.(*A1).Src = 2 },
+		func(m interface{}) { m.(*A1).Scheme = Scrape },
+		func(m interface{}) { m.(*A1).B = foreign },
+		func(m interface{}) { m.(*A1).Commits[1] = nil },
+	}, shareMutations(a1)...),
+		func(m interface{}) { m.(*A1).Commits = m.(*A1).Commits[:1] },
+		func(m interface{}) { m.(*A1).Shares = append(m.(*A1).Shares, m.(*A1).Shares[0], m.(*A1).Shares[1]) },
+	), func() interface{} {
+		return &A1{SessionID: rs.sessionID, Scheme: Feldman, Src: 1, B: b, Commits: append([]abstract.Point{}, commits...), Shares: copyShares(encShares)}
+	}, func(m interface{}) error {
+		return rs.validA1(m.(*A1), from)
+	}, func(m interface{}) error {
+		return rs.HandleA1(StructA1{TreeNode: from, A1: *m.(*A1)})
+	})
+
+	scrape := setup(Scrape)
+	scrapeShares, values, err := EncSharesScrape(scrape.suite, scrape.H, scrape.X, nil, scrape.threshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgtest.Malformed(t, "SCRAPE announce", []func(interface{}){
+		func(m interface{}) { m.(*A1).Values[0] = nil },
+		func(m interface{}) { m.(*A1).Values[3] = foreign },
+		func(m interface{}) { m.(*A1).Values = m.(*A1).Values[:nodes-1] },
+	}, func() interface{} {
+		return &A1{SessionID: scrape.sessionID, Scheme: Scrape, Src: 1, Values: append([]abstract.Point{}, values...), Shares: copyShares(scrapeShares)}
+	}, func(m interface{}) error {
+		return scrape.validA1(m.(*A1), from)
+	}, func(m interface{}) error {
+		return scrape.HandleA1(StructA1{TreeNode: from, A1: *m.(*A1)})
+	})
+
+	msgtest.Malformed(t, "vote", []func(interface{}){
+		func(m interface{}) { m.(*V1).Src = -1 },
+		func(m interface{}) { m.(*V1).Src = 2 },
+		func(m interface{}) { m.(*V1).Votes[nodes] = &Vote{Vote: 1} },
+		func(m interface{}) { m.(*V1).Votes[0] = nil },
+		func(m interface{}) { m.(*V1).Votes[1] = &Vote{Vote: -1} },
+		func(m interface{}) { m.(*V1).Votes[2] = &Vote{Vote: nodes + 1} },
+		func(m interface{}) { m.(*V1).Votes[-1] = &Vote{Vote: 1} },
+	}, func() interface{} {
+		votes := make(map[int]*Vote)
+		for i := 0; i < nodes; i++ {
+			votes[i] = &Vote{Vote: 1}
+		}
+		return &V1{SessionID: rs.sessionID, Src: 1, Votes: votes}
+	}, func(m interface{}) error {
+		return rs.validV1(m.(*V1), from)
+	}, func(m interface{}) error {
+		return rs.HandleV1(StructV1{TreeNode: from, V1: *m.(*V1)})
+	})
+
+	//the reply of node 1, the validation doesn't check the proofs so its encrypted shares do
+	r1 := func(m interface{}) []*pvss.PubVerShare {
+		var shares []*pvss.PubVerShare
+		for _, s := range m.(*R1).Shares {
+			shares = append(shares, s.PubVerShare)
+		}
+		return shares
+	}
+	msgtest.Malformed(t, "reply", []func(interface{}){
+		func(m interface{}) { m.(*R1).Src = nodes },
+		func(m interface{}) { m.(*R1).Src = 2 },
+		func(m interface{}) { r1(m)[0].S.I = 0 },
+		func(m interface{}) { r1(m)[1].P.VH = foreign },
+		func(m interface{}) { r1(m)[2].P.C = nil },
+		func(m interface{}) { m.(*R1).Shares[1].Row = nodes },
+		func(m interface{}) { m.(*R1).Shares[2].Row = 3 },
+		func(m interface{}) { m.(*R1).Shares[0] = nil },
+		func(m interface{}) { m.(*R1).Shares[3].PubVerShare = nil },
+		func(m interface{}) { m.(*R1).Shares = append(m.(*R1).Shares, m.(*R1).Shares[0]) },
+	}, func() interface{} {
+		reply := &R1{SessionID: rs.sessionID, Src: 1}
+		for row := 0; row < nodes; row++ {
+			s := *encShares[1]
+			reply.Shares = append(reply.Shares, &Share{Row: row, PubVerShare: &s})
+		}
+		return reply
+	}, func(m interface{}) error {
+		return rs.validR1(m.(*R1), from)
+	}, func(m interface{}) error {
+		return rs.HandleR1(StructR1{TreeNode: from, R1: *m.(*R1)})
+	})
+
+	//nothing was stored
+	for i := 0; i < nodes; i++ {
+		if len(rs.encShares[i]) != 0 || len(rs.decShares[i]) != 0 || rs.votes[i].Voted || rs.votes[i].Vote != 0 {
+			t.Fatal("A malformed message changed the state")
+		}
+	}
+	if len(rs.tracker) != 0 || len(scrape.tracker) != 0 {
+		t.Fatal("A malformed message changed the state")
+	}
+}
@@ -0,0 +1,134 @@
+package randsharepvss
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/share"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+)
+
+//chunkMin is the smallest number of shares given to one worker, smaller chunks lose most of the gain of batching
+const chunkMin = 8
+
+//workerPool bounds the number of goroutines verifying shares at the same time. There is one pool for the
+//whole conode so that concurrent protocol instances share the cores instead of each using all of them.
+type workerPool struct {
+	slots chan struct{}
+}
+
+//verifiers holds the pool used by every RandShare instance of this conode, see SetVerifyWorkers
+var verifiers = struct {
+	sync.RWMutex
+	pool *workerPool
+}{pool: newWorkerPool(runtime.NumCPU())}
+
+//pool returns the current pool, the verifications running when it is replaced finish in the old one
+func pool() *workerPool {
+	verifiers.RLock()
+	defer verifiers.RUnlock()
+	return verifiers.pool
+}
+
+func newWorkerPool(workers int) *workerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	return &workerPool{slots: make(chan struct{}, workers)}
+}
+
+//SetVerifyWorkers sets the maximum number of shares verifications running in parallel on this conode.
+//The verifications already running keep the previous bound.
+func SetVerifyWorkers(workers int) {
+	wp := newWorkerPool(workers)
+	verifiers.Lock()
+	defer verifiers.Unlock()
+	verifiers.pool = wp
+}
+
+//workers returns the maximum number of jobs running at once
+func (wp *workerPool) workers() int {
+	return cap(wp.slots)
+}
+
+//run executes the jobs with at most workers() of them at the same time and returns once they are all done
+func (wp *workerPool) run(jobs []func()) {
+	var wg sync.WaitGroup
+	wg.Add(len(jobs))
+	for _, job := range jobs {
+		wp.slots <- struct{}{}
+		go func(job func()) {
+			defer func() {
+				<-wp.slots
+				wg.Done()
+			}()
+			job()
+		}(job)
+	}
+	wg.Wait()
+}
+
+//chunks cuts [0, n) into at most workers intervals of at least chunkMin elements
+func (wp *workerPool) chunks(n int) [][2]int {
+	size := (n + wp.workers() - 1) / wp.workers()
+	if size < chunkMin {
+		size = chunkMin
+	}
+	var intervals [][2]int
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		intervals = append(intervals, [2]int{start, end})
+	}
+	return intervals
+}
+
+//verifyChunks runs verify on every chunk of [0, n) in the pool. verify returns the positions of the invalid
+//elements relative to the start of its chunk. The result is sorted, so it doesn't depend on the scheduling.
+func (wp *workerPool) verifyChunks(n int, verify func(start, end int) []int) []int {
+	intervals := wp.chunks(n)
+	results := make([][]int, len(intervals))
+	jobs := make([]func(), len(intervals))
+	for c, interval := range intervals {
+		c, start, end := c, interval[0], interval[1]
+		jobs[c] = func() {
+			for _, p := range verify(start, end) {
+				results[c] = append(results[c], start+p)
+			}
+		}
+	}
+	wp.run(jobs)
+
+	var bad []int
+	for _, r := range results {
+		bad = append(bad, r...)
+	}
+	sort.Ints(bad)
+	return bad
+}
+
+//VerifyEncSharesParallel does the same as VerifyEncSharesBatch but splits the shares between the workers of the conode
+func VerifyEncSharesParallel(suite abstract.Suite, H abstract.Point, X []abstract.Point, pubPoly *share.PubPoly, shares []*pvss.PubVerShare) []int {
+	return pool().verifyChunks(len(shares), func(start, end int) []int {
+		return VerifyEncSharesBatch(suite, H, X, pubPoly, shares[start:end])
+	})
+}
+
+//VerifyDecSharesParallel does the same as VerifyDecSharesBatch but splits the shares between the workers of the conode
+func VerifyDecSharesParallel(suite abstract.Suite, X abstract.Point, encShares []*pvss.PubVerShare, decShares []*pvss.PubVerShare) []int {
+	return pool().verifyChunks(len(decShares), func(start, end int) []int {
+		//encShares may be shorter, VerifyDecSharesBatch reports the decrypted shares without encrypted share
+		var enc []*pvss.PubVerShare
+		if start < len(encShares) {
+			enc = encShares[start:]
+			if end < len(encShares) {
+				enc = encShares[start:end]
+			}
+		}
+		return VerifyDecSharesBatch(suite, X, enc, decShares[start:end])
+	})
+}
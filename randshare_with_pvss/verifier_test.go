@@ -0,0 +1,104 @@
+package randsharepvss
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolBound(t *testing.T) {
+	wp := newWorkerPool(3)
+	var mutex sync.Mutex
+	running, max := 0, 0
+	jobs := make([]func(), 20)
+	for i := range jobs {
+		jobs[i] = func() {
+			mutex.Lock()
+			running++
+			if running > max {
+				max = running
+			}
+			mutex.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+		}
+	}
+	wp.run(jobs)
+	if max > 3 || max == 0 {
+		t.Fatal("Wrong number of jobs running at once", max)
+	}
+}
+
+func TestVerifyParallel(t *testing.T) {
+	defer SetVerifyWorkers(pool().workers())
+	SetVerifyWorkers(4)
+
+	d := newDealing(t, Ed25519, 40)
+	d.encShares[3].S.V, d.encShares[30].S.V = d.encShares[30].S.V, d.encShares[3].S.V
+	d.encShares[17] = nil
+	expected := []int{3, 17, 30}
+	for i := 0; i < 3; i++ { //the result must not depend on the scheduling
+		if bad := VerifyEncSharesParallel(d.suite, d.H, d.X, d.pubPoly, d.encShares); !reflect.DeepEqual(bad, expected) {
+			t.Fatal("Wrong invalid encrypted shares", bad)
+		}
+	}
+
+	encShares, decShares := decryptions(t, d.suite, d.x[0], 40)
+	decShares[9].P.R = d.suite.Scalar().Zero()
+	decShares[25].S.V = d.suite.Point().Base()
+	encShares = encShares[:38] //the last two have no encrypted share
+	expected = []int{9, 25, 38, 39}
+	if bad := VerifyDecSharesParallel(d.suite, d.X[0], encShares, decShares); !reflect.DeepEqual(bad, expected) {
+		t.Fatal("Wrong invalid decrypted shares", bad)
+	}
+}
+
+//TestSetVerifyWorkers changes the number of workers while shares are verified, run it with -race
+func TestSetVerifyWorkers(t *testing.T) {
+	defer SetVerifyWorkers(pool().workers())
+
+	d := newDealing(t, Ed25519, 20)
+	var wg sync.WaitGroup
+	wg.Add(4)
+	for i := 0; i < 4; i++ {
+		go func() {
+			defer wg.Done()
+			if bad := VerifyEncSharesParallel(d.suite, d.H, d.X, d.pubPoly, d.encShares); bad != nil {
+				t.Error("Valid shares rejected", bad)
+			}
+		}()
+	}
+	for w := 1; w <= 4; w++ {
+		SetVerifyWorkers(w)
+	}
+	wg.Wait()
+	if pool().workers() != 4 {
+		t.Fatal("Wrong number of workers", pool().workers())
+	}
+}
+
+func BenchmarkEncSharesParallel128(b *testing.B) {
+	d := newDealing(b, Ed25519, 128)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if bad := VerifyEncSharesParallel(d.suite, d.H, d.X, d.pubPoly, d.encShares); bad != nil {
+			b.Fatal("valid shares rejected")
+		}
+	}
+}
+
+func BenchmarkDecSharesParallel128(b *testing.B) {
+	suite, _ := SuiteByName(Ed25519)
+	x := suite.NewKey(nil)
+	X := suite.Point().Mul(nil, x)
+	encShares, decShares := decryptions(b, suite, x, 128)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if bad := VerifyDecSharesParallel(suite, X, encShares, decShares); bad != nil {
+			b.Fatal("valid shares rejected")
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package randsharepvss
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+)
+
+//lagrangeMax is the number of share-index sets a Verifier remembers before starting a new cache
+const lagrangeMax = 1024
+
+//Verifier verifies transcripts. It keeps the Lagrange coefficients of the share-index sets it has
+//seen, as the same sets come back from one transcript to the next.
+type Verifier struct {
+	mutex    sync.Mutex                   //protects lagrange
+	lagrange map[string][]abstract.Scalar //Lagrange coefficients at 0, by suite and share indexes
+}
+
+//NewVerifier returns a Verifier with an empty cache
+func NewVerifier() *Verifier {
+	return &Verifier{lagrange: make(map[string][]abstract.Scalar)}
+}
+
+//defaultVerifier is the Verifier used by Verify
+var defaultVerifier = NewVerifier()
+
+//Verify is a method that verifies that we created the random collective string following the transcript
+func Verify(random []byte, transcript *Transcript) error {
+	return defaultVerifier.Verify(random, transcript)
+}
+
+//dealerShares are the decrypted shares of one dealer that enter the collective string
+type dealerShares struct {
+	dealer    int
+	indexes   []int               //sorted indexes of the decrypted shares
+	encShares []*pvss.PubVerShare //encShares[k] was decrypted into decShares[k] by node indexes[k]
+	decShares []*pvss.PubVerShare
+}
+
+//Verify verifies every decrypted share of the good dealers against its proof, the dealers being checked in
+//parallel by the worker pool, and then that their secrets add up to random.
+func (v *Verifier) Verify(random []byte, transcript *Transcript) error {
+	return v.verify(random, transcript, true)
+}
+
+//VerifyFast only checks that the secrets of the good dealers add up to random, it is for callers that already
+//trust the proofs of the decrypted shares (e.g. they verified them while running the protocol).
+func (v *Verifier) VerifyFast(random []byte, transcript *Transcript) error {
+	return v.verify(random, transcript, false)
+}
+
+func (v *Verifier) verify(random []byte, transcript *Transcript, proofs bool) error {
+	suite, err := SuiteByName(transcript.Suite)
+	if err != nil {
+		return err
+	}
+
+	for i, x := range transcript.X {
+		if !validPoint(suite, x) {
+			return fmt.Errorf("Malformed public key %d", i)
+		}
+	}
+
+	//verification of sessionID
+	sid := SessionID(suite, transcript.Scheme, transcript.Nodes, transcript.Faulty, transcript.X, transcript.Purpose, transcript.Time)
+	if !bytes.Equal(transcript.SessionID, sid) {
+		return errors.New("Wrong session identifier")
+	}
+
+	dealers, err := goodDealers(transcript)
+	if err != nil {
+		return err
+	}
+	threshold := transcript.Faulty + 1
+
+	if proofs {
+		//we verify the proofs of each dealer in parallel and drop the invalid decrypted shares, as pvss.RecoverSecret does
+		jobs := make([]func(), len(dealers))
+		for d, ds := range dealers {
+			ds := ds
+			jobs[d] = func() {
+				var indexes []int
+				var decShares []*pvss.PubVerShare
+				for k, j := range ds.indexes {
+					if pvss.VerifyDecShare(suite, nil, transcript.X[j], ds.encShares[k], ds.decShares[k]) == nil {
+						indexes = append(indexes, j)
+						decShares = append(decShares, ds.decShares[k])
+					}
+				}
+				ds.indexes, ds.decShares = indexes, decShares
+			}
+		}
+		pool().run(jobs)
+	}
+
+	//then the collective string is sum_dealers sum_j lambda_j*S_j, computed in a single multiplication
+	var scalars []abstract.Scalar
+	var points []abstract.Point
+	for _, ds := range dealers {
+		if len(ds.indexes) < threshold {
+			return fmt.Errorf("Not enough valid decrypted shares for dealer %d", ds.dealer)
+		}
+		//the shares have to lie on one polynomial of degree < threshold, or the secret would depend on the shares
+		//we pick : a transcript could then hold a dealing the nodes rejected and choose its secret
+		values := make([]abstract.Point, len(ds.decShares))
+		for k, decShare := range ds.decShares {
+			values[k] = decShare.S.V
+		}
+		if !dualCheck(suite, ds.indexes, values, threshold) {
+			return fmt.Errorf("The decrypted shares of dealer %d aren't consistent", ds.dealer)
+		}
+		//threshold shares are enough to interpolate, using the same first ones makes the index sets repeat
+		lambdas := v.coefficients(suite, ds.indexes[:threshold])
+		for k, lambda := range lambdas {
+			scalars = append(scalars, lambda)
+			points = append(points, ds.decShares[k].S.V)
+		}
+	}
+	coString := multiMul(suite, scalars, points)
+	bs, err := coString.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(bs, random) {
+		return errors.New("CoString isn't correct")
+	}
+
+	//everything was correct
+	return nil
+}
+
+//goodDealers gathers the decrypted shares of the dealers with enough votes, sorted by dealer and share index
+func goodDealers(transcript *Transcript) ([]*dealerShares, error) {
+	var dealers []*dealerShares
+	for id, vote := range transcript.Votes {
+		if vote == nil || vote.Vote <= transcript.Faulty {
+			continue
+		}
+		ds := &dealerShares{dealer: id}
+		for j := range transcript.DecShares[id] {
+			ds.indexes = append(ds.indexes, j)
+		}
+		sort.Ints(ds.indexes)
+		for _, j := range ds.indexes {
+			decShare := transcript.DecShares[id][j]
+			encShare := transcript.EncShares[id][j]
+			if j < 0 || j >= len(transcript.X) || j >= transcript.Nodes {
+				return nil, fmt.Errorf("Wrong share index %d for dealer %d", j, id)
+			}
+			if !wellFormed(decShare) || encShare == nil || encShare.S.V == nil || decShare.S.I != j {
+				return nil, fmt.Errorf("Malformed share %d of dealer %d", j, id)
+			}
+			ds.encShares = append(ds.encShares, encShare)
+			ds.decShares = append(ds.decShares, decShare)
+		}
+		dealers = append(dealers, ds)
+	}
+	sort.Slice(dealers, func(a, b int) bool { return dealers[a].dealer < dealers[b].dealer })
+	return dealers, nil
+}
+
+//coefficients returns the Lagrange coefficients at 0 of the shares with the given indexes (x = index+1)
+func (v *Verifier) coefficients(suite abstract.Suite, indexes []int) []abstract.Scalar {
+	key := fmt.Sprint(suite.String(), indexes)
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if lambdas, ok := v.lagrange[key]; ok {
+		return lambdas
+	}
+
+	lambdas := make([]abstract.Scalar, len(indexes))
+	for k, i := range indexes {
+		xi := suite.Scalar().SetInt64(1 + int64(i))
+		num := suite.Scalar().One()
+		den := suite.Scalar().One()
+		for _, j := range indexes {
+			if j == i {
+				continue
+			}
+			xj := suite.Scalar().SetInt64(1 + int64(j))
+			num.Mul(num, xj)
+			den.Mul(den, suite.Scalar().Sub(xj, xi))
+		}
+		lambdas[k] = num.Div(num, den)
+	}
+	if len(v.lagrange) >= lagrangeMax {
+		v.lagrange = make(map[string][]abstract.Scalar)
+	}
+	v.lagrange[key] = lambdas
+	return lambdas
+}
@@ -0,0 +1,181 @@
+package randsharepvss
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/dedis/crypto.v0/abstract"
+	"gopkg.in/dedis/crypto.v0/share"
+	"gopkg.in/dedis/crypto.v0/share/pvss"
+)
+
+//newTranscript runs the PVSS of n honest nodes without the network and returns the collective string with its transcript
+func newTranscript(t testing.TB, suiteName string, n int) ([]byte, *Transcript) {
+	suite, err := SuiteByName(suiteName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	faulty := n / 3
+	x := make([]abstract.Scalar, n)
+	X := make([]abstract.Point, n)
+	for i := range x {
+		x[i] = suite.NewKey(nil)
+		X[i] = suite.Point().Mul(nil, x[i])
+	}
+	tr := &Transcript{
+		Suite:     suiteName,
+		Scheme:    Feldman,
+		Nodes:     n,
+		Faulty:    faulty,
+		Purpose:   "transcript test",
+		Time:      time.Now().Unix(),
+		X:         X,
+		EncShares: make(map[int]map[int]*pvss.PubVerShare),
+		DecShares: make(map[int]map[int]*pvss.PubVerShare),
+		Votes:     make(map[int]*Vote),
+	}
+	tr.SessionID = SessionID(suite, Feldman, n, faulty, X, tr.Purpose, tr.Time)
+	tr.H, _ = suite.Point().Pick(nil, suite.Cipher(tr.SessionID))
+
+	coString := suite.Point().Null()
+	for i := 0; i < n; i++ {
+		encShares, pubPoly, err := pvss.EncShares(suite, tr.H, X, nil, faulty+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr.EncShares[i] = make(map[int]*pvss.PubVerShare)
+		tr.DecShares[i] = make(map[int]*pvss.PubVerShare)
+		tr.Votes[i] = &Vote{Voted: true, Vote: n}
+		var shares []*share.PubShare
+		for j := 0; j < n; j++ {
+			decShare, err := pvss.DecShare(suite, tr.H, X[j], pubPoly.Eval(j).V, x[j], encShares[j])
+			if err != nil {
+				t.Fatal(err)
+			}
+			tr.EncShares[i][j] = encShares[j]
+			tr.DecShares[i][j] = decShare
+			shares = append(shares, &decShare.S)
+		}
+		secret, err := share.RecoverCommit(suite, shares, faulty+1, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		coString.Add(coString, secret)
+	}
+	random, err := coString.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return random, tr
+}
+
+//verifySequential is the verification done before Verifier: one pvss.RecoverSecret per dealer, one after the other
+func verifySequential(random []byte, transcript *Transcript) error {
+	suite, _ := SuiteByName(transcript.Suite)
+	coString := suite.Point().Null()
+	for id, vote := range transcript.Votes {
+		if vote.Vote > transcript.Faulty {
+			var encShareList []*pvss.PubVerShare
+			var decShareList []*pvss.PubVerShare
+			var keys []abstract.Point
+			for j, share := range transcript.DecShares[id] {
+				encShareList = append(encShareList, transcript.EncShares[id][j])
+				decShareList = append(decShareList, share)
+				keys = append(keys, transcript.X[j])
+			}
+			secret, err := pvss.RecoverSecret(suite, nil, keys, encShareList, decShareList, transcript.Faulty+1, transcript.Nodes)
+			if err != nil {
+				return err
+			}
+			coString.Add(coString, secret)
+		}
+	}
+	bs, _ := coString.MarshalBinary()
+	if !bytes.Equal(bs, random) {
+		return errors.New("CoString isn't correct")
+	}
+	return nil
+}
+
+func TestVerifier(t *testing.T) {
+	random, tr := newTranscript(t, Ed25519, 7)
+	v := NewVerifier()
+	if err := v.Verify(random, tr); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.VerifyFast(random, tr); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.lagrange) != 1 {
+		t.Fatal("The index set of the dealers should have been cached once, got", len(v.lagrange))
+	}
+
+	wrong := append([]byte{}, random...)
+	wrong[0] ^= 1
+	if err := v.Verify(wrong, tr); err == nil {
+		t.Fatal("Verify accepted a wrong collective string")
+	}
+
+	//the session identifier binds the scheme, a Feldman session can't pass for a SCRAPE one
+	tr.Scheme = Scrape
+	if err := v.Verify(random, tr); err == nil {
+		t.Fatal("Verify accepted a transcript with another scheme")
+	}
+	tr.Scheme = Feldman
+
+	//a bad decrypted share is dropped by Verify as long as enough remain, but VerifyFast trusts it
+	suite, _ := SuiteByName(Ed25519)
+	tr.DecShares[2][0].S.V = suite.Point().Add(tr.DecShares[2][0].S.V, suite.Point().Base())
+	if err := v.Verify(random, tr); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.VerifyFast(random, tr); err == nil {
+		t.Fatal("VerifyFast should use the share it was told to trust")
+	}
+
+	//below the threshold the dealer's secret can't be recovered
+	for j := 1; j < 7-tr.Faulty; j++ {
+		tr.DecShares[2][j].P.R = suite.Scalar().Zero()
+	}
+	if err := v.Verify(random, tr); err == nil {
+		t.Fatal("Verify accepted a dealer without enough valid shares")
+	}
+
+	delete(tr.EncShares[3], 1)
+	if err := v.Verify(random, tr); err == nil {
+		t.Fatal("Verify accepted a decrypted share without encrypted share")
+	}
+}
+
+//TestInconsistentDealer swaps a share between two dealers : every proof still holds but the shares of each dealer
+//don't lie on one polynomial anymore, so the secret would depend on the shares used to recover it
+func TestInconsistentDealer(t *testing.T) {
+	random, tr := newTranscript(t, Ed25519, 7)
+	tr.EncShares[0][3], tr.EncShares[1][3] = tr.EncShares[1][3], tr.EncShares[0][3]
+	tr.DecShares[0][3], tr.DecShares[1][3] = tr.DecShares[1][3], tr.DecShares[0][3]
+	if err := Verify(random, tr); err == nil {
+		t.Fatal("Verify accepted a dealer whose shares aren't consistent")
+	}
+	if err := NewVerifier().VerifyFast(random, tr); err == nil {
+		t.Fatal("VerifyFast accepted a dealer whose shares aren't consistent")
+	}
+}
+
+func benchmarkVerify(b *testing.B, n int, verify func([]byte, *Transcript) error) {
+	random, tr := newTranscript(b, Ed25519, n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := verify(random, tr); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVerifySequential10(b *testing.B) { benchmarkVerify(b, 10, verifySequential) }
+func BenchmarkVerify10(b *testing.B)           { benchmarkVerify(b, 10, NewVerifier().Verify) }
+func BenchmarkVerifyFast10(b *testing.B)       { benchmarkVerify(b, 10, NewVerifier().VerifyFast) }
+func BenchmarkVerifySequential31(b *testing.B) { benchmarkVerify(b, 31, verifySequential) }
+func BenchmarkVerify31(b *testing.B)           { benchmarkVerify(b, 31, NewVerifier().Verify) }
+func BenchmarkVerifyFast31(b *testing.B)       { benchmarkVerify(b, 31, NewVerifier().VerifyFast) }
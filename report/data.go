@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//Run holds the columns of the simulation rows of one host count
+type Run struct {
+	Hosts  int
+	Values map[string]float64
+}
+
+//Variant is one protocol variant: the runs of one CSV, sorted by host count
+type Variant struct {
+	Name string
+	Runs []*Run
+}
+
+//onet measures every simulation with these, they aren't the protocol's
+var onetMeasures = map[string]bool{"ChildrenWait": true, "SimulSyncWait": true}
+
+//readVariant reads the CSV of a variant given as name=path or path, the name being then the file name
+func readVariant(arg string) (*Variant, error) {
+	name, path := "", arg
+	if i := strings.Index(arg, "="); i >= 0 {
+		name, path = arg[:i], arg[i+1:]
+	}
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	runs, err := readRuns(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &Variant{Name: name, Runs: runs}, nil
+}
+
+//readRuns parses a CSV written by onet, separated by commas or, once edited in a spreadsheet, semicolons.
+//The rows of the same host count are averaged.
+func readRuns(r io.Reader) ([]*Run, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	header := string(data)
+	if i := strings.IndexByte(header, '\n'); i >= 0 {
+		header = header[:i]
+	}
+	reader := csv.NewReader(strings.NewReader(string(data)))
+	if strings.Count(header, ";") > strings.Count(header, ",") {
+		reader.Comma = ';'
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 || len(records[0]) == 0 || records[0][0] != "hosts" {
+		return nil, errors.New("Not a simulation CSV, the first column should be hosts")
+	}
+
+	byHosts := make(map[int]*Run)
+	counts := make(map[int]int)
+	for _, record := range records[1:] {
+		hosts, err := strconv.Atoi(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("Wrong host count %q", record[0])
+		}
+		run, ok := byHosts[hosts]
+		if !ok {
+			run = &Run{Hosts: hosts, Values: make(map[string]float64)}
+			byHosts[hosts] = run
+		}
+		counts[hosts]++
+		for i, column := range records[0] {
+			if i >= len(record) {
+				break
+			}
+			v, err := strconv.ParseFloat(strings.TrimSpace(record[i]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("Wrong value %q for %s", record[i], column)
+			}
+			run.Values[column] += v
+		}
+	}
+
+	var runs []*Run
+	for hosts, run := range byHosts {
+		for column := range run.Values {
+			run.Values[column] /= float64(counts[hosts])
+		}
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Hosts < runs[j].Hosts })
+	return runs, nil
+}
+
+//value returns a column of the run, NaN if it is missing
+func (r *Run) value(column string) float64 {
+	v, ok := r.Values[column]
+	if !ok {
+		return math.NaN()
+	}
+	return v
+}
+
+//CPU is the user and system time of a measure, in seconds
+func (r *Run) CPU(measure string) float64 {
+	return r.value(measure+"_user_avg") + r.value(measure+"_system_avg")
+}
+
+//Wall is the wall clock time of a measure, in seconds
+func (r *Run) Wall(measure string) float64 {
+	return r.value(measure + "_wall_avg")
+}
+
+//Bandwidth is the number of bytes sent by all the hosts
+func (r *Run) Bandwidth() float64 {
+	return r.value("bandwidth_tx_sum")
+}
+
+//measures returns the time measures of the protocols found in the variants, those of onet left out
+func measures(variants []*Variant) []string {
+	found := make(map[string]bool)
+	for _, v := range variants {
+		for _, run := range v.Runs {
+			for column := range run.Values {
+				if strings.HasSuffix(column, "_wall_avg") {
+					measure := strings.TrimSuffix(column, "_wall_avg")
+					if !onetMeasures[measure] {
+						found[measure] = true
+					}
+				}
+			}
+		}
+	}
+	var list []string
+	for m := range found {
+		list = append(list, m)
+	}
+	sort.Strings(list)
+	return list
+}
+
+//hasColumn tells if a run of the variants has column
+func hasColumn(variants []*Variant, column string) bool {
+	for _, v := range variants {
+		for _, run := range v.Runs {
+			if _, ok := run.Values[column]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//hostCounts returns every host count of the variants, sorted
+func hostCounts(variants []*Variant) []int {
+	found := make(map[int]bool)
+	for _, v := range variants {
+		for _, run := range v.Runs {
+			found[run.Hosts] = true
+		}
+	}
+	var list []int
+	for h := range found {
+		list = append(list, h)
+	}
+	sort.Ints(list)
+	return list
+}
+
+//run returns the run of v with hosts, nil if there is none
+func (v *Variant) run(hosts int) *Run {
+	for _, run := range v.Runs {
+		if run.Hosts == hosts {
+			return run
+		}
+	}
+	return nil
+}
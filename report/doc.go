@@ -0,0 +1,20 @@
+/*Command report turns the CSVs written by the onet simulations into SVG charts and a Markdown table, so that
+the variants of the protocol can be compared without copying numbers by hand into plot/.
+
+Each argument is the simulation.csv of one variant, optionally named: name=path, the file name otherwise.
+
+	go run ./report -out report randshare=simulation/test_data/randshare/simulation.csv \
+		pvss=simulation/test_data/pvss/simulation.csv
+
+For every host count it computes, averaging the rows of the same host count:
+	- the CPU time of each measure of the protocols, user plus system
+	- the wall clock time of each measure
+	- the bytes sent by all the hosts
+and writes cpu.svg, wall.svg, bandwidth.svg and report.md, the summary table, in the -out directory.
+The measures of onet itself (ChildrenWait, SimulSyncWait) are left out, -measures picks others.
+
+The simulations with adversaries add the completion rate and n' to the table. With -baseline, e.g. the variant
+run without faults, the table also gives the extra CPU, wall clock time and bytes of the other variants over
+it at each host count, the times summed over the measures.
+*/
+package main
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/dedis/onet.v1/log"
+)
+
+func main() {
+	out := flag.String("out", "report", "directory of the charts and of report.md")
+	list := flag.String("measures", "", "comma separated time measures to report, every one of the protocols by default")
+	baseline := flag.String("baseline", "", "variant the extra cost of the others is computed against, e.g. the run without faults")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: report [-out dir] [-measures m1,m2] [-baseline name] [name=]simulation.csv...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var variants []*Variant
+	for _, arg := range flag.Args() {
+		v, err := readVariant(arg)
+		log.ErrFatal(err)
+		variants = append(variants, v)
+	}
+	var ms []string
+	if *list != "" {
+		ms = strings.Split(*list, ",")
+	}
+	log.ErrFatal(write(*out, variants, ms, *baseline))
+	log.Lvl1("Report written to", filepath.Join(*out, "report.md"))
+}
+
+//write writes the charts and the summary of the variants in dir, for the time measures ms or every one found.
+//If baseline names a variant, the summary gives the extra cost of the others over it.
+func write(dir string, variants []*Variant, ms []string, baseline string) error {
+	if len(ms) == 0 {
+		ms = measures(variants)
+	}
+	if len(ms) == 0 {
+		return errors.New("No time measure in the CSVs")
+	}
+	var base *Variant
+	for _, v := range variants {
+		if v.Name == baseline {
+			base = v
+		}
+	}
+	if baseline != "" && base == nil {
+		return fmt.Errorf("No variant %s", baseline)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	var names []string
+	for _, v := range variants {
+		names = append(names, v.Name)
+	}
+	hosts := hostCounts(variants)
+	//value returns the values of a metric of the runs, NaN for a host count a variant has no run for
+	value := func(get func(run *Run, measure string) float64) func(int, int, string) float64 {
+		return func(v int, h int, m string) float64 {
+			run := variants[v].run(h)
+			if run == nil {
+				return math.NaN()
+			}
+			return get(run, m)
+		}
+	}
+
+	charts := map[string]*chart{
+		"cpu.svg":  {title: "CPU time (user + system)", unit: "seconds", hosts: hosts, variants: names, measures: ms, value: value((*Run).CPU)},
+		"wall.svg": {title: "Wall clock time", unit: "seconds", hosts: hosts, variants: names, measures: ms, value: value((*Run).Wall)},
+		"bandwidth.svg": {title: "Bytes sent by all the hosts", unit: "MB", hosts: hosts, variants: names, measures: []string{""},
+			value: value(func(run *Run, _ string) float64 { return run.Bandwidth() / 1e6 })},
+	}
+	for file, c := range charts {
+		if err := writeFile(filepath.Join(dir, file), c.write); err != nil {
+			return err
+		}
+	}
+	return writeFile(filepath.Join(dir, "report.md"), func(w io.Writer) error {
+		return summary(w, variants, hosts, ms, base)
+	})
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+//summary writes the Markdown table of the variants, one row per variant and host count. The simulations with
+//adversaries add the completion rate and n', and a base variant the extra cost of the others over it.
+func summary(w io.Writer, variants []*Variant, hosts []int, ms []string, base *Variant) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "# Simulation report")
+	fmt.Fprintln(b)
+	fmt.Fprintln(b, "![CPU time](cpu.svg)")
+	fmt.Fprintln(b, "![Wall clock time](wall.svg)")
+	fmt.Fprintln(b, "![Bandwidth](bandwidth.svg)")
+	fmt.Fprintln(b)
+
+	faults := hasColumn(variants, "completed_avg")
+	columns := []string{"Variant", "Hosts"}
+	for _, m := range ms {
+		columns = append(columns, m+" CPU (s)", m+" wall (s)")
+	}
+	columns = append(columns, "Sent (MB)", "Sent per host (kB)")
+	if faults {
+		columns = append(columns, "Completed", "n'")
+	}
+	if base != nil {
+		columns = append(columns, "Extra CPU (s)", "Extra wall (s)", "Extra sent (MB)")
+	}
+	fmt.Fprintln(b, "| "+strings.Join(columns, " | ")+" |")
+	fmt.Fprintln(b, "|"+strings.Repeat(" --- |", len(columns)))
+	for _, v := range variants {
+		for _, h := range hosts {
+			run := v.run(h)
+			if run == nil {
+				continue
+			}
+			row := []string{v.Name, fmt.Sprint(h)}
+			for _, m := range ms {
+				row = append(row, format(run.CPU(m), "%.3f"), format(run.Wall(m), "%.3f"))
+			}
+			row = append(row, format(run.Bandwidth()/1e6, "%.2f"), format(run.Bandwidth()/1e3/float64(h), "%.1f"))
+			if faults {
+				row = append(row, format(100*run.value("completed_avg"), "%.0f%%"), format(run.value("nprime_avg"), "%.1f"))
+			}
+			if base != nil {
+				row = append(row, extra(run, base.run(h), ms, v == base)...)
+			}
+			fmt.Fprintln(b, "| "+strings.Join(row, " | ")+" |")
+		}
+	}
+	return b.Flush()
+}
+
+//extra returns the CPU, wall clock time and bytes sent of run beyond those of the base run with as many hosts,
+//the times summed over the measures. There are none for the base variant itself.
+func extra(run *Run, base *Run, ms []string, isBase bool) []string {
+	if isBase || base == nil {
+		return []string{"-", "-", "-"}
+	}
+	var cpu, wall float64
+	for _, m := range ms {
+		cpu += run.CPU(m) - base.CPU(m)
+		wall += run.Wall(m) - base.Wall(m)
+	}
+	return []string{format(cpu, "%+.3f"), format(wall, "%+.3f"), format((run.Bandwidth()-base.Bandwidth())/1e6, "%+.2f")}
+}
+
+//format formats v, a missing value being a dash
+func format(v float64, f string) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return fmt.Sprintf(f, v)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadRuns(t *testing.T) {
+	//onet writes commas
+	v, err := readVariant("randshare=../simulation/test_data/randshare/simulation.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "randshare" || len(v.Runs) != 4 || v.Runs[0].Hosts != 8 {
+		t.Fatalf("Wrong variant %s with %d runs", v.Name, len(v.Runs))
+	}
+	if wall := v.run(8).Wall("tgen-randshare"); math.Abs(wall-2.310495) > 1e-6 {
+		t.Fatal("Wrong wall clock time", wall)
+	}
+	if !math.IsNaN(v.run(8).Wall("tver-randshare")) {
+		t.Fatal("A missing measure should be NaN")
+	}
+
+	//a spreadsheet writes semicolons
+	pvss, err := readVariant("../simulation/test_data/pvss/simulation.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pvss.Name != "simulation" || pvss.run(128) == nil {
+		t.Fatalf("Wrong variant %s", pvss.Name)
+	}
+	ms := measures([]*Variant{v, pvss})
+	if strings.Join(ms, ",") != "tgen-randshare,tver-randshare" {
+		t.Fatal("Wrong measures", ms)
+	}
+	if hosts := hostCounts([]*Variant{v, pvss}); len(hosts) != 5 || hosts[4] != 128 {
+		t.Fatal("Wrong host counts", hosts)
+	}
+
+	//the rows of the same host count are averaged
+	runs, err := readRuns(strings.NewReader("hosts,m_wall_avg,m_user_avg,m_system_avg\n4,1,0.5,0.1\n4,3,1.5,0.3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].Wall("m") != 2 || math.Abs(runs[0].CPU("m")-1.2) > 1e-9 {
+		t.Fatalf("Wrong average %+v", runs)
+	}
+	if _, err := readRuns(strings.NewReader("round,m_wall_avg\n1,2\n")); err == nil {
+		t.Fatal("A CSV without hosts should be refused")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var variants []*Variant
+	for _, arg := range []string{"randshare=../simulation/test_data/randshare/simulation.csv", "pvss=../simulation/test_data/pvss/simulation.csv"} {
+		v, err := readVariant(arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		variants = append(variants, v)
+	}
+	if err := write(dir, variants, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{"cpu.svg", "wall.svg", "bandwidth.svg"} {
+		b, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		svg := string(b)
+		if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>\n") || !strings.Contains(svg, "pvss") {
+			t.Fatalf("Wrong %s:\n%s", file, svg)
+		}
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "report.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := string(b)
+	if !strings.Contains(md, "| randshare | 8 | 0.092 | 2.310 | - | - |") || !strings.Contains(md, "| pvss | 128 |") {
+		t.Fatalf("Wrong summary:\n%s", md)
+	}
+	if strings.Contains(md, "ChildrenWait") {
+		t.Fatal("The measures of onet should be left out")
+	}
+}
+
+//TestFaults summarises the samples of testdata, written in the format of the simulations with adversaries
+func TestFaults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var variants []*Variant
+	for _, arg := range []string{"pvss=testdata/pvss.csv", "faults=testdata/pvss_faults.csv", "crashed=testdata/plain_crashed.csv"} {
+		v, err := readVariant(arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		variants = append(variants, v)
+	}
+	if err := write(dir, variants, nil, "missing"); err == nil {
+		t.Fatal("An unknown baseline should be refused")
+	}
+	if err := write(dir, variants, nil, "pvss"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "report.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := string(b)
+	for _, row := range []string{
+		"| pvss | 7 | ", " | 100% | 7.0 | - | - | - |", //the baseline has no extra cost
+		"| faults | 10 | ", " | 100% | 8.0 | +",
+		"| crashed | 7 | - | - | - | - | ", " | 0% | - | ",
+	} {
+		if !strings.Contains(md, row) {
+			t.Fatalf("No %q in the summary:\n%s", row, md)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+)
+
+//chart is a bar chart with one group of bars per host count, one bar per variant, each bar stacking the
+//values of the measures
+type chart struct {
+	title    string
+	unit     string
+	hosts    []int
+	variants []string
+	measures []string //one segment per measure, a single empty measure for unstacked bars
+	value    func(variant int, hosts int, measure string) float64
+}
+
+//palette gives the colour of each variant, the later measures of a bar are darker shades of it
+var palette = []string{"#9ACD32", "#6495ED", "#FF8C00", "#BA55D3", "#20B2AA", "#DC143C", "#DAA520", "#708090"}
+
+const (
+	chartWidth  = 760.0
+	chartHeight = 440.0
+	left        = 80.0
+	right       = 20.0
+	top         = 40.0
+	bottom      = 60.0
+)
+
+//shade returns the colour of the k-th measure of variant v
+func shade(v int, k int) string {
+	c := palette[v%len(palette)]
+	r, _ := strconv.ParseUint(c[1:3], 16, 8)
+	g, _ := strconv.ParseUint(c[3:5], 16, 8)
+	b, _ := strconv.ParseUint(c[5:7], 16, 8)
+	f := math.Pow(0.65, float64(k))
+	return fmt.Sprintf("#%02X%02X%02X", int(float64(r)*f), int(float64(g)*f), int(float64(b)*f))
+}
+
+//scale maps the values to the height of the plot, logarithmic if they span more than two orders of magnitude
+type scale struct {
+	log    bool
+	lo, hi float64
+}
+
+func newScale(min float64, max float64) *scale {
+	if max <= 0 {
+		return &scale{lo: 0, hi: 1}
+	}
+	if min > 0 && max/min > 100 {
+		return &scale{log: true, lo: math.Pow(10, math.Floor(math.Log10(min))), hi: math.Pow(10, math.Ceil(math.Log10(max)))}
+	}
+	step := niceStep(max / 5)
+	return &scale{lo: 0, hi: math.Ceil(max/step) * step}
+}
+
+//niceStep rounds x up to 1, 2 or 5 times a power of ten
+func niceStep(x float64) float64 {
+	p := math.Pow(10, math.Floor(math.Log10(x)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if x <= m*p {
+			return m * p
+		}
+	}
+	return 10 * p
+}
+
+//y returns the vertical position of v, clamped to the plot
+func (s *scale) y(v float64) float64 {
+	plot := chartHeight - top - bottom
+	var f float64
+	if s.log {
+		if v <= s.lo {
+			f = 0
+		} else {
+			f = (math.Log10(v) - math.Log10(s.lo)) / (math.Log10(s.hi) - math.Log10(s.lo))
+		}
+	} else {
+		f = (v - s.lo) / (s.hi - s.lo)
+	}
+	f = math.Max(0, math.Min(1, f))
+	return chartHeight - bottom - f*plot
+}
+
+//ticks returns the values of the horizontal grid lines
+func (s *scale) ticks() []float64 {
+	var ticks []float64
+	if s.log {
+		for v := s.lo; v <= s.hi*1.001; v *= 10 {
+			ticks = append(ticks, v)
+		}
+		return ticks
+	}
+	step := niceStep(s.hi / 5)
+	for v := s.lo; v <= s.hi*1.001; v += step {
+		ticks = append(ticks, v)
+	}
+	return ticks
+}
+
+//write draws the chart as SVG
+func (c *chart) write(w io.Writer) error {
+	min, max := math.Inf(1), 0.0
+	for v := range c.variants {
+		for _, h := range c.hosts {
+			total := 0.0
+			for _, m := range c.measures {
+				if x := c.value(v, h, m); !math.IsNaN(x) && x > 0 {
+					total += x
+					min = math.Min(min, x)
+				}
+			}
+			max = math.Max(max, total)
+		}
+	}
+	s := newScale(min, max)
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" font-family="sans-serif" font-size="12">`+"\n", chartWidth, chartHeight+20*float64(len(c.variants)*len(c.measures)))
+	fmt.Fprintf(b, `<text x="%g" y="20" text-anchor="middle" font-size="15">%s</text>`+"\n", chartWidth/2, html.EscapeString(c.title))
+	for _, t := range s.ticks() {
+		fmt.Fprintf(b, `<line x1="%g" y1="%.1f" x2="%g" y2="%.1f" stroke="#DDDDDD"/>`+"\n", left, s.y(t), chartWidth-right, s.y(t))
+		fmt.Fprintf(b, `<text x="%g" y="%.1f" text-anchor="end">%s</text>`+"\n", left-6, s.y(t)+4, strconv.FormatFloat(t, 'g', 4, 64))
+	}
+	fmt.Fprintf(b, `<text x="15" y="%g" text-anchor="middle" transform="rotate(-90 15 %g)">%s</text>`+"\n", (chartHeight-bottom+top)/2, (chartHeight-bottom+top)/2, html.EscapeString(c.unit))
+
+	group := (chartWidth - left - right) / float64(len(c.hosts))
+	bar := group * 0.8 / float64(len(c.variants))
+	for i, h := range c.hosts {
+		x0 := left + float64(i)*group + group*0.1
+		for v := range c.variants {
+			x := x0 + float64(v)*bar
+			total := 0.0
+			for k, m := range c.measures {
+				value := c.value(v, h, m)
+				if math.IsNaN(value) || value <= 0 {
+					continue
+				}
+				y0, y1 := s.y(total), s.y(total+value)
+				total += value
+				fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s %s %d hosts: %s</title></rect>`+"\n",
+					x, y1, bar, y0-y1, shade(v, k), html.EscapeString(c.variants[v]), html.EscapeString(m), h, strconv.FormatFloat(value, 'g', 4, 64))
+			}
+		}
+		fmt.Fprintf(b, `<text x="%.1f" y="%g" text-anchor="middle">%d</text>`+"\n", x0+group*0.4, chartHeight-bottom+16, h)
+	}
+	fmt.Fprintf(b, `<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="black"/>`+"\n", left, chartHeight-bottom, chartWidth-right, chartHeight-bottom)
+	fmt.Fprintf(b, `<text x="%g" y="%g" text-anchor="middle">Number of hosts</text>`+"\n", (chartWidth+left)/2, chartHeight-bottom+36)
+
+	y := chartHeight
+	for v, name := range c.variants {
+		for k, m := range c.measures {
+			label := name
+			if m != "" {
+				label += " " + m
+			}
+			fmt.Fprintf(b, `<rect x="%g" y="%g" width="12" height="12" fill="%s"/><text x="%g" y="%g">%s</text>`+"\n", left, y-10, shade(v, k), left+18, y, html.EscapeString(label))
+			y += 20
+		}
+	}
+	fmt.Fprintln(b, "</svg>")
+	return b.Flush()
+}
@@ -0,0 +1,17 @@
+/*Package sampling turns the collective string of randsharepvss into selections that anyone can re-derive:
+	- Int picks an integer in [0,n) without modulo bias
+	- Sample picks k distinct integers of [0,n)
+	- Permutation shuffles [0,n) with Fisher-Yates
+
+Every selection comes with a Proof holding the collective string, its transcript, a label and the
+parameters of the selection. Proof.Verify checks the transcript with randsharepvss.Verify and derives the
+selection again, so a third party only needs the proof to check it.
+
+The label separates the uses of a same collective string: two selections with different labels are
+independent, e.g. "committee" and "leader".
+
+The package uses two files:
+- source.go defines the deterministic stream of integers drawn from a collective string
+- sampling.go defines the selections and their proofs
+*/
+package sampling
@@ -0,0 +1,105 @@
+package sampling
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/dedis/student_17_randomness/randshare_with_pvss"
+)
+
+//The kinds of selection a Proof can be about
+const (
+	KindInt         = "int"
+	KindSample      = "sample"
+	KindPermutation = "permutation"
+)
+
+//Proof ties a selection to the collective string it was derived from
+type Proof struct {
+	Random     []byte                    //The collective string
+	Transcript *randsharepvss.Transcript //The transcript of the collective string
+	Label      string                    //The label of the selection, see NewSource
+	Kind       string                    //KindInt, KindSample or KindPermutation
+	N          int                       //The selection is made in [0,N)
+	K          int                       //The number of elements selected
+	Result     []int                     //The selection
+}
+
+//Int verifies the transcript and returns an integer of [0,n) derived from random
+func Int(random []byte, transcript *randsharepvss.Transcript, label string, n int) (int, *Proof, error) {
+	p := &Proof{Random: random, Transcript: transcript, Label: label, Kind: KindInt, N: n, K: 1}
+	if err := p.prove(); err != nil {
+		return 0, nil, err
+	}
+	return p.Result[0], p, nil
+}
+
+//Sample verifies the transcript and returns k distinct integers of [0,n) derived from random
+func Sample(random []byte, transcript *randsharepvss.Transcript, label string, n int, k int) ([]int, *Proof, error) {
+	p := &Proof{Random: random, Transcript: transcript, Label: label, Kind: KindSample, N: n, K: k}
+	if err := p.prove(); err != nil {
+		return nil, nil, err
+	}
+	return p.Result, p, nil
+}
+
+//Permutation verifies the transcript and returns a permutation of [0,n) derived from random
+func Permutation(random []byte, transcript *randsharepvss.Transcript, label string, n int) ([]int, *Proof, error) {
+	p := &Proof{Random: random, Transcript: transcript, Label: label, Kind: KindPermutation, N: n, K: n}
+	if err := p.prove(); err != nil {
+		return nil, nil, err
+	}
+	return p.Result, p, nil
+}
+
+//prove fills Result with the selection derived from a verified collective string
+func (p *Proof) prove() error {
+	result, err := p.derive()
+	if err != nil {
+		return err
+	}
+	p.Result = result
+	return nil
+}
+
+//derive verifies the transcript and computes the selection described by the proof from its collective string
+func (p *Proof) derive() ([]int, error) {
+	if p.Transcript == nil {
+		return nil, errors.New("No transcript")
+	}
+	if err := randsharepvss.Verify(p.Random, p.Transcript); err != nil {
+		return nil, err
+	}
+	source := NewSource(p.Random, p.Label)
+	switch p.Kind {
+	case KindInt:
+		if p.K != 1 {
+			return nil, errors.New("An integer proof selects one element")
+		}
+		i, err := source.Intn(p.N)
+		if err != nil {
+			return nil, err
+		}
+		return []int{i}, nil
+	case KindSample:
+		return source.Sample(p.N, p.K)
+	case KindPermutation:
+		if p.K != p.N {
+			return nil, errors.New("A permutation selects every element")
+		}
+		return source.Permutation(p.N)
+	}
+	return nil, errors.New("Unknown kind of selection " + p.Kind)
+}
+
+//Verify checks the transcript of the collective string and that the selection is the one derived from it
+func (p *Proof) Verify() error {
+	result, err := p.derive()
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(result, p.Result) {
+		return errors.New("The selection doesn't match the collective string")
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package sampling
+
+import (
+	"testing"
+
+	"github.com/dedis/student_17_randomness/randshare_with_pvss/pvsstest"
+)
+
+func TestProofs(t *testing.T) {
+	random, transcript := pvsstest.Run(t, 7, "sampling test")
+
+	i, proofInt, err := Int(random, transcript, "leader", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i < 0 || i >= 7 {
+		t.Fatal("Integer out of range", i)
+	}
+	sample, proofSample, err := Sample(random, transcript, "committee", 100, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sample) != 10 {
+		t.Fatal("Wrong sample size", len(sample))
+	}
+	_, proofPerm, err := Permutation(random, transcript, "order", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []*Proof{proofInt, proofSample, proofPerm} {
+		if err := p.Verify(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//a third party rejects a selection that wasn't derived from the string
+	proofSample.Result[0], proofSample.Result[1] = proofSample.Result[1], proofSample.Result[0]
+	if err := proofSample.Verify(); err == nil {
+		t.Fatal("Verify accepted a modified selection")
+	}
+	proofPerm.Label = "another order"
+	if err := proofPerm.Verify(); err == nil {
+		t.Fatal("Verify accepted a selection under another label")
+	}
+
+	//and a string that doesn't match its transcript
+	wrong := append([]byte{}, random...)
+	wrong[0] ^= 1
+	if _, _, err := Int(wrong, transcript, "leader", 7); err == nil {
+		t.Fatal("Int accepted a string that doesn't match the transcript")
+	}
+}
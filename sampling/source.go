@@ -0,0 +1,101 @@
+package sampling
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+)
+
+//Source is a deterministic stream of integers derived from a collective string and a label.
+//The bytes are the blocks SHA256(seed || counter) for counter = 0, 1, ... where seed = SHA256(len(label) || label || random).
+type Source struct {
+	seed    [sha256.Size]byte
+	counter uint64
+	block   []byte //unused bytes of the current block
+}
+
+//NewSource returns the stream of the collective string random for the given label
+func NewSource(random []byte, label string) *Source {
+	h := sha256.New()
+	length := make([]byte, 8)
+	binary.BigEndian.PutUint64(length, uint64(len(label)))
+	h.Write(length)
+	h.Write([]byte(label))
+	h.Write(random)
+	s := &Source{}
+	copy(s.seed[:], h.Sum(nil))
+	return s
+}
+
+//Uint64 returns the next 8 bytes of the stream as a big-endian integer
+func (s *Source) Uint64() uint64 {
+	if len(s.block) < 8 {
+		buf := make([]byte, len(s.seed)+8)
+		copy(buf, s.seed[:])
+		binary.BigEndian.PutUint64(buf[len(s.seed):], s.counter)
+		s.counter++
+		block := sha256.Sum256(buf)
+		s.block = block[:]
+	}
+	v := binary.BigEndian.Uint64(s.block[:8])
+	s.block = s.block[8:]
+	return v
+}
+
+//Intn returns an integer uniformly distributed in [0,n)
+func (s *Source) Intn(n int) (int, error) {
+	if n <= 0 {
+		return 0, errors.New("Intn needs a positive bound")
+	}
+	v, err := s.Uint64n(uint64(n))
+	return int(v), err
+}
+
+//Uint64n returns an integer uniformly distributed in [0,n). The values of Uint64 above the largest multiple
+//of n are thrown away, so that every residue is equally likely.
+func (s *Source) Uint64n(n uint64) (uint64, error) {
+	if n == 0 {
+		return 0, errors.New("Uint64n needs a positive bound")
+	}
+	limit := ^uint64(0) - (^uint64(0)%n+1)%n //largest value of the last full range of n
+	for {
+		v := s.Uint64()
+		if v <= limit {
+			return v % n, nil
+		}
+	}
+}
+
+//Sample returns k distinct integers of [0,n), in the order they were drawn. It runs the first k steps of Fisher-Yates.
+func (s *Source) Sample(n int, k int) ([]int, error) {
+	if k < 0 || k > n {
+		return nil, errors.New("Can't sample more elements than there are")
+	}
+	//only the swapped positions are stored, the others still hold their own index
+	swapped := make(map[int]int)
+	at := func(i int) int {
+		if v, ok := swapped[i]; ok {
+			return v
+		}
+		return i
+	}
+	sample := make([]int, k)
+	for i := 0; i < k; i++ {
+		j, err := s.Intn(n - i)
+		if err != nil {
+			return nil, err
+		}
+		j += i
+		sample[i] = at(j)
+		swapped[j] = at(i)
+	}
+	return sample, nil
+}
+
+//Permutation returns a uniformly distributed permutation of [0,n)
+func (s *Source) Permutation(n int) ([]int, error) {
+	if n < 0 {
+		return nil, errors.New("Permutation needs a non-negative size")
+	}
+	return s.Sample(n, n)
+}
@@ -0,0 +1,76 @@
+package sampling
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSourceDeterministic(t *testing.T) {
+	a, _ := NewSource([]byte("random"), "committee").Permutation(50)
+	b, _ := NewSource([]byte("random"), "committee").Permutation(50)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("The same string and label gave two permutations")
+	}
+	c, _ := NewSource([]byte("random"), "leader").Permutation(50)
+	if reflect.DeepEqual(a, c) {
+		t.Fatal("Two labels gave the same permutation")
+	}
+}
+
+func TestIntn(t *testing.T) {
+	s := NewSource([]byte("random"), "intn")
+	if _, err := s.Intn(0); err == nil {
+		t.Fatal("Intn accepted an empty range")
+	}
+	//every value of a small range comes up about as often
+	counts := make([]int, 6)
+	draws := 60000
+	for i := 0; i < draws; i++ {
+		v, err := s.Intn(len(counts))
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[v]++
+	}
+	for v, c := range counts {
+		if c < draws/6*9/10 || c > draws/6*11/10 {
+			t.Fatalf("Value %d drawn %d times out of %d", v, c, draws)
+		}
+	}
+	//a bound just above 2^63 rejects about half of the draws but must still end
+	for i := 0; i < 100; i++ {
+		if v, _ := s.Uint64n(1<<63 + 1); v > 1<<63 {
+			t.Fatal("Value out of range", v)
+		}
+	}
+}
+
+func TestSample(t *testing.T) {
+	s := NewSource([]byte("random"), "sample")
+	if _, err := s.Sample(5, 6); err == nil {
+		t.Fatal("Sample accepted k > n")
+	}
+	sample, err := s.Sample(1000, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[int]bool)
+	for _, v := range sample {
+		if v < 0 || v >= 1000 || seen[v] {
+			t.Fatal("Wrong sample", sample)
+		}
+		seen[v] = true
+	}
+
+	perm, err := s.Permutation(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Ints(perm)
+	for i, v := range perm {
+		if i != v {
+			t.Fatal("Not a permutation")
+		}
+	}
+}
@@ -0,0 +1,21 @@
+/*Package session runs many PVSS sessions (see randsharepvss) at once on the same roster, through a service on
+every conode.
+
+A session is a protocol instance of its own, so its state is isolated from the other sessions, and it is known
+by its SessionID, the hash of the roster, the purpose and the time. Each conode admits a session when its
+first announce sets it up and refuses a SessionID that runs or ran already, so a session can't be run twice
+to pick the best string. A session whose time is further than SetMaxAge (DefaultMaxAge by default) from the
+clock of the conode is refused too, so the SessionIDs that ran are only kept until they get that old. The
+instance of a refused session is closed at once.
+
+Run, or the StartSession request of a client, starts a session with the conode as the root and returns its
+collective string. It waits while the conode runs SetLimit sessions (DefaultLimit by default), those started
+by the other conodes included. The sessions of the others are never held back: a conode waiting for the
+sessions it takes part in could wait for another one that waits for it.
+
+A session that doesn't end within SetTimeout (DefaultTimeout by default) frees its place; the instance of
+every session is closed at the timeout, so that the conode still answers the slower nodes until then.
+
+Plain randshare has no SessionID, only the PVSS sessions are run by the service.
+*/
+package session
@@ -0,0 +1,241 @@
+package session
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dedis/student_17_randomness/randshare_with_pvss"
+	"gopkg.in/dedis/onet.v1"
+	"gopkg.in/dedis/onet.v1/log"
+)
+
+//ServiceName is the name of the session service
+const ServiceName = "RandShareSessions"
+
+//DefaultLimit is the number of sessions a conode runs at once unless SetLimit changes it
+const DefaultLimit = 16
+
+//DefaultTimeout is the time a session may take unless SetTimeout changes it
+const DefaultTimeout = time.Minute
+
+//DefaultMaxAge is how far the Time of a session may be from our clock unless SetMaxAge changes it
+const DefaultMaxAge = time.Hour
+
+//hooks lets the tests watch the admissions, it is nil otherwise
+var hooks struct {
+	admitted func(conode string, running int) //called with the number of sessions running once one is admitted
+}
+
+func init() {
+	if _, err := onet.RegisterNewService(ServiceName, newService); err != nil {
+		log.Fatal(err)
+	}
+}
+
+//Service runs the PVSS sessions of its conode. Every session is its own protocol instance and is known by its
+//SessionID, which can only be used once.
+type Service struct {
+	*onet.ServiceProcessor
+	mutex    sync.Mutex
+	cond     *sync.Cond
+	limit    int                 //the number of sessions running before Run waits
+	timeout  time.Duration       //the time a session may take
+	maxAge   time.Duration       //how far the Time of a session may be from our clock
+	running  map[string]*session //the sessions running, by SessionID in hex
+	ended    map[string]int64    //the SessionIDs that ran already with their Time, until they are too old to run
+	starting int                 //the sessions of Run that got their turn but aren't set up yet
+}
+
+func newService(c *onet.Context) onet.Service {
+	s := &Service{
+		ServiceProcessor: onet.NewServiceProcessor(c),
+		limit:            DefaultLimit,
+		timeout:          DefaultTimeout,
+		maxAge:           DefaultMaxAge,
+		running:          make(map[string]*session),
+		ended:            make(map[string]int64),
+	}
+	s.cond = sync.NewCond(&s.mutex)
+	if err := s.RegisterHandler(s.StartSession); err != nil {
+		log.Error("Couldn't register the session handler:", err)
+	}
+	return s
+}
+
+//SetLimit sets the number of sessions the conode runs before Run waits for one to end
+func (s *Service) SetLimit(limit int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.limit = limit
+	s.cond.Broadcast()
+}
+
+//SetTimeout sets the time a session may take, the instance of a session is closed once it is over
+func (s *Service) SetTimeout(timeout time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.timeout = timeout
+}
+
+//SetMaxAge sets how far the Time of a session may be from our clock, the sessions further away are refused.
+//The SessionIDs that ran are kept as long as their Time could be admitted.
+func (s *Service) SetMaxAge(maxAge time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.maxAge = maxAge
+}
+
+//Running returns the number of sessions running at our conode, started by us or by others
+func (s *Service) Running() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.running)
+}
+
+//StartSession runs a session with our conode as the root and returns its collective string
+func (s *Service) StartSession(req *StartSession) (*SessionDone, onet.ClientError) {
+	result, err := s.Run(req)
+	if err != nil {
+		return nil, onet.NewClientError(err)
+	}
+	return &SessionDone{SessionID: result.SessionID, Random: result.Random}, nil
+}
+
+//Run runs a session with our conode as the root. It first waits until fewer than the limit of sessions run at
+//our conode, those started by the other conodes included.
+func (s *Service) Run(req *StartSession) (*Result, error) {
+	if req.Roster == nil {
+		return nil, errors.New("No roster")
+	}
+	tree := req.Roster.GenerateNaryTreeWithRoot(2, s.ServerIdentity())
+	if tree == nil {
+		return nil, errors.New("We are not in the roster")
+	}
+	nodes := len(req.Roster.List)
+	faulty := req.Faulty
+	if faulty < 0 {
+		faulty = (nodes - 1) / 3
+	}
+
+	s.mutex.Lock()
+	for len(s.running)+s.starting >= s.limit {
+		s.cond.Wait()
+	}
+	s.starting++
+	s.mutex.Unlock()
+
+	sess, err := s.setup(tree, nodes, faulty, req)
+	s.mutex.Lock()
+	s.starting--
+	s.cond.Broadcast()
+	s.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := sess.rs.Start(); err != nil {
+		return nil, err
+	}
+
+	<-sess.ended
+	random, transcript, err := sess.rs.Random()
+	if err != nil {
+		return nil, fmt.Errorf("Session %s didn't end: %v", sess.id, err)
+	}
+	return &Result{SessionID: transcript.SessionID, Random: random, Transcript: transcript}, nil
+}
+
+//setup creates the instance of a session we start and sets it up, which admits it
+func (s *Service) setup(tree *onet.Tree, nodes int, faulty int, req *StartSession) (*session, error) {
+	pi, err := s.CreateProtocol(randsharepvss.Name, tree)
+	if err != nil {
+		return nil, err
+	}
+	sess := s.watch(pi.(*randsharepvss.RandShare))
+	if err := sess.rs.Setup(nodes, faulty, req.Purpose, req.Time, req.Suite, req.Scheme); err != nil {
+		sess.rs.TreeNodeInstance.Done()
+		return nil, err
+	}
+	sess.rs.SetTree(req.Tree)
+	return sess, nil
+}
+
+//NewProtocol creates the instances of the sessions started by the other conodes, they are admitted once their
+//first announce sets them up
+func (s *Service) NewProtocol(tn *onet.TreeNodeInstance, conf *onet.GenericConfig) (onet.ProtocolInstance, error) {
+	if tn.ProtocolName() != randsharepvss.Name {
+		return nil, nil
+	}
+	pi, err := randsharepvss.NewRandShare(tn)
+	if err != nil {
+		return nil, err
+	}
+	return s.watch(pi.(*randsharepvss.RandShare)).rs, nil
+}
+
+//watch makes rs admit its session through us
+func (s *Service) watch(rs *randsharepvss.RandShare) *session {
+	sess := &session{rs: rs, ended: make(chan struct{})}
+	rs.Admit = func(sessionID []byte, time int64) error {
+		return s.admit(sess, sessionID, time)
+	}
+	return sess
+}
+
+//admit refuses a SessionID that runs or ran already, or whose time t is too far from our clock, otherwise the
+//session runs until it ends or times out
+func (s *Service) admit(sess *session, sessionID []byte, t int64) error {
+	id := hex.EncodeToString(sessionID)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	s.forget(now)
+	if age := now.Sub(time.Unix(t, 0)); age > s.maxAge || age < -s.maxAge {
+		return fmt.Errorf("Session %s has a time %s away from ours", id, age)
+	}
+	_, running := s.running[id]
+	_, ran := s.ended[id]
+	if running || ran {
+		return fmt.Errorf("Session %s was already started", id)
+	}
+	sess.id, sess.time = id, t
+	s.running[id] = sess
+	if hooks.admitted != nil {
+		hooks.admitted(s.ServerIdentity().Address.String(), len(s.running))
+	}
+	go s.end(sess, s.timeout)
+	return nil
+}
+
+//forget drops the SessionIDs that ran whose time is too old to be admitted again. The mutex must be held.
+func (s *Service) forget(now time.Time) {
+	for id, t := range s.ended {
+		if now.Sub(time.Unix(t, 0)) > s.maxAge {
+			delete(s.ended, id)
+		}
+	}
+}
+
+//end waits for the session to end or time out and frees its place. The instance is closed once the session
+//timed out, so that we still answer the nodes that are slower than us until then.
+func (s *Service) end(sess *session, timeout time.Duration) {
+	deadline := time.After(timeout)
+	select {
+	case <-sess.rs.Done:
+	case <-deadline:
+		log.Lvl2("Session", sess.id, "timed out")
+		deadline = nil
+	}
+	s.mutex.Lock()
+	delete(s.running, sess.id)
+	s.ended[sess.id] = sess.time
+	s.cond.Broadcast()
+	s.mutex.Unlock()
+	close(sess.ended)
+	if deadline != nil {
+		<-deadline
+	}
+	sess.rs.TreeNodeInstance.Done()
+}
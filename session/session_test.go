@@ -0,0 +1,189 @@
+package session
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dedis/student_17_randomness/randshare_with_pvss"
+	"gopkg.in/dedis/onet.v1"
+)
+
+func services(local *onet.LocalTest, servers []*onet.Server) []*Service {
+	var list []*Service
+	for _, s := range local.GetServices(servers, onet.ServiceFactory.ServiceID(ServiceName)) {
+		list = append(list, s.(*Service))
+	}
+	return list
+}
+
+//TestSessions runs dozens of overlapping sessions started by every conode, each must end with its own string
+func TestSessions(t *testing.T) {
+	nodes, sessions, limit := 5, 30, 4
+	local := onet.NewLocalTest()
+	servers, roster, _ := local.GenTree(nodes, true)
+	defer local.CloseAll()
+	list := services(local, servers)
+	for _, s := range list {
+		s.SetLimit(limit)
+	}
+
+	var mutex sync.Mutex
+	peak := make(map[string]int)
+	hooks.admitted = func(conode string, running int) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if running > peak[conode] {
+			peak[conode] = running
+		}
+	}
+	defer func() { hooks.admitted = nil }()
+
+	results := make([]*Result, sessions)
+	errs := make([]error, sessions)
+	var wg sync.WaitGroup
+	for i := 0; i < sessions; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = list[i%2].Run(&StartSession{Roster: roster, Purpose: fmt.Sprint("session ", i),
+				Time: time.Now().Unix(), Faulty: -1, Suite: randsharepvss.Ed25519, Scheme: randsharepvss.Feldman, Tree: i%3 == 0})
+		}(i)
+	}
+	wg.Wait()
+
+	strings := make(map[string]bool)
+	for i, r := range results {
+		if errs[i] != nil {
+			t.Fatal("Session", i, errs[i])
+		}
+		if err := randsharepvss.Verify(r.Random, r.Transcript); err != nil {
+			t.Fatal("Session", i, err)
+		}
+		if strings[string(r.Random)] {
+			t.Fatal("Two sessions gave the same string")
+		}
+		strings[string(r.Random)] = true
+	}
+	//the conodes that started no session take part in those of the others, as many as run at once
+	for i, s := range list[:2] {
+		if p := peak[s.ServerIdentity().Address.String()]; p > 2*limit {
+			t.Fatal("Conode", i, "ran", p, "sessions at once")
+		}
+	}
+	if peak[list[nodes-1].ServerIdentity().Address.String()] < 2 {
+		t.Fatal("The sessions didn't overlap")
+	}
+}
+
+//TestLimit starts the sessions from a single conode, which runs no more than its limit at once
+func TestLimit(t *testing.T) {
+	nodes, sessions, limit := 4, 12, 3
+	local := onet.NewLocalTest()
+	servers, roster, _ := local.GenTree(nodes, true)
+	defer local.CloseAll()
+	root := services(local, servers)[0]
+	root.SetLimit(limit)
+
+	peak := 0
+	var mutex sync.Mutex
+	hooks.admitted = func(conode string, running int) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if conode == root.ServerIdentity().Address.String() && running > peak {
+			peak = running
+		}
+	}
+	defer func() { hooks.admitted = nil }()
+
+	var wg sync.WaitGroup
+	for i := 0; i < sessions; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := root.Run(&StartSession{Roster: roster, Purpose: fmt.Sprint("limit ", i), Time: time.Now().Unix(), Faulty: 1,
+				Suite: randsharepvss.Ed25519, Scheme: randsharepvss.Feldman}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if peak > limit || peak == 0 {
+		t.Fatal("Wrong number of sessions at once", peak)
+	}
+	if root.Running() != 0 {
+		t.Fatal("Sessions still running", root.Running())
+	}
+}
+
+//TestReplay runs a session twice: the second run is refused
+func TestReplay(t *testing.T) {
+	local := onet.NewLocalTest()
+	servers, roster, _ := local.GenTree(4, true)
+	defer local.CloseAll()
+	list := services(local, servers)
+
+	req := &StartSession{Roster: roster, Purpose: "replay", Time: time.Now().Unix(), Faulty: 1, Suite: randsharepvss.Ed25519, Scheme: randsharepvss.Feldman}
+	if _, err := list[0].Run(req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := list[0].Run(req); err == nil {
+		t.Fatal("The root ran the session twice")
+	}
+
+	//the session replayed by a root without the service is refused by the other conodes
+	tree := roster.GenerateNaryTreeWithRoot(2, list[0].ServerIdentity())
+	pi, err := list[0].CreateProtocol(randsharepvss.Name, tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := pi.(*randsharepvss.RandShare)
+	defer rs.TreeNodeInstance.Done()
+	if err := rs.Setup(4, 1, req.Purpose, req.Time, req.Suite, req.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rs.Done:
+		t.Fatal("The session ran twice")
+	case <-time.After(time.Second):
+	}
+}
+
+//TestMaxAge refuses the sessions whose time is too far from ours, and forgets the SessionIDs that got too old
+func TestMaxAge(t *testing.T) {
+	local := onet.NewLocalTest()
+	servers, roster, _ := local.GenTree(4, true)
+	defer local.CloseAll()
+	list := services(local, servers)
+	for _, s := range list {
+		s.SetMaxAge(2 * time.Second)
+	}
+
+	req := &StartSession{Roster: roster, Purpose: "old", Time: time.Now().Unix(), Faulty: 1, Suite: randsharepvss.Ed25519, Scheme: randsharepvss.Feldman}
+	result, err := list[0].Run(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(4 * time.Second)
+	if _, err := list[0].Run(req); err == nil {
+		t.Fatal("A session too old was run")
+	}
+	req.Purpose, req.Time = "new", time.Now().Unix()
+	if _, err := list[0].Run(req); err != nil {
+		t.Fatal(err)
+	}
+	id := hex.EncodeToString(result.SessionID)
+	for i, s := range list {
+		s.mutex.Lock()
+		_, kept := s.ended[id]
+		s.mutex.Unlock()
+		if kept {
+			t.Fatal("Conode", i, "didn't forget the old session")
+		}
+	}
+}
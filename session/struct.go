@@ -0,0 +1,44 @@
+package session
+
+import (
+	"github.com/dedis/student_17_randomness/randshare_with_pvss"
+	"gopkg.in/dedis/onet.v1"
+	"gopkg.in/dedis/onet.v1/network"
+)
+
+func init() {
+	network.RegisterMessage(&StartSession{})
+	network.RegisterMessage(&SessionDone{})
+}
+
+//StartSession asks a conode to run a PVSS session as the root, it waits if the conode runs too many sessions
+type StartSession struct {
+	Roster  *onet.Roster //the nodes of the session, the conode must be one of them
+	Purpose string       //the purpose, with Time it makes the session unique
+	Time    int64
+	Faulty  int    //the number of faulty nodes, (n-1)/3 if negative
+	Suite   string //the suite of the PVSS (see randsharepvss.SuiteByName)
+	Scheme  string //the scheme of the PVSS, Feldman or Scrape
+	Tree    bool   //do the messages travel along the tree ? (see randsharepvss.SetTree)
+}
+
+//SessionDone is the collective string of a session
+type SessionDone struct {
+	SessionID []byte
+	Random    []byte
+}
+
+//Result is the outcome of a session started with Run
+type Result struct {
+	SessionID  []byte
+	Random     []byte
+	Transcript *randsharepvss.Transcript
+}
+
+//session is a PVSS instance of our conode, the root of the session or not
+type session struct {
+	id    string //the SessionID in hex once the instance is set up
+	time  int64  //the time of the session
+	rs    *randsharepvss.RandShare
+	ended chan struct{} //closed once the session ended or timed out
+}
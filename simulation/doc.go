@@ -0,0 +1,47 @@
+/*Command simulation runs the RandShare variants with onet/simul, on localhost or on deterlab (deter.toml).
+
+Every variant is registered as the simulation RandShare and the toml picks the one to run:
+	- Variant: plain (package randshare), pvss (package randsharepvss), demo or commitreveal (the baseline
+	  of package commitreveal), see variants.go
+	- Faulty: the number of faulty nodes, (Hosts-1)/3 by default, commitreveal has none
+	- Threshold: the number of shares recovering a secret; the protocols only support Faulty+1, so it can
+	  replace Faulty but a different value is refused
+	- Purpose: the purpose of the run, hashed into the session of pvss and demo
+	- Suite, Scheme and Tree: the suite (Ed25519 by default), the scheme (Feldman by default) and whether
+	  the messages travel along the tree, pvss only
+	- Crashed, Invalid, Delayed and Delay: the adversaries (see package adversary), the number of nodes that
+	  never answer, of dealers sending invalid shares and of nodes holding each phase for Delay milliseconds
+	- Timeout: the seconds a round may take, 10*Hosts by default
+	- PhaseTimeout: the milliseconds the nodes of plain and pvss wait for each phase before going on without
+	  the missing nodes, which are left out of n'; they wait for everyone by default
+	- Latency, Jitter, Bandwidth, Loss, Regions and LocalLatency: the emulated network (see package netem), the
+	  one way latency and jitter in milliseconds, the bandwidth of a link in Mbit/s and the packet loss rate of
+	  the links between regions, and the latency inside a region; the nodes talk directly without any of them
+	- Precompute: the number of dealings each node keeps ready, pvss only (see randsharepvss.Precompute)
+Like Hosts, any of them can be a column of the runs.
+
+simulation.toml runs plain randshare, pvss.toml and pvss_tree.toml the PVSS one broadcasting or along the
+tree, demo.toml the demo with several numbers of faulty nodes and commitreveal.toml the commit-reveal
+baseline:
+	go run . simulation.toml
+Each of the Rounds runs records tgen-randshare, the time to the collective string, tver-randshare, the time to
+verify it, and bw-randshare in test_data/<toml>.csv, along with completed, 1 if it ended before the timeout, and
+nprime, the number of dealers it kept. The average of completed is the completion rate.
+
+invalid.toml, delayed.toml and crashed.toml run pvss with adversaries. Without PhaseTimeout both protocols wait
+for the announce and the votes of every node, so a single crashed node stops them: crashed.toml sets it, the
+crashed dealers are then left out of n'. Invalid dealers are excluded and delayed nodes slow the run down. The
+extra cost is the difference with pvss.toml at the same Hosts:
+	go run ./report -baseline pvss pvss=simulation/test_data/pvss.csv invalid=simulation/test_data/invalid.csv
+
+wan.toml and pvss_wan.toml run plain randshare and pvss with their nodes spread over three regions 80ms apart,
+which the local runs leave out as their messages cross localhost. They compare like the others:
+	go run ./report wan=simulation/test_data/wan.csv pvss_wan=simulation/test_data/pvss_wan.csv
+
+pvss_precompute.toml runs pvss with every node keeping two dealings ready, the rounds after the first only bind
+them to their session; tgen-randshare compares with pvss.toml.
+
+The results of earlier runs are in test_data/randshare and test_data/pvss, the report command turns such CSVs
+into charts. The runs with adversaries have no results yet.
+*/
+package main
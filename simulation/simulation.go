@@ -0,0 +1,231 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/dedis/student_17_randomness/adversary"
+	"github.com/dedis/student_17_randomness/netem"
+	"github.com/dedis/student_17_randomness/randshare_with_pvss"
+	"gopkg.in/dedis/onet.v1"
+	"gopkg.in/dedis/onet.v1/log"
+	"gopkg.in/dedis/onet.v1/simul"
+	"gopkg.in/dedis/onet.v1/simul/monitor"
+)
+
+func init() {
+	onet.SimulationRegister("RandShare", NewRSSimulation)
+}
+
+// RSSimulation implements a RandShare simulation of any of the variants
+type RSSimulation struct {
+	onet.SimulationBFTree
+	Servers   int
+	Variant   string //plain, pvss, demo or commitreveal (see variants)
+	Faulty    int    //the number of faulty nodes (see faulty)
+	Threshold int    //the number of shares recovering a secret, the protocols only support faulty+1
+	Purpose   string
+	Suite     string //the suite of pvss (see randsharepvss.SuiteByName)
+	Scheme    string //the scheme of pvss, Feldman or Scrape
+	Tree      bool   //pvss sends the messages along the tree instead of broadcasting them
+	Crashed   int    //the number of nodes that never answer (see package adversary)
+	Invalid   int    //the number of dealers that send invalid shares
+	Delayed   int    //the number of nodes that hold each of their phases for Delay
+	Delay     int    //in milliseconds
+	Timeout   int    //the seconds a round may take, 10*Hosts by default
+	//the emulated network (see package netem), none without Latency, Bandwidth or Loss
+	Latency      int     //in milliseconds, one way between nodes of different regions
+	Jitter       int     //in milliseconds, the latency varies by up to Jitter either way
+	Bandwidth    float64 //in Mbit/s per link, uncapped with 0
+	Loss         float64 //the probability that a packet is lost
+	Regions      int     //the number of regions the nodes are spread over
+	LocalLatency int     //in milliseconds, one way between nodes of the same region
+	PhaseTimeout int     //in milliseconds, how long plain and pvss wait for each phase, forever with 0 (see randsharepvss.SetTimeout)
+	Precompute   int     //the number of dealings each node of pvss keeps ready (see randsharepvss.Precompute)
+}
+
+// NewRSSimulation creates a new RandShare simulation
+func NewRSSimulation(config string) (onet.Simulation, error) {
+	rss := &RSSimulation{Variant: "plain", Faulty: -1, Purpose: "Test", Suite: randsharepvss.Ed25519, Scheme: randsharepvss.Feldman}
+	_, err := toml.Decode(config, rss)
+	if err != nil {
+		return nil, err
+	}
+	if err := rss.check(); err != nil {
+		return nil, err
+	}
+	return rss, nil
+}
+
+//faulty returns Faulty, or its default if the toml leaves it out or sets it to -1: Threshold-1 if Threshold is
+//given, (Hosts-1)/3 otherwise. onet decodes the toml again once NewRSSimulation returns, so the defaults can't
+//be stored in the fields.
+func (rss *RSSimulation) faulty() int {
+	switch {
+	case rss.Faulty >= 0:
+		return rss.Faulty
+	case rss.Threshold > 0:
+		return rss.Threshold - 1
+	default:
+		return (rss.Hosts - 1) / 3
+	}
+}
+
+//check refuses the configurations the variant can't run
+func (rss *RSSimulation) check() error {
+	if _, ok := variants[rss.Variant]; !ok {
+		return fmt.Errorf("Unknown variant %q", rss.Variant)
+	}
+	faulty := rss.faulty()
+	if rss.Threshold != 0 && rss.Threshold != faulty+1 {
+		return fmt.Errorf("Threshold %d with %d faulty nodes, the protocols recover the secrets from faulty+1 shares", rss.Threshold, faulty)
+	}
+	if 3*faulty >= rss.Hosts {
+		return fmt.Errorf("%d faulty nodes out of %d hosts, there have to be less than a third", faulty, rss.Hosts)
+	}
+	if rss.Tree && rss.Variant != "pvss" {
+		return errors.New("Only the pvss variant sends its messages along the tree")
+	}
+	if rss.Crashed < 0 || rss.Invalid < 0 || rss.Delayed < 0 || rss.Delay < 0 {
+		return errors.New("Negative number of adversaries")
+	}
+	if rss.Crashed+rss.Invalid+rss.Delayed >= rss.Hosts {
+		return fmt.Errorf("%d adversaries out of %d hosts, the root has to be honest", rss.Crashed+rss.Invalid+rss.Delayed, rss.Hosts)
+	}
+	if (rss.Variant == "demo" || rss.Variant == "commitreveal") && rss.Crashed+rss.Invalid+rss.Delayed > 0 {
+		return fmt.Errorf("The %s variant has no adversaries", rss.Variant)
+	}
+	if rss.PhaseTimeout < 0 {
+		return errors.New("Negative phase timeout")
+	}
+	if rss.PhaseTimeout > 0 && (rss.Variant == "demo" || rss.Variant == "commitreveal") {
+		return fmt.Errorf("The %s variant has no phase timeout", rss.Variant)
+	}
+	if rss.Precompute < 0 || rss.Precompute > 0 && rss.Variant != "pvss" {
+		return errors.New("Only the pvss variant precomputes its dealings")
+	}
+	if n := rss.network(); n != nil {
+		if rss.Variant == "demo" || rss.Variant == "commitreveal" {
+			return fmt.Errorf("The %s variant has no emulated network", rss.Variant)
+		}
+		return n.Check()
+	}
+	return nil
+}
+
+//scenario returns the adversaries of the toml, nil if every node is honest
+func (rss *RSSimulation) scenario() *adversary.Scenario {
+	if rss.Crashed+rss.Invalid+rss.Delayed == 0 {
+		return nil
+	}
+	return &adversary.Scenario{Crashed: rss.Crashed, Invalid: rss.Invalid, Delayed: rss.Delayed, Delay: time.Duration(rss.Delay) * time.Millisecond}
+}
+
+//network returns the emulated network of the toml, nil if the nodes talk directly. The links of a region have
+//the LocalLatency and the bandwidth of the others, but neither jitter nor loss.
+func (rss *RSSimulation) network() *netem.Network {
+	if rss.Latency == 0 && rss.LocalLatency == 0 && rss.Bandwidth == 0 && rss.Loss == 0 {
+		return nil
+	}
+	bandwidth := rss.Bandwidth * 1e6 / 8
+	return &netem.Network{
+		Local: netem.Link{Latency: time.Duration(rss.LocalLatency) * time.Millisecond, Bandwidth: bandwidth},
+		Remote: netem.Link{
+			Latency:   time.Duration(rss.Latency) * time.Millisecond,
+			Jitter:    time.Duration(rss.Jitter) * time.Millisecond,
+			Bandwidth: bandwidth,
+			Loss:      rss.Loss,
+		},
+		Regions: rss.Regions,
+	}
+}
+
+// Setup configures a RandShare simulation with certain parameters
+func (rss *RSSimulation) Setup(dir string, hosts []string) (*onet.SimulationConfig, error) {
+	sim := new(onet.SimulationConfig)
+	rss.CreateRoster(sim, hosts, 2000)
+	err := rss.CreateTree(sim)
+	return sim, err
+}
+
+// Node makes the nodes of the process behave as the scenario of the toml says, across its network, and starts
+// precomputing the dealings of the node
+func (rss *RSSimulation) Node(config *onet.SimulationConfig) error {
+	adversary.Set(rss.scenario())
+	netem.Set(rss.network())
+	if rss.Precompute > 0 {
+		suite, err := randsharepvss.SuiteByName(rss.Suite)
+		if err != nil {
+			return err
+		}
+		randsharepvss.Precompute(config.Server.ServerIdentity.Address.String(), suite, config.Tree.Roster.Publics(), rss.faulty()+1, rss.Precompute)
+	}
+	return rss.SimulationBFTree.Node(config)
+}
+
+// Run initiates Rounds RandShare runs
+func (rss *RSSimulation) Run(config *onet.SimulationConfig) error {
+	for round := 0; round < rss.Rounds || round == 0; round++ {
+		log.Lvl1("Starting round", round)
+		if err := rss.round(config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//round runs the protocol once. Besides the time and bandwidth, it records whether the run ended before the
+//timeout (completed, its average being the completion rate) and the number n' of dealers it kept (nprime).
+func (rss *RSSimulation) round(config *onet.SimulationConfig) error {
+	v := variants[rss.Variant]
+	randM := monitor.NewTimeMeasure("tgen-randshare")
+	bandW := monitor.NewCounterIOMeasure("bw-randshare", config.Server)
+	client, err := config.Overlay.CreateProtocol(v.protocol, config.Tree, onet.NilServiceID)
+	if err != nil {
+		return err
+	}
+	r, err := v.setup(rss, client)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Start(); err != nil {
+		log.Error("Error while starting protocol:", err)
+	}
+
+	timeout := time.Second * time.Duration(rss.Hosts) * 10
+	if rss.Timeout > 0 {
+		timeout = time.Second * time.Duration(rss.Timeout)
+	}
+	select {
+	case <-r.done:
+		randM.Record()
+		bandW.Record()
+		random, err := r.random()
+		if err != nil {
+			return err
+		}
+		log.Lvlf1("RandShare %s - done\nCollective string : %x", rss.Variant, random)
+		log.Lvlf1("RandShare %s - collective randomness: ok", rss.Variant)
+
+		verifyM := monitor.NewTimeMeasure("tver-randshare")
+		if err := r.verify(random); err != nil {
+			return err
+		}
+		verifyM.Record()
+		log.Lvlf1("RandShare %s - verification: ok", rss.Variant)
+		monitor.RecordSingleMeasure("completed", 1)
+		monitor.RecordSingleMeasure("nprime", float64(r.nPrime()))
+
+	case <-time.After(timeout):
+		log.Print("RandShare - time out")
+		monitor.RecordSingleMeasure("completed", 0)
+	}
+	return nil
+}
+
+func main() {
+	simul.Start()
+}
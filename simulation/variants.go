@@ -0,0 +1,128 @@
+package main
+
+import (
+	"time"
+
+	"github.com/dedis/student_17_randomness/commitreveal"
+	"github.com/dedis/student_17_randomness/demo"
+	"github.com/dedis/student_17_randomness/randshare"
+	"github.com/dedis/student_17_randomness/randshare_with_pvss"
+	"gopkg.in/dedis/onet.v1"
+)
+
+//variant is a protocol the simulation can run: the name it is registered under and how to set up its root
+type variant struct {
+	protocol string
+	setup    func(rss *RSSimulation, pi onet.ProtocolInstance) (*run, error)
+}
+
+//run is a protocol set up at the root: done is signalled once the collective string is ready, random returns it,
+//verify checks it against the transcript of the run and nPrime counts the dealers the transcript kept
+type run struct {
+	done   chan bool
+	random func() ([]byte, error)
+	verify func(random []byte) error
+	nPrime func() int
+}
+
+//variants are the values of Variant in the toml, a new variant only needs an entry here
+var variants = map[string]variant{
+	"plain": {randshare.Name, setupPlain},
+	"pvss":  {randsharepvss.Name, setupPVSS},
+	"demo":  {demo.Name, setupDemo},
+
+	"commitreveal": {commitreveal.Name, setupCommitReveal},
+}
+
+func setupPlain(rss *RSSimulation, pi onet.ProtocolInstance) (*run, error) {
+	rs := pi.(*randshare.RandShare)
+	if err := rs.Setup(rss.Hosts, rss.faulty(), rss.Purpose); err != nil {
+		return nil, err
+	}
+	rs.SetTimeout(time.Duration(rss.PhaseTimeout) * time.Millisecond)
+	var transcript *randshare.Transcript
+	return &run{
+		done: rs.Done,
+		random: func() (random []byte, err error) {
+			random, transcript, err = rs.Random()
+			return random, err
+		},
+		verify: func(random []byte) error { return randshare.Verify(random, transcript) },
+		nPrime: func() int {
+			n := 0
+			for _, tracked := range transcript.Tracker {
+				n += tracked
+			}
+			return n
+		},
+	}, nil
+}
+
+func setupPVSS(rss *RSSimulation, pi onet.ProtocolInstance) (*run, error) {
+	rs := pi.(*randsharepvss.RandShare)
+	if err := rs.Setup(rss.Hosts, rss.faulty(), rss.Purpose, time.Now().Unix(), rss.Suite, rss.Scheme); err != nil {
+		return nil, err
+	}
+	rs.SetTree(rss.Tree)
+	rs.SetTimeout(time.Duration(rss.PhaseTimeout) * time.Millisecond)
+	var transcript *randsharepvss.Transcript
+	return &run{
+		done: rs.Done,
+		random: func() (random []byte, err error) {
+			random, transcript, err = rs.Random()
+			return random, err
+		},
+		verify: func(random []byte) error { return randsharepvss.Verify(random, transcript) },
+		nPrime: func() int {
+			n := 0
+			for _, vote := range transcript.Votes {
+				if vote.Vote > transcript.Faulty {
+					n++
+				}
+			}
+			return n
+		},
+	}, nil
+}
+
+func setupDemo(rss *RSSimulation, pi onet.ProtocolInstance) (*run, error) {
+	rs := pi.(*demo.RandShare)
+	if err := rs.Setup(rss.Hosts, rss.faulty(), rss.Purpose, time.Now().Unix()); err != nil {
+		return nil, err
+	}
+	var transcript *demo.Transcript
+	return &run{
+		done: rs.Done,
+		random: func() (random []byte, err error) {
+			random, transcript, err = rs.Random()
+			return random, err
+		},
+		verify: func(random []byte) error { return demo.Verify(random, transcript) },
+		nPrime: func() int {
+			n := 0
+			for _, vote := range transcript.Votes {
+				if vote.Vote > transcript.Faulty {
+					n++
+				}
+			}
+			return n
+		},
+	}, nil
+}
+
+func setupCommitReveal(rss *RSSimulation, pi onet.ProtocolInstance) (*run, error) {
+	cr := pi.(*commitreveal.CommitReveal)
+	if err := cr.Setup(rss.Hosts, rss.Purpose, time.Now().Unix()); err != nil {
+		return nil, err
+	}
+	var transcript *commitreveal.Transcript
+	return &run{
+		done: cr.Done,
+		random: func() (random []byte, err error) {
+			random, transcript, err = cr.Random()
+			return random, err
+		},
+		verify: func(random []byte) error { return commitreveal.Verify(random, transcript) },
+		nPrime: func() int { return len(transcript.Reveals) }, //every node has to reveal
+	}, nil
+}
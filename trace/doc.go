@@ -0,0 +1,13 @@
+/*Package trace records the steps of the RandShare protocols as structured events, so that a run can be
+reconstructed after the fact instead of reading the logs of every node.
+
+Each protocol instance has a Tracer that stamps its events with the protocol, the session (the SessionID of
+PVSS in hex, the onet round of plain randshare), the index of the node, the phase, the peer and the outcome,
+e.g. a pvss node 3 in phase reveal, peer 5, outcome "reply verified", detail "4 shares, 1 bad".
+
+The events go to the Sink given to SetSink, none by default, which costs a nil check per event:
+	- JSONSink writes one JSON object per line, ReadJSON reads them back sorted by time
+	- Recorder keeps them in memory for the tests
+Timeline prints the events of one session in order, one line per event.
+*/
+package trace
@@ -0,0 +1,188 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+//NoPeer is the peer of the events that don't involve another node
+const NoPeer = -1
+
+//Event is one step of a protocol run at one node
+type Event struct {
+	Time     time.Time `json:"time"`
+	Protocol string    `json:"protocol"`         //randshare or pvss
+	Session  string    `json:"session"`          //the same at every node of the run
+	Node     int       `json:"node"`             //the index of the node in the roster
+	Phase    string    `json:"phase"`            //setup, deal, vote, commit, reveal or done
+	Peer     int       `json:"peer"`             //the other node of the event, NoPeer if there is none
+	Outcome  string    `json:"outcome"`          //what happened, e.g. sent, received, invalid, recovered
+	Detail   string    `json:"detail,omitempty"` //the error of an invalid message, a count...
+}
+
+//Sink receives the events, it must be safe for concurrent use
+type Sink interface {
+	Record(e *Event)
+}
+
+var sink struct {
+	sync.RWMutex
+	Sink
+}
+
+//SetSink sends the events of every protocol run of the process to s, nil drops them (the default)
+func SetSink(s Sink) {
+	sink.Lock()
+	defer sink.Unlock()
+	sink.Sink = s
+}
+
+//current returns the sink, nil if tracing is off
+func current() Sink {
+	sink.RLock()
+	defer sink.RUnlock()
+	return sink.Sink
+}
+
+//Tracer records the events of one node in one run, a nil Tracer records nothing (e.g. before Setup)
+type Tracer struct {
+	protocol string
+	session  string
+	node     int
+}
+
+//New returns the tracer of node in the run session of protocol
+func New(protocol string, session string, node int) *Tracer {
+	return &Tracer{protocol: protocol, session: session, node: node}
+}
+
+//Event records an event without detail
+func (t *Tracer) Event(phase string, peer int, outcome string) {
+	t.record(phase, peer, outcome, "")
+}
+
+//Eventf records an event, the detail is only formatted when there is a sink
+func (t *Tracer) Eventf(phase string, peer int, outcome string, format string, args ...interface{}) {
+	if t == nil || current() == nil {
+		return
+	}
+	t.record(phase, peer, outcome, fmt.Sprintf(format, args...))
+}
+
+func (t *Tracer) record(phase string, peer int, outcome string, detail string) {
+	s := current()
+	if t == nil || s == nil {
+		return
+	}
+	s.Record(&Event{
+		Time:     time.Now(),
+		Protocol: t.protocol,
+		Session:  t.session,
+		Node:     t.node,
+		Phase:    phase,
+		Peer:     peer,
+		Outcome:  outcome,
+		Detail:   detail,
+	})
+}
+
+//JSONSink writes every event as one line of JSON
+type JSONSink struct {
+	mutex sync.Mutex
+	w     *bufio.Writer
+	enc   *json.Encoder
+}
+
+//NewJSONSink returns a sink writing to w, Flush must be called before w is read
+func NewJSONSink(w io.Writer) *JSONSink {
+	b := bufio.NewWriter(w)
+	return &JSONSink{w: b, enc: json.NewEncoder(b)}
+}
+
+//Record writes e
+func (s *JSONSink) Record(e *Event) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.enc.Encode(e) //an Event always encodes, the write errors show up in Flush
+}
+
+//Flush writes the buffered events
+func (s *JSONSink) Flush() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.w.Flush()
+}
+
+//ReadJSON reads the events written by a JSONSink, sorted by time
+func ReadJSON(r io.Reader) ([]*Event, error) {
+	var events []*Event
+	dec := json.NewDecoder(r)
+	for {
+		e := &Event{}
+		if err := dec.Decode(e); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events, nil
+}
+
+//Recorder keeps the events in memory, for the tests
+type Recorder struct {
+	mutex  sync.Mutex
+	events []*Event
+}
+
+//Record keeps e
+func (r *Recorder) Record(e *Event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, e)
+}
+
+//Events returns the events recorded so far that match filter, all of them if filter is nil
+func (r *Recorder) Events(filter func(*Event) bool) []*Event {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var events []*Event
+	for _, e := range r.events {
+		if filter == nil || filter(e) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+//Timeline writes the events of session one per line, in the order they happened, so that a run can be followed
+//node by node
+func Timeline(w io.Writer, events []*Event, session string) error {
+	var start time.Time
+	for _, e := range events {
+		if e.Session != session {
+			continue
+		}
+		if start.IsZero() {
+			start = e.Time
+		}
+		peer := "-"
+		if e.Peer != NoPeer {
+			peer = fmt.Sprint(e.Peer)
+		}
+		line := fmt.Sprintf("%10.3fms node %-3d %-7s peer %-3s %s", float64(e.Time.Sub(start))/float64(time.Millisecond), e.Node, e.Phase, peer, e.Outcome)
+		if e.Detail != "" {
+			line += " (" + e.Detail + ")"
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
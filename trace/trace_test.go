@@ -0,0 +1,62 @@
+package trace
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestJSONSink(t *testing.T) {
+	defer SetSink(nil)
+	var b bytes.Buffer
+	sink := NewJSONSink(&b)
+	SetSink(sink)
+
+	tracer := New("test", "session", 2)
+	tracer.Event("deal", NoPeer, "dealt")
+	tracer.Eventf("deal", 1, "invalid announce", "Wrong announce sender %d", 7)
+	New("test", "other", 0).Event("deal", NoPeer, "dealt")
+	if err := sink.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(b.String(), "\n") != 3 {
+		t.Fatalf("Expected one line per event:\n%s", b.String())
+	}
+
+	events, err := ReadJSON(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 || events[1].Session != "session" || events[1].Node != 2 || events[1].Peer != 1 || events[1].Detail != "Wrong announce sender 7" {
+		t.Fatalf("Wrong events %+v", events)
+	}
+
+	var timeline bytes.Buffer
+	if err := Timeline(&timeline, events, "session"); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(timeline.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], "node 2") || !strings.HasSuffix(lines[1], "invalid announce (Wrong announce sender 7)") {
+		t.Fatalf("Wrong timeline:\n%s", timeline.String())
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	defer SetSink(nil)
+	r := &Recorder{}
+	New("test", "session", 0).Event("deal", NoPeer, "dropped") //no sink yet
+	var nilTracer *Tracer
+	nilTracer.Event("deal", NoPeer, "dropped")
+	SetSink(r)
+	nilTracer.Eventf("deal", NoPeer, "dropped", "%d", 1)
+	New("test", "session", 0).Event("deal", NoPeer, "dealt")
+	New("test", "session", 1).Event("vote", 0, "votes received")
+
+	if len(r.Events(nil)) != 2 {
+		t.Fatal("Wrong number of events", len(r.Events(nil)))
+	}
+	votes := r.Events(func(e *Event) bool { return e.Phase == "vote" })
+	if len(votes) != 1 || votes[0].Node != 1 || votes[0].Outcome != "votes received" {
+		t.Fatalf("Wrong events %+v", votes)
+	}
+}